@@ -0,0 +1,192 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+)
+
+// Variáveis de ambiente lidas por NewClientFromEnv
+const (
+	TableNameEnv   = "TABLE_NAME"
+	EnvironmentEnv = "ENVIRONMENT"
+	RegionEnv      = "AWS_REGION"
+	EndpointEnv    = "DYNAMODB_ENDPOINT"
+)
+
+type (
+	// Options são as opções de NewClientFromEnv
+	Options struct {
+		// Migrate executa o Migrate da tabela na primeira inicialização,
+		// apenas quando ENVIRONMENT for development
+		Migrate bool
+		// AllowDestructive e Protected são repassados para domain.Config
+		AllowDestructive bool
+		Protected        bool
+
+		Log logger.Log
+	}
+
+	// cacheEntry é o client em cache de uma configuração. O mu da entrada
+	// serializa a inicialização, com o Migrate, de uma mesma configuração
+	// sem bloquear as demais
+	cacheEntry struct {
+		mu     sync.Mutex
+		client *drivers.DynamoClient
+	}
+)
+
+var (
+	mu      sync.Mutex
+	clients = map[string]*cacheEntry{}
+)
+
+// NewClientFromEnv monta um DynamoClient a partir das variáveis de ambiente
+// da Lambda: TABLE_NAME, ENVIRONMENT, AWS_REGION e, opcionalmente,
+// DYNAMODB_ENDPOINT apontando para o DynamoDB Local.
+//
+// O client é mantido em cache entre invocações de uma Lambda aquecida,
+// um por tabela, ENVIRONMENT, endpoint, entidade e Options. Cada chamada
+// recebe uma cópia do client com o ctx da invocação.
+func NewClientFromEnv(ctx context.Context, entity interface{}, options Options) (*drivers.DynamoClient, error) {
+	tableName := os.Getenv(TableNameEnv)
+	if tableName == "" {
+		return nil, fmt.Errorf("%s environment variable is required", TableNameEnv)
+	}
+
+	if entity == nil {
+		return nil, errors.New("entity is required")
+	}
+
+	entry := cached(cacheKey(tableName, entity, options))
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.client == nil {
+		client, err := newClient(ctx, tableName, entity, options)
+		if err != nil {
+			return nil, err
+		}
+
+		entry.client = client
+	}
+
+	client := *entry.client
+	client.Ctx = ctx
+
+	return &client, nil
+}
+
+// Reset limpa o cache de clients. Útil para testes que alteram as
+// variáveis de ambiente
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	clients = map[string]*cacheEntry{}
+}
+
+// cacheKey identifica a configuração de um client. O Log não entra na
+// chave
+func cacheKey(tableName string, entity interface{}, options Options) string {
+	return fmt.Sprintf(
+		"%s|%s|%s|%s|%s|migrate=%t|allowDestructive=%t|protected=%t",
+		tableName,
+		os.Getenv(EnvironmentEnv),
+		os.Getenv(RegionEnv),
+		os.Getenv(EndpointEnv),
+		reflect.TypeOf(entity),
+		options.Migrate,
+		options.AllowDestructive,
+		options.Protected,
+	)
+}
+
+// cached retorna a entrada do cache da chave, criando uma vazia quando
+// não existe. O mu global protege apenas o mapa
+func cached(key string) *cacheEntry {
+	mu.Lock()
+	defer mu.Unlock()
+
+	entry, ok := clients[key]
+	if !ok {
+		entry = &cacheEntry{}
+		clients[key] = entry
+	}
+
+	return entry
+}
+
+// newClient carrega a configuração da AWS e inicializa o DynamoClient
+func newClient(ctx context.Context, tableName string, entity interface{}, options Options) (*drivers.DynamoClient, error) {
+	var loadOptions []func(*config.LoadOptions) error
+
+	if region := os.Getenv(RegionEnv); region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(region))
+	}
+
+	if endpoint := os.Getenv(EndpointEnv); endpoint != "" {
+		loadOptions = append(loadOptions, config.WithEndpointResolverWithOptions(
+			aws.EndpointResolverWithOptionsFunc(func(service, region string, _ ...interface{}) (aws.Endpoint, error) {
+				if service == dynamodb.ServiceID {
+					return aws.Endpoint{URL: endpoint, SigningRegion: region}, nil
+				}
+
+				return aws.Endpoint{}, &aws.EndpointNotFoundError{}
+			}),
+		))
+
+		// O DynamoDB Local aceita qualquer credencial
+		if os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+			loadOptions = append(loadOptions, config.WithCredentialsProvider(
+				credentials.NewStaticCredentialsProvider("local", "local", ""),
+			))
+		}
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("load aws config: %v", err)
+	}
+
+	tb, err := table.NewTable(tableName, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := domain.Environment(os.Getenv(EnvironmentEnv))
+
+	client := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:        tableName,
+		Environment:      environment,
+		Client:           dynamodb.NewFromConfig(cfg),
+		AllowDestructive: options.AllowDestructive,
+		Protected:        options.Protected,
+		Table:            tb,
+		Log:              options.Log,
+	})
+
+	if options.Migrate && environment.IsDev() {
+		migrator := *client
+		migrator.Ctx = ctx
+
+		if err = migrator.Migrate(); err != nil {
+			return nil, fmt.Errorf("migrate: %v", err)
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,121 @@
+package bootstrap_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/bootstrap"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	PK string `diinamo:"type:string;hash"`
+	SK string `diinamo:"type:string;range"`
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Run("should require TABLE_NAME", func(t *testing.T) {
+		bootstrap.Reset()
+		t.Setenv(bootstrap.TableNameEnv, "")
+
+		_, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{})
+		assert.EqualError(t, err, "TABLE_NAME environment variable is required")
+	})
+	t.Run("should build and cache the client across invocations", func(t *testing.T) {
+		bootstrap.Reset()
+		t.Setenv(bootstrap.TableNameEnv, "users")
+		t.Setenv(bootstrap.EnvironmentEnv, "development")
+		t.Setenv(bootstrap.RegionEnv, "us-east-1")
+		t.Setenv(bootstrap.EndpointEnv, "http://localhost:8000")
+
+		type ctxKey string
+		first := context.WithValue(context.Background(), ctxKey("invocation"), 1)
+		second := context.WithValue(context.Background(), ctxKey("invocation"), 2)
+
+		c1, err := bootstrap.NewClientFromEnv(first, user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+		c2, err := bootstrap.NewClientFromEnv(second, user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "users", *c1.TableName)
+		assert.Equal(t, "PK", *c1.HashKey)
+		assert.Equal(t, "development", string(c1.Environment))
+		assert.Same(t, c1.Client, c2.Client)
+		assert.Equal(t, first, c1.Ctx)
+		assert.Equal(t, second, c2.Ctx)
+	})
+	t.Run("should cache a client per environment and options", func(t *testing.T) {
+		bootstrap.Reset()
+		t.Setenv(bootstrap.TableNameEnv, "users")
+		t.Setenv(bootstrap.EnvironmentEnv, "development")
+		t.Setenv(bootstrap.RegionEnv, "us-east-1")
+		t.Setenv(bootstrap.EndpointEnv, "http://localhost:8000")
+
+		development, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+
+		allowed, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{AllowDestructive: true})
+		assert.Nil(t, err)
+		assert.NotSame(t, development.Client, allowed.Client)
+		assert.True(t, allowed.AllowDestructive)
+
+		t.Setenv(bootstrap.EnvironmentEnv, "production")
+
+		production, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+		assert.NotSame(t, development.Client, production.Client)
+		assert.Equal(t, "production", string(production.Environment))
+		assert.False(t, production.AllowDestructive)
+
+		t.Setenv(bootstrap.RegionEnv, "sa-east-1")
+
+		saoPaulo, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+		assert.NotSame(t, production.Client, saoPaulo.Client)
+	})
+	t.Run("should not block other clients while migrating", func(t *testing.T) {
+		fake := inmemory.NewServer()
+		defer fake.Close()
+
+		var once sync.Once
+		started, release := make(chan struct{}), make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() { close(started) })
+			<-release
+			fake.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		bootstrap.Reset()
+		t.Setenv(bootstrap.TableNameEnv, "users")
+		t.Setenv(bootstrap.EnvironmentEnv, "development")
+		t.Setenv(bootstrap.RegionEnv, "us-east-1")
+		t.Setenv(bootstrap.EndpointEnv, server.URL)
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+
+		migrated := make(chan error, 1)
+		go func() {
+			_, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{Migrate: true})
+			migrated <- err
+		}()
+
+		// O Migrate está em andamento, esperando o servidor
+		<-started
+
+		_, err := bootstrap.NewClientFromEnv(context.Background(), user{}, bootstrap.Options{})
+		assert.Nil(t, err)
+
+		close(release)
+		assert.Nil(t, <-migrated)
+
+		out, err := fake.NewClient().DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("users")})
+		assert.Nil(t, err)
+		assert.Equal(t, "users", *out.Table.TableName)
+	})
+}
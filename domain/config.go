@@ -10,6 +10,15 @@ type (
 		TableName   string
 		Environment Environment
 		Client      *dynamodb.Client
+
+		// AllowDestructive libera operações destrutivas em ambientes
+		// protegidos. Veja Environment.IsProtected
+		AllowDestructive bool
+		// Protected bloqueia as operações destrutivas em qualquer
+		// ambiente, não apenas em production. Ex: staging, ou Lambdas em
+		// que o ENVIRONMENT pode não estar definido
+		Protected bool
+
 		Table
 		logger.Log
 	}
@@ -1,21 +1,44 @@
 package domain
 
-import (
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-)
-
 type (
 	Action      string
 	Environment string
 
+	// Dynamo é o contrato do client de operações no DynamoDB.
+	//
+	// Seed aceita structs com as tags diinamo, itens no formato
+	// map[string]types.AttributeValue, FixtureFile e FixtureDir
 	Dynamo interface {
 		Perform(action Action, sql SqlExpression, result interface{}) error
 		NewExpressionBuilder() SqlExpression
+		Prepare(query string, args ...interface{}) (SqlExpression, error)
 		Migrate() error
-		Seed(items ...*dynamodb.PutItemInput) error
+		Seed(items ...interface{}) error
 	}
+
+	// FixtureFile é o caminho de um arquivo de fixtures JSON ou YAML
+	// aceito por Dynamo.Seed
+	FixtureFile string
+
+	// FixtureDir é o caminho de um diretório de fixtures aceito por
+	// Dynamo.Seed. Os arquivos da raiz são carregados em todos os
+	// ambientes e os arquivos de <FixtureDir>/<Environment> apenas no
+	// ambiente correspondente
+	FixtureDir string
 )
 
 func (e Environment) IsDev() bool {
 	return string(e) == "development"
 }
+
+// IsProduction indica se o ambiente é produção
+func (e Environment) IsProduction() bool {
+	return string(e) == "production"
+}
+
+// IsProtected indica se operações destrutivas devem ser bloqueadas no
+// ambiente. Apenas production é protegido. Outros ambientes, inclusive
+// um ENVIRONMENT vazio, são protegidos com Config.Protected
+func (e Environment) IsProtected() bool {
+	return e.IsProduction()
+}
@@ -5,11 +5,21 @@ import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 type (
 	Condition string
 
+	// Placeholders gera os placeholders de nomes (#n) e valores (:v) das
+	// expressões, evitando colisões e palavras reservadas do DynamoDB
+	Placeholders interface {
+		Name(attribute string) string
+		Value(value types.AttributeValue) string
+	}
+
 	WithCondition interface {
 		SetName(name string) WithCondition
 		Name() string
-		Value() types.AttributeValue
-		KeyCondition() string
+		// Value converte o valor da condição, com os erros do codec e de
+		// MarshalDiinamo
+		Value() (types.AttributeValue, error)
+		// KeyCondition monta a condição com os placeholders informados
+		KeyCondition(placeholders Placeholders) (string, error)
 	}
 
 	WithSortKeyCondition interface {
@@ -33,16 +43,28 @@ type (
 		SetIndex(indexName string) SqlExpression
 		Where(condition WithCondition) SqlExpression
 		AndWhere(keyCondition WithSortKeyCondition) SqlExpression
+		Filter(condition WithCondition) SqlExpression
+		FilterExpression() *string
 		ExpressionAttributeValues() map[string]types.AttributeValue
 		IndexName() *string
+		// ResolveIndex define o índice pelos atributos de Where e AndWhere
+		// quando SetIndex não foi chamado
+		ResolveIndex() error
 		Update(keys ...WithCondition) SqlExpression
 		UpdateExpression() *string
+		// Updates retorna as atribuições do SET de Update, com o nome do
+		// atributo no DynamoDB
+		Updates() []WithCondition
+		// Err retorna o primeiro erro de conversão dos valores da
+		// expressão
+		Err() error
 		AttributeNames() map[string]string
 
 		Key() map[string]types.AttributeValue
 		KeyCondition() *string
 
 		SetItem(item interface{}) SqlExpression
+		Item() interface{}
 		Names() map[string]types.AttributeValue
 		Values() map[string]types.AttributeValue
 	}
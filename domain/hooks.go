@@ -0,0 +1,34 @@
+package domain
+
+type (
+	// BeforePutHook é executado antes do PUT, com o item da expressão.
+	// Use um receiver de ponteiro para alterar o item antes da gravação.
+	// Ex: normalizar emails ou montar chaves derivadas
+	BeforePutHook interface {
+		BeforePut() error
+	}
+
+	// AfterGetHook é executado depois do GET, QUERY e SCAN em cada item
+	// encontrado
+	AfterGetHook interface {
+		AfterGet() error
+	}
+
+	// BeforeUpdateHook é executado antes do UPDATE, com a expressão que
+	// será enviada
+	BeforeUpdateHook interface {
+		BeforeUpdate(sql SqlExpression) error
+	}
+
+	// BeforeDeleteHook é executado antes do DELETE, com a expressão que
+	// será enviada
+	BeforeDeleteHook interface {
+		BeforeDelete(sql SqlExpression) error
+	}
+
+	// Validator é executado depois do BeforePut e antes de gravar o item
+	// no PUT e no UPDATE com SetItem
+	Validator interface {
+		Validate() error
+	}
+)
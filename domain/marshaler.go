@@ -0,0 +1,19 @@
+package domain
+
+import "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+type (
+	// Marshaler é implementado pelos tipos que definem a própria
+	// representação no DynamoDB, como value objects (Money, EmailAddress,
+	// ULID). É usado nos itens e nos valores de condições e updates.
+	// Ex: um Money gravado como N em centavos
+	Marshaler interface {
+		MarshalDiinamo() (types.AttributeValue, error)
+	}
+
+	// Unmarshaler é o inverso de Marshaler, executado na leitura dos
+	// itens. Use um receiver de ponteiro
+	Unmarshaler interface {
+		UnmarshalDiinamo(value types.AttributeValue) error
+	}
+)
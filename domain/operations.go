@@ -1,10 +1,39 @@
 package domain
 
 type (
+	// Statement é uma instrução PartiQL com os parâmetros posicionais (?)
+	// em Params, convertidos pelo reflector como os valores das expressões.
+	//
+	// NextToken e Limit paginam um SELECT: passe o token retornado por
+	// DynamoSQL.Execute na próxima chamada até que ele volte nil
+	Statement struct {
+		Query  string
+		Params []interface{}
+
+		NextToken      *string
+		Limit          int32
+		ConsistentRead bool
+	}
+
 	DynamoSQL interface {
 		Get(expression SqlExpression, target interface{}) error
 		Put(item interface{}, result interface{}) error
 		Update(expression interface{}, item interface{}, result interface{}) error
 		Delete(expression SqlExpression) error
+
+		// Execute executa uma instrução e decodifica os itens retornados
+		// em target, que pode ser nil. Retorna o token da próxima página
+		Execute(statement Statement, target interface{}) (*string, error)
+		// BatchExecute executa até 25 leituras ou escritas independentes.
+		// target recebe um item por instrução, vazio quando não há item
+		BatchExecute(statements []Statement, target interface{}) error
+		// ExecuteTransaction executa todas as instruções ou nenhuma.
+		// target recebe um item por instrução nas transações de leitura
+		ExecuteTransaction(statements []Statement, target interface{}) error
 	}
 )
+
+// NewStatement cria uma Statement com os parâmetros posicionais
+func NewStatement(query string, params ...interface{}) Statement {
+	return Statement{Query: query, Params: params}
+}
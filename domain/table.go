@@ -20,6 +20,7 @@ type (
 		Billing() types.BillingMode
 		ProvisionedThroughput() *types.ProvisionedThroughput
 		TableClass() types.TableClass
+		Validate() error
 
 		GetGSI() []types.GlobalSecondaryIndex
 		GetLSI() []types.LocalSecondaryIndex
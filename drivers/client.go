@@ -3,6 +3,7 @@ package drivers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -21,24 +22,34 @@ type (
 		HashKey   *string
 		RangeKey  *string
 
+		Environment      domain.Environment
+		AllowDestructive bool
+		Protected        bool
+
 		domain.Table
 		logger.Log
 	}
 )
 
+// DynamoClient deve sempre satisfazer o contrato domain.Dynamo
+var _ domain.Dynamo = &DynamoClient{}
+
 func NewDynamoClient(ctx context.Context, conf *domain.Config) *DynamoClient {
 	if conf.Log == nil {
 		conf.Log = logger.NewLogger()
 	}
 
 	dynamoClient := &DynamoClient{
-		Client:    conf.Client,
-		Ctx:       ctx,
-		TableName: aws.String(conf.TableName),
-		HashKey:   aws.String(conf.GetMetadata().GetHash()),
-		RangeKey:  aws.String(conf.GetMetadata().GetHash()),
-		Table:     conf.Table,
-		Log:       conf.Log,
+		Client:           conf.Client,
+		Ctx:              ctx,
+		TableName:        aws.String(conf.TableName),
+		HashKey:          aws.String(conf.GetMetadata().GetHash()),
+		RangeKey:         aws.String(conf.GetMetadata().GetRange()),
+		Environment:      conf.Environment,
+		AllowDestructive: conf.AllowDestructive,
+		Protected:        conf.Protected,
+		Table:            conf.Table,
+		Log:              conf.Log,
 	}
 
 	conf.Log.Info("dynamo client connected\n")
@@ -46,12 +57,34 @@ func NewDynamoClient(ctx context.Context, conf *domain.Config) *DynamoClient {
 	return dynamoClient
 }
 
+// Perform executa a action com a expressão. Os hooks de domain
+// implementados pela entidade (BeforePut, AfterGet, BeforeUpdate,
+// BeforeDelete e Validate) são executados ao redor da action e as regras
+// de validação das tags diinamo antes das escritas. Veja RunBeforeHooks,
+// RunValidation e RunAfterHooks
 func (d *DynamoClient) Perform(action domain.Action, sql domain.SqlExpression, target interface{}) error {
 	d.Log.Info("performing %s action\n", action)
 	if reflect.TypeOf(target).Kind() != reflect.Ptr {
 		return errors.New("target must be a pointer")
 	}
 
+	if err := RunBeforeHooks(action, sql, target); err != nil {
+		return err
+	}
+
+	if err := RunValidation(action, sql, d.Table); err != nil {
+		return err
+	}
+
+	if err := d.perform(action, sql, target); err != nil {
+		return err
+	}
+
+	return RunAfterHooks(action, target)
+}
+
+// perform executa a action sem os hooks da entidade
+func (d *DynamoClient) perform(action domain.Action, sql domain.SqlExpression, target interface{}) error {
 	switch action {
 	case GET:
 		return d.Get(sql, target)
@@ -63,6 +96,8 @@ func (d *DynamoClient) Perform(action domain.Action, sql domain.SqlExpression, t
 		return d.Update(sql, target)
 	case DELETE:
 		return d.Delete(sql)
+	case SCAN:
+		return d.Scan(sql, target)
 	}
 	return nil
 }
@@ -75,111 +110,78 @@ func (d *DynamoClient) NewExpressionBuilder() domain.SqlExpression {
 	})
 }
 
-func (d *DynamoClient) Migrate() error {
-	tables, err := d.Client.ListTables(d.Ctx, &dynamodb.ListTablesInput{})
-	if err != nil {
-		d.Critical("failed to list tables: %v", err)
-	}
-
-	d.Debug("found %d tables\n", len(tables.TableNames))
-
-	created := false
-	for _, table := range tables.TableNames {
-		d.Info("checking table: %s\n", table)
-		if table == *d.TableName {
-			created = true
-			break
-		}
-	}
-
-	if created {
-		d.Info("table `%s` already exists\n", *d.TableName)
-		return nil
-	}
-
-	err = d.CreateTable()
-	if err != nil {
-		return err
-	}
-
-	return err
+// Prepare monta a expressão de uma condição de chave em texto, com os
+// valores posicionais em args. Ex:
+//
+// 	sql, err := d.Prepare("PK = ? AND begins_with(SK, ?)", "USER#1", "ORDER#")
+//
+// Veja expressions.Compile
+func (d *DynamoClient) Prepare(query string, args ...interface{}) (domain.SqlExpression, error) {
+	return expressions.Compile(&domain.Config{
+		TableName: *d.TableName,
+		Table:     d.Table,
+		Log:       d.Log,
+	}, query, args...)
 }
 
-func (d *DynamoClient) Seed(items ...map[string]types.AttributeValue) error {
-	if len(items) <= 0 {
-		d.Info("no items to seed")
-		return nil
+// FlushDb remove todos os itens e depois a tabela.
+//
+// Em ambientes protegidos a operação é recusada, a menos que o client
+// tenha sido configurado com AllowDestructive
+func (d *DynamoClient) FlushDb() error {
+	if err := d.guard("FlushDb"); err != nil {
+		return err
 	}
 
-	d.Debug("seeding table with %d items\n", len(items))
-
-	var transactItems []types.TransactWriteItem
+	d.Error("performing flush db action. Remove this instruction to not lose all your base")
 
-	for _, item := range items {
-		d.Debug("seeding item: %+v\n", item)
-		transactItems = append(transactItems, types.TransactWriteItem{
-			Put: &types.Put{
-				Item:      item,
-				TableName: d.TableName,
-			},
-		})
+	if _, err := d.truncate(); err != nil {
+		return err
 	}
 
-	_, err := d.Client.TransactWriteItems(d.Ctx, &dynamodb.TransactWriteItemsInput{
-		TransactItems: transactItems,
-	})
-
-	if err != nil {
+	if err := d.dropTable(); err != nil {
 		return err
 	}
 
-	if len(transactItems) > 0 {
-		d.Log.Debug("seed complete: %+v seeded\n", len(items))
-	}
+	d.Info("db flush complete")
 
 	return nil
 }
 
-func (d *DynamoClient) FlushDb() {
-	d.Error("performing flush db action. Remove this instruction to not lose all your base")
-	p := dynamodb.NewScanPaginator(d.Client, &dynamodb.ScanInput{TableName: d.TableName, Limit: aws.Int32(10)})
-
-	for p.HasMorePages() {
-		page, err := p.NextPage(d.Ctx)
-		if err != nil {
-			d.Critical("failed on paginate: %v\n", err)
-		}
-
-		for _, item := range page.Items {
-			keyCondition := map[string]types.AttributeValue{}
-
-			keyCondition[d.GetMetadata().GetHash()] = item[d.GetMetadata().GetHash()]
-			keyCondition[d.GetMetadata().GetRange()] = item[d.GetMetadata().GetRange()]
-
-			_, err = d.Client.DeleteItem(d.Ctx, &dynamodb.DeleteItemInput{
-				TableName: d.TableName,
-				Key:       keyCondition,
-			})
-
-			if err != nil {
-				d.Critical("failed on delete: %v\n", err)
-			}
-		}
+// DropTable remove a tabela do DynamoDB.
+//
+// Em ambientes protegidos a operação é recusada, a menos que o client
+// tenha sido configurado com AllowDestructive
+func (d *DynamoClient) DropTable() error {
+	if err := d.guard("DropTable"); err != nil {
+		return err
 	}
 
+	return d.dropTable()
+}
+
+// dropTable remove a tabela sem passar pelo guard, para as operações que
+// já foram autorizadas
+func (d *DynamoClient) dropTable() error {
 	out, err := d.Client.DeleteTable(d.Ctx, &dynamodb.DeleteTableInput{
 		TableName: d.TableName,
 	})
-
 	if err != nil {
-		d.Critical("failed on delete table: %v\n", err)
+		return fmt.Errorf("delete table: %v", err)
 	}
 
 	d.Info("table '%s' deleted\n", *out.TableDescription.TableName)
-	d.Info("db flush complete")
+
+	return nil
 }
 
+// CreateTable cria a tabela depois de validar o schema da entidade, então
+// um schema que o DynamoDB recusaria falha antes da chamada à AWS
 func (d *DynamoClient) CreateTable() error {
+	if err := d.Table.Validate(); err != nil {
+		return fmt.Errorf("create table: %w", err)
+	}
+
 	table := &dynamodb.CreateTableInput{
 		AttributeDefinitions:   d.AttributeDefinitions(),
 		KeySchema:              d.KeySchema(),
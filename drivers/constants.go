@@ -13,8 +13,10 @@ const (
 	QUERY  = domain.Action("QUERY")
 	UPDATE = domain.Action("UPDATE")
 	DELETE = domain.Action("DELETE")
+	SCAN   = domain.Action("SCAN")
 
 	prod = domain.Environment("production")
 	stg  = domain.Environment("staging")
 	dev  = domain.Environment("development")
+	test = domain.Environment("testing")
 )
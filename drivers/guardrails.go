@@ -0,0 +1,54 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDestructiveOperation é o erro base das operações destrutivas
+// recusadas pelos guardrails de ambiente
+var ErrDestructiveOperation = errors.New("destructive operation refused")
+
+// GuardrailError é o erro retornado quando uma operação destrutiva é
+// executada em um ambiente protegido sem AllowDestructive
+type GuardrailError struct {
+	Operation   string
+	Environment string
+	TableName   string
+}
+
+func (e *GuardrailError) Error() string {
+	environment := e.Environment
+	if environment == "" {
+		environment = "<empty>"
+	}
+
+	return fmt.Sprintf(
+		"%v: %s on table `%s` is not allowed in environment %s. Set AllowDestructive in domain.Config to override",
+		ErrDestructiveOperation, e.Operation, e.TableName, environment,
+	)
+}
+
+// Unwrap permite comparar o erro com errors.Is(err, ErrDestructiveOperation)
+func (e *GuardrailError) Unwrap() error {
+	return ErrDestructiveOperation
+}
+
+// guard recusa a operação caso o ambiente seja protegido, por ser
+// production ou pelo Protected do client, e o client não tenha sido
+// configurado com AllowDestructive
+func (d *DynamoClient) guard(operation string) error {
+	if d.AllowDestructive || !(d.Protected || d.Environment.IsProtected()) {
+		return nil
+	}
+
+	err := &GuardrailError{
+		Operation:   operation,
+		Environment: string(d.Environment),
+		TableName:   *d.TableName,
+	}
+
+	d.Error("%v\n", err)
+
+	return err
+}
@@ -0,0 +1,95 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	PK string `diinamo:"type:string;hash"`
+	SK string `diinamo:"type:string;range"`
+}
+
+func protectedClient(env domain.Environment) *drivers.DynamoClient {
+	return &drivers.DynamoClient{
+		TableName:   aws.String("users"),
+		Environment: env,
+		Log:         logger.NewLogger(),
+	}
+}
+
+func TestDynamoClient_Guardrails(t *testing.T) {
+	t.Run("should refuse destructive operations in production", func(t *testing.T) {
+		d := protectedClient("production")
+
+		_, err := d.Truncate()
+		assert.True(t, errors.Is(err, drivers.ErrDestructiveOperation))
+
+		err = d.FlushDb()
+		assert.EqualError(t, err, "destructive operation refused: FlushDb on table `users` is not allowed in environment production. Set AllowDestructive in domain.Config to override")
+
+		assert.True(t, errors.Is(d.DropTable(), drivers.ErrDestructiveOperation))
+		assert.True(t, errors.Is(d.Seed(map[string]interface{}{}), drivers.ErrDestructiveOperation))
+		assert.True(t, errors.Is(d.Scan(nil, &[]interface{}{}), drivers.ErrDestructiveOperation))
+	})
+	t.Run("should protect any environment with Protected", func(t *testing.T) {
+		d := protectedClient("staging")
+		d.Protected = true
+
+		var guardErr *drivers.GuardrailError
+		assert.True(t, errors.As(d.DropTable(), &guardErr))
+		assert.Equal(t, "DropTable", guardErr.Operation)
+
+		d = protectedClient("")
+		d.Protected = true
+		assert.EqualError(t, d.DropTable(), "destructive operation refused: DropTable on table `users` is not allowed in environment <empty>. Set AllowDestructive in domain.Config to override")
+	})
+	t.Run("should allow destructive operations in production with AllowDestructive", func(t *testing.T) {
+		d := protectedClient("production")
+		d.AllowDestructive = true
+		d.Protected = true
+
+		server := inmemory.NewServer()
+		defer server.Close()
+
+		d.Client = server.NewClient()
+		d.Ctx = context.Background()
+		d.Table = table.MustNewTable("users", user{})
+
+		assert.Nil(t, d.CreateTable())
+		assert.Nil(t, d.DropTable())
+	})
+	t.Run("should not protect empty or unknown environments by default", func(t *testing.T) {
+		server := inmemory.NewServer()
+		defer server.Close()
+
+		for _, env := range []domain.Environment{"", "prodution", "staging"} {
+			d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+				TableName:   "users",
+				Environment: env,
+				Client:      server.NewClient(),
+				Table:       table.MustNewTable("users", user{}),
+			})
+
+			assert.Nil(t, d.CreateTable(), string(env))
+			assert.Nil(t, d.Seed(user{PK: "USER#1", SK: "PROFILE"}), string(env))
+			assert.Nil(t, d.Scan(nil, &[]user{}), string(env))
+
+			deleted, err := d.Truncate()
+			assert.Nil(t, err, string(env))
+			assert.Equal(t, int64(1), deleted, string(env))
+
+			assert.Nil(t, d.Seed(user{PK: "USER#1", SK: "PROFILE"}), string(env))
+			assert.Nil(t, d.FlushDb(), string(env))
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package drivers
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+)
+
+// RunBeforeHooks executa os hooks da entidade antes de uma action do
+// Perform. Um erro em qualquer hook interrompe a operação.
+//
+//   - PUT: BeforePut e Validate no item de SetItem
+//   - UPDATE: BeforeUpdate e, com SetItem, Validate no item
+//   - DELETE: BeforeDelete no target
+//
+// Os hooks recebem um ponteiro para o item, e o item alterado volta para
+// a expressão com SetItem
+func RunBeforeHooks(action domain.Action, sql domain.SqlExpression, target interface{}) error {
+	switch action {
+	case PUT:
+		return runItemHooks(sql, func(entity interface{}) error {
+			if hook, ok := entity.(domain.BeforePutHook); ok {
+				if err := hook.BeforePut(); err != nil {
+					return fmt.Errorf("BeforePut: %w", err)
+				}
+			}
+
+			return validate(entity)
+		})
+	case UPDATE:
+		if sql.Item() == nil {
+			return beforeUpdate(target, sql)
+		}
+
+		return runItemHooks(sql, func(entity interface{}) error {
+			if err := beforeUpdate(entity, sql); err != nil {
+				return err
+			}
+
+			return validate(entity)
+		})
+	case DELETE:
+		if hook, ok := target.(domain.BeforeDeleteHook); ok {
+			if err := hook.BeforeDelete(sql); err != nil {
+				return fmt.Errorf("BeforeDelete: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunAfterHooks executa os hooks da entidade depois de uma action do
+// Perform. No GET, QUERY e SCAN o AfterGet é executado em cada item do
+// target. Um GET sem item encontrado não executa o AfterGet
+func RunAfterHooks(action domain.Action, target interface{}) error {
+	switch action {
+	case GET, QUERY, SCAN:
+	default:
+		return nil
+	}
+
+	value := reflect.ValueOf(target).Elem()
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if action == GET && value.IsZero() {
+			return nil
+		}
+
+		return afterGet(target)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			item := value.Index(i)
+			if item.Kind() == reflect.Ptr {
+				if item.IsNil() {
+					continue
+				}
+			} else {
+				item = item.Addr()
+			}
+
+			if err := afterGet(item.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// runItemHooks executa os hooks em um ponteiro para o item da expressão
+// e devolve o item alterado para a expressão
+func runItemHooks(sql domain.SqlExpression, hooks func(entity interface{}) error) error {
+	item := sql.Item()
+	if item == nil {
+		return nil
+	}
+
+	entity := reflect.New(reflect.TypeOf(item))
+	entity.Elem().Set(reflect.ValueOf(item))
+
+	if err := hooks(entity.Interface()); err != nil {
+		return err
+	}
+
+	sql.SetItem(entity.Elem().Interface())
+
+	return nil
+}
+
+func beforeUpdate(entity interface{}, sql domain.SqlExpression) error {
+	if hook, ok := entity.(domain.BeforeUpdateHook); ok {
+		if err := hook.BeforeUpdate(sql); err != nil {
+			return fmt.Errorf("BeforeUpdate: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func afterGet(entity interface{}) error {
+	if hook, ok := entity.(domain.AfterGetHook); ok {
+		if err := hook.AfterGet(); err != nil {
+			return fmt.Errorf("AfterGet: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func validate(entity interface{}) error {
+	if validator, ok := entity.(domain.Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("Validate: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+var errBlocked = errors.New("account is blocked")
+
+type account struct {
+	PK      string `diinamo:"type:string;hash"`
+	SK      string `diinamo:"type:string;range"`
+	Email   string
+	Blocked bool
+	Loaded  bool
+}
+
+func (a *account) BeforePut() error {
+	a.Email = strings.ToLower(strings.TrimSpace(a.Email))
+	a.PK = "ACCOUNT#" + a.Email
+
+	return nil
+}
+
+func (a *account) Validate() error {
+	if !strings.Contains(a.Email, "@") {
+		return errors.New("invalid email")
+	}
+
+	return nil
+}
+
+func (a *account) AfterGet() error {
+	a.Loaded = true
+	return nil
+}
+
+func (a *account) BeforeDelete(sql domain.SqlExpression) error {
+	if a.Blocked {
+		return errBlocked
+	}
+
+	return nil
+}
+
+func TestDynamoClient_Hooks(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "accounts",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("accounts", account{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	t.Run("should run BeforePut and Validate before writing", func(t *testing.T) {
+		var created account
+		sql := d.NewExpressionBuilder().SetItem(account{SK: "PROFILE", Email: "  Jane@Example.com "})
+
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &created))
+		assert.Equal(t, "ACCOUNT#jane@example.com", created.PK)
+		assert.Equal(t, "jane@example.com", created.Email)
+
+		sql = d.NewExpressionBuilder().SetItem(account{SK: "PROFILE", Email: "invalid"})
+		assert.EqualError(t, d.Perform(drivers.PUT, sql, &created), "Validate: invalid email")
+	})
+	t.Run("should run AfterGet on every item read", func(t *testing.T) {
+		var found []account
+		sql := d.NewExpressionBuilder().Where(expressions.NewKeyCondition("PK", "ACCOUNT#jane@example.com"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &found))
+		assert.Len(t, found, 1)
+		assert.True(t, found[0].Loaded)
+
+		var missing account
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ACCOUNT#john@example.com")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("PROFILE"))
+
+		assert.Nil(t, d.Perform(drivers.GET, sql, &missing))
+		assert.False(t, missing.Loaded)
+	})
+	t.Run("should abort the operation when a hook fails", func(t *testing.T) {
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ACCOUNT#jane@example.com")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("PROFILE"))
+
+		err := d.Perform(drivers.DELETE, sql, &account{Blocked: true})
+		assert.True(t, errors.Is(err, errBlocked))
+
+		var found account
+		assert.Nil(t, d.Perform(drivers.GET, sql, &found))
+		assert.Equal(t, "jane@example.com", found.Email)
+	})
+}
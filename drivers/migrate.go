@@ -0,0 +1,220 @@
+package drivers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type (
+	// migrationChange é uma diferença entre a tabela existente e a tabela
+	// definida pela entidade
+	migrationChange struct {
+		description string
+		// destructive indica que a mudança remove ou recria um índice ou
+		// a própria tabela
+		destructive bool
+	}
+)
+
+// Migrate cria a tabela caso ela não exista. Caso exista, compara a tabela
+// com a definição da entidade e registra as diferenças encontradas nas
+// chaves, nos Local Secondary Index e nos Global Secondary Index, incluindo
+// a projection e a capacidade de cada índice.
+//
+// As diferenças não são aplicadas. Diferenças destrutivas (remoção ou
+// recriação de índices, mudança de chaves) são recusadas em ambientes
+// protegidos, a menos que o client tenha sido configurado com
+// AllowDestructive.
+//
+// O schema da entidade é validado antes de qualquer chamada à AWS.
+func (d *DynamoClient) Migrate() error {
+	if err := d.Table.Validate(); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	out, err := d.Client.DescribeTable(d.Ctx, &dynamodb.DescribeTableInput{TableName: d.TableName})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return d.CreateTable()
+		}
+
+		return fmt.Errorf("describe table: %v", err)
+	}
+
+	changes := d.diffTable(out.Table)
+	if len(changes) == 0 {
+		d.Info("table `%s` already exists and is up to date\n", *d.TableName)
+		return nil
+	}
+
+	var destructive []string
+	for _, change := range changes {
+		d.Warn("migration: %s\n", change.description)
+
+		if change.destructive {
+			destructive = append(destructive, change.description)
+		}
+	}
+
+	if len(destructive) > 0 {
+		if err = d.guard("Migrate (" + strings.Join(destructive, "; ") + ")"); err != nil {
+			return err
+		}
+	}
+
+	d.Warn("table `%s` differs from the entity: %d pending changes must be applied manually\n", *d.TableName, len(changes))
+
+	return nil
+}
+
+// diffTable compara a descrição da tabela existente com as definições
+// da entidade
+func (d *DynamoClient) diffTable(current *types.TableDescription) []migrationChange {
+	var changes []migrationChange
+
+	if !sameKeySchema(current.KeySchema, d.KeySchema()) {
+		changes = append(changes, migrationChange{
+			description: "change table key schema",
+			destructive: true,
+		})
+	}
+
+	currentLSI := map[string][]types.KeySchemaElement{}
+	for _, lsi := range current.LocalSecondaryIndexes {
+		currentLSI[*lsi.IndexName] = lsi.KeySchema
+	}
+
+	wantedLSI := map[string][]types.KeySchemaElement{}
+	for _, lsi := range d.GetLSI() {
+		wantedLSI[*lsi.IndexName] = lsi.KeySchema
+	}
+
+	if len(currentLSI) != len(wantedLSI) {
+		changes = append(changes, migrationChange{
+			description: "change local secondary indexes",
+			destructive: true,
+		})
+	} else {
+		for name, keySchema := range wantedLSI {
+			if !sameKeySchema(currentLSI[name], keySchema) {
+				changes = append(changes, migrationChange{
+					description: fmt.Sprintf("change local secondary index %s", name),
+					destructive: true,
+				})
+			}
+		}
+	}
+
+	wantedGSI := map[string]types.GlobalSecondaryIndex{}
+	for _, gsi := range d.GetGSI() {
+		wantedGSI[*gsi.IndexName] = gsi
+	}
+
+	currentGSI := map[string]bool{}
+	for _, gsi := range current.GlobalSecondaryIndexes {
+		currentGSI[*gsi.IndexName] = true
+
+		wanted, ok := wantedGSI[*gsi.IndexName]
+		switch {
+		case !ok:
+			changes = append(changes, migrationChange{
+				description: fmt.Sprintf("delete global secondary index %s", *gsi.IndexName),
+				destructive: true,
+			})
+		case !sameKeySchema(gsi.KeySchema, wanted.KeySchema) || !sameProjection(gsi.Projection, wanted.Projection):
+			// A chave e a projection de um índice só mudam recriando o índice
+			changes = append(changes, migrationChange{
+				description: fmt.Sprintf("recreate global secondary index %s", *gsi.IndexName),
+				destructive: true,
+			})
+		case !sameThroughput(gsi.ProvisionedThroughput, wanted.ProvisionedThroughput):
+			changes = append(changes, migrationChange{
+				description: fmt.Sprintf("change throughput of global secondary index %s", *gsi.IndexName),
+			})
+		}
+	}
+
+	for _, gsi := range d.GetGSI() {
+		if currentGSI[*gsi.IndexName] {
+			continue
+		}
+
+		changes = append(changes, migrationChange{
+			description: fmt.Sprintf("create global secondary index %s", *gsi.IndexName),
+		})
+	}
+
+	return changes
+}
+
+// sameKeySchema compara dois KeySchema
+func sameKeySchema(a, b []types.KeySchemaElement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	keys := map[types.KeyType]string{}
+	for _, key := range a {
+		keys[key.KeyType] = aws.ToString(key.AttributeName)
+	}
+
+	for _, key := range b {
+		if keys[key.KeyType] != aws.ToString(key.AttributeName) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameProjection compara a projection de um índice existente com a
+// projection definida pela entidade. Sem projection o índice é ALL
+func sameProjection(current, wanted *types.Projection) bool {
+	projectionType := func(projection *types.Projection) types.ProjectionType {
+		if projection == nil || projection.ProjectionType == "" {
+			return types.ProjectionTypeAll
+		}
+
+		return projection.ProjectionType
+	}
+
+	if projectionType(current) != projectionType(wanted) {
+		return false
+	}
+
+	if projectionType(wanted) != types.ProjectionTypeInclude {
+		return true
+	}
+
+	attributes := func(projection *types.Projection) string {
+		names := append([]string{}, projection.NonKeyAttributes...)
+		sort.Strings(names)
+
+		return strings.Join(names, ",")
+	}
+
+	return attributes(current) == attributes(wanted)
+}
+
+// sameThroughput compara a capacidade de um índice existente com a
+// capacidade definida pela entidade. Em tabelas on-demand a entidade não
+// define capacidade e a comparação é ignorada
+func sameThroughput(current *types.ProvisionedThroughputDescription, wanted *types.ProvisionedThroughput) bool {
+	if wanted == nil {
+		return true
+	}
+
+	if current == nil {
+		return false
+	}
+
+	return aws.ToInt64(current.ReadCapacityUnits) == aws.ToInt64(wanted.ReadCapacityUnits) &&
+		aws.ToInt64(current.WriteCapacityUnits) == aws.ToInt64(wanted.WriteCapacityUnits)
+}
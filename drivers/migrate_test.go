@@ -0,0 +1,146 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	courseV1 struct {
+		PK    string `diinamo:"type:string;hash"`
+		SK    string `diinamo:"type:string;range"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+	}
+
+	// courseV2 remove o OwnerIndex e cria o TitleIndex
+	courseV2 struct {
+		PK    string `diinamo:"type:string;hash"`
+		SK    string `diinamo:"type:string;range"`
+		Title string `diinamo:"type:string;gsi:TitleIndex;keyPairs:Title=SK"`
+	}
+
+	// courseV3 adiciona o TitleIndex sem remover o OwnerIndex
+	courseV3 struct {
+		PK    string `diinamo:"type:string;hash"`
+		SK    string `diinamo:"type:string;range"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+		Title string `diinamo:"type:string;gsi:TitleIndex;keyPairs:Title=SK"`
+	}
+
+	// courseV4 troca o range key da tabela
+	courseV4 struct {
+		PK    string `diinamo:"type:string;hash"`
+		Slug  string `diinamo:"type:string;range"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=Slug"`
+	}
+
+	// courseV5 troca a projection do OwnerIndex
+	courseV5 struct {
+		PK    string `diinamo:"type:string;hash"`
+		SK    string `diinamo:"type:string;range"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK;projection:keys"`
+	}
+
+	// courseV6 troca a capacidade do OwnerIndex
+	courseV6 struct {
+		PK    string `diinamo:"type:string;hash"`
+		SK    string `diinamo:"type:string;range"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK;rcu:10"`
+	}
+)
+
+func newMigrateClient(client *dynamodb.Client, env domain.Environment, entity interface{}) *drivers.DynamoClient {
+	return drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "courses",
+		Environment: env,
+		Client:      client,
+		Table:       table.MustNewTable("courses", entity),
+	})
+}
+
+func indexNames(t *testing.T, client *dynamodb.Client) []string {
+	out, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("courses")})
+	assert.Nil(t, err)
+
+	var names []string
+	for _, gsi := range out.Table.GlobalSecondaryIndexes {
+		names = append(names, *gsi.IndexName)
+	}
+
+	return names
+}
+
+func TestDynamoClient_Migrate(t *testing.T) {
+	setup := func(t *testing.T) *dynamodb.Client {
+		server := inmemory.NewServer()
+		t.Cleanup(server.Close)
+
+		client := server.NewClient()
+		assert.Nil(t, newMigrateClient(client, "testing", courseV1{}).Migrate())
+
+		return client
+	}
+
+	t.Run("should refuse to drop a gsi in production", func(t *testing.T) {
+		client := setup(t)
+
+		err := newMigrateClient(client, "production", courseV2{}).Migrate()
+
+		var guardErr *drivers.GuardrailError
+		if assert.True(t, errors.As(err, &guardErr)) {
+			assert.Equal(t, "Migrate (delete global secondary index OwnerIndex)", guardErr.Operation)
+		}
+
+		assert.Equal(t, []string{"OwnerIndex"}, indexNames(t, client))
+	})
+	t.Run("should not apply the changes with AllowDestructive", func(t *testing.T) {
+		client := setup(t)
+
+		d := newMigrateClient(client, "production", courseV2{})
+		d.AllowDestructive = true
+
+		assert.Nil(t, d.Migrate())
+		assert.Equal(t, []string{"OwnerIndex"}, indexNames(t, client))
+	})
+	t.Run("should accept a new gsi in production", func(t *testing.T) {
+		client := setup(t)
+
+		assert.Nil(t, newMigrateClient(client, "production", courseV3{}).Migrate())
+		assert.Equal(t, []string{"OwnerIndex"}, indexNames(t, client))
+	})
+	t.Run("should refuse a key change in production", func(t *testing.T) {
+		client := setup(t)
+
+		err := newMigrateClient(client, "production", courseV4{}).Migrate()
+		assert.True(t, errors.Is(err, drivers.ErrDestructiveOperation))
+		assert.Contains(t, err.Error(), "change table key schema")
+	})
+	t.Run("should refuse a projection change in production", func(t *testing.T) {
+		client := setup(t)
+
+		var guardErr *drivers.GuardrailError
+		if assert.True(t, errors.As(newMigrateClient(client, "production", courseV5{}).Migrate(), &guardErr)) {
+			assert.Equal(t, "Migrate (recreate global secondary index OwnerIndex)", guardErr.Operation)
+		}
+	})
+	t.Run("should accept a throughput change in production", func(t *testing.T) {
+		client := setup(t)
+
+		assert.Nil(t, newMigrateClient(client, "production", courseV6{}).Migrate())
+	})
+	t.Run("should accept destructive changes outside production", func(t *testing.T) {
+		client := setup(t)
+
+		assert.Nil(t, newMigrateClient(client, "development", courseV4{}).Migrate())
+		assert.Equal(t, []string{"OwnerIndex"}, indexNames(t, client))
+	})
+}
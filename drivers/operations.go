@@ -3,12 +3,17 @@ package drivers
 import (
 	"fmt"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
 )
 
 func (d *DynamoClient) Get(expression domain.SqlExpression, target interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+
 	output, err := d.Client.GetItem(d.Ctx, &dynamodb.GetItemInput{
 		TableName: d.TableName,
 		Key:       expression.Key(),
@@ -17,7 +22,7 @@ func (d *DynamoClient) Get(expression domain.SqlExpression, target interface{})
 		return fmt.Errorf("get item: %v", err)
 	}
 
-	err = attributevalue.UnmarshalMap(output.Item, target)
+	err = expressions.UnmarshalMap(output.Item, target)
 	if err != nil {
 		return fmt.Errorf("UnmarshalMap: %v", err)
 	}
@@ -25,10 +30,23 @@ func (d *DynamoClient) Get(expression domain.SqlExpression, target interface{})
 	return nil
 }
 
+// Query busca os itens que satisfazem a key condition da expressão. Sem
+// SetIndex, o índice é inferido pelas chaves de Where e AndWhere. Veja
+// ResolveIndex
 func (d *DynamoClient) Query(expression domain.SqlExpression, target interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := expression.ResolveIndex(); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
 	output, err := d.Client.Query(d.Ctx, &dynamodb.QueryInput{
 		TableName:                 d.TableName,
 		KeyConditionExpression:    expression.KeyCondition(),
+		FilterExpression:          expression.FilterExpression(),
+		ExpressionAttributeNames:  expression.AttributeNames(),
 		ExpressionAttributeValues: expression.ExpressionAttributeValues(),
 		IndexName:                 expression.IndexName(),
 	})
@@ -37,7 +55,7 @@ func (d *DynamoClient) Query(expression domain.SqlExpression, target interface{}
 		return fmt.Errorf("query: %v", err)
 	}
 
-	err = attributevalue.UnmarshalListOfMaps(output.Items, target)
+	err = expressions.UnmarshalListOfMaps(output.Items, target)
 	if err != nil {
 		return fmt.Errorf("UnmarshalMap: %v", err)
 	}
@@ -45,16 +63,81 @@ func (d *DynamoClient) Query(expression domain.SqlExpression, target interface{}
 	return nil
 }
 
+// Scan lê todos os itens da tabela que satisfazem o Filter da expressão,
+// paginando até o final.
+//
+// Um Scan sem filtro lê a tabela inteira, então em ambientes protegidos
+// a operação é recusada, a menos que o client tenha sido configurado
+// com AllowDestructive
+func (d *DynamoClient) Scan(expression domain.SqlExpression, target interface{}) error {
+	if expression == nil || expression.FilterExpression() == nil {
+		if err := d.guard("unfiltered Scan"); err != nil {
+			return err
+		}
+	}
+
+	var items []map[string]types.AttributeValue
+
+	input := &dynamodb.ScanInput{TableName: d.TableName}
+	if expression != nil {
+		if err := expression.Err(); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		input.IndexName = expression.IndexName()
+		input.FilterExpression = expression.FilterExpression()
+		input.ExpressionAttributeNames = expression.AttributeNames()
+		input.ExpressionAttributeValues = expression.ExpressionAttributeValues()
+	}
+
+	p := dynamodb.NewScanPaginator(d.Client, input)
+
+	for p.HasMorePages() {
+		page, err := p.NextPage(d.Ctx)
+		if err != nil {
+			return fmt.Errorf("scan: %v", err)
+		}
+
+		items = append(items, page.Items...)
+	}
+
+	err := expressions.UnmarshalListOfMaps(items, target)
+	if err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
+
+// ItemValues converte o item de SetItem no item do DynamoDB. Diferente de
+// SqlExpression.Values, os erros de MarshalDiinamo voltam como error
+func ItemValues(sql domain.SqlExpression) (map[string]types.AttributeValue, error) {
+	if err := sql.Err(); err != nil {
+		return nil, err
+	}
+
+	if item := sql.Item(); item != nil {
+		return expressions.MarshalMap(item)
+	}
+
+	return sql.Values(), nil
+}
+
 func (d *DynamoClient) Put(item domain.SqlExpression, result interface{}) error {
-	_, err := d.Client.PutItem(d.Ctx, &dynamodb.PutItemInput{
-		Item:      item.Values(),
+	values, err := ItemValues(item)
+	if err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	_, err = d.Client.PutItem(d.Ctx, &dynamodb.PutItemInput{
+		Item:      values,
 		TableName: d.TableName,
 	})
 	if err != nil {
 		return fmt.Errorf("put item: %v", err)
 	}
 
-	err = attributevalue.UnmarshalMap(item.Values(), result)
+	err = expressions.UnmarshalMap(values, result)
 	if err != nil {
 		return fmt.Errorf("UnmarshalMap: %v", err)
 	}
@@ -62,20 +145,28 @@ func (d *DynamoClient) Put(item domain.SqlExpression, result interface{}) error
 	return nil
 }
 
+// Update aplica o UpdateExpression no item da chave da expressão. Com
+// ReturnValues ALL_NEW, result recebe o item inteiro depois da
+// atualização, não só os atributos alterados
 func (d *DynamoClient) Update(expression domain.SqlExpression, result interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
 	out, err := d.Client.UpdateItem(d.Ctx, &dynamodb.UpdateItemInput{
 		TableName:                 d.TableName,
 		Key:                       expression.Key(),
 		UpdateExpression:          expression.UpdateExpression(),
 		ExpressionAttributeValues: expression.ExpressionAttributeValues(),
 		ExpressionAttributeNames:  expression.AttributeNames(),
+		ReturnValues:              types.ReturnValueAllNew,
 	})
 
 	if err != nil {
 		return fmt.Errorf("update item: %v", err)
 	}
 
-	err = attributevalue.UnmarshalMap(out.Attributes, result)
+	err = expressions.UnmarshalMap(out.Attributes, result)
 	if err != nil {
 		return fmt.Errorf("UnmarshalMap: %v", err)
 	}
@@ -84,6 +175,10 @@ func (d *DynamoClient) Update(expression domain.SqlExpression, result interface{
 }
 
 func (d *DynamoClient) Delete(expression domain.SqlExpression) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+
 	_, err := d.Client.DeleteItem(d.Ctx, &dynamodb.DeleteItemInput{
 		TableName: d.TableName,
 		Key:       expression.Key(),
@@ -0,0 +1,416 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	PK       string `diinamo:"type:string;hash;name:pk"`
+	SK       string `diinamo:"type:string;range" dynamodbav:"sk"`
+	Customer string `diinamo:"type:string;name:customer_id;gsi:CustomerIndex;keyPairs:Customer=SK"`
+	Total    int    `dynamodbav:"total,omitempty"`
+	Internal string `dynamodbav:"-"`
+}
+
+type (
+	// cents é um value object gravado como N
+	cents int64
+
+	// emailAddress é gravado sempre em minúsculas
+	emailAddress string
+
+	wallet struct {
+		PK      emailAddress `diinamo:"type:string;hash"`
+		SK      string       `diinamo:"type:string;range"`
+		Balance cents
+	}
+)
+
+func (c cents) MarshalDiinamo() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(int64(c)*100, 10)}, nil
+}
+
+func (c *cents) UnmarshalDiinamo(value types.AttributeValue) error {
+	n, err := strconv.ParseInt(value.(*types.AttributeValueMemberN).Value, 10, 64)
+	*c = cents(n / 100)
+
+	return err
+}
+
+func (e emailAddress) MarshalDiinamo() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberS{Value: strings.ToLower(string(e))}, nil
+}
+
+func TestDynamoClient_Marshaler(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "wallets",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("wallets", wallet{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	key := func(sql domain.SqlExpression) domain.SqlExpression {
+		return sql.
+			Where(expressions.NewKeyCondition("PK", emailAddress("Jane@Example.com"))).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("WALLET"))
+	}
+
+	t.Run("should write and read value objects", func(t *testing.T) {
+		var created wallet
+		sql := d.NewExpressionBuilder().SetItem(wallet{PK: "JANE@example.com", SK: "WALLET", Balance: 10})
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &created))
+
+		var found wallet
+		assert.Nil(t, d.Perform(drivers.GET, key(d.NewExpressionBuilder()), &found))
+		assert.Equal(t, wallet{PK: "jane@example.com", SK: "WALLET", Balance: 10}, found)
+	})
+	t.Run("should marshal condition and update values", func(t *testing.T) {
+		var updated wallet
+		sql := key(d.NewExpressionBuilder()).Update(expressions.NewKeyCondition("Balance", cents(25)))
+
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, cents(25), updated.Balance)
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "2500"}, sql.ExpressionAttributeValues()[":v0"])
+	})
+}
+
+func TestDynamoClient_CreateTableBilling(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	client := server.NewClient()
+
+	t.Run("should create an on-demand table without throughput", func(t *testing.T) {
+		d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+			TableName:   "orders",
+			Environment: "testing",
+			Client:      client,
+			Table:       table.MustNewTable("orders", order{}, table.WithPayPerRequest()),
+		})
+		assert.Nil(t, d.CreateTable())
+
+		out, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("orders")})
+		assert.Nil(t, err)
+		assert.Equal(t, types.BillingModePayPerRequest, out.Table.BillingModeSummary.BillingMode)
+		assert.Nil(t, out.Table.ProvisionedThroughput)
+		assert.Nil(t, out.Table.GlobalSecondaryIndexes[0].ProvisionedThroughput)
+	})
+	t.Run("should reject throughput on an on-demand table", func(t *testing.T) {
+		_, err := client.CreateTable(context.Background(), &dynamodb.CreateTableInput{
+			TableName:             aws.String("invoices"),
+			BillingMode:           types.BillingModePayPerRequest,
+			KeySchema:             []types.KeySchemaElement{{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash}},
+			AttributeDefinitions:  []types.AttributeDefinition{{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS}},
+			ProvisionedThroughput: &types.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(1), WriteCapacityUnits: aws.Int64(1)},
+		})
+
+		assert.Contains(t, err.Error(), "when BillingMode is PAY_PER_REQUEST")
+	})
+}
+
+func TestDynamoClient_CreateTableValidate(t *testing.T) {
+	type invalid struct {
+		PK   string `diinamo:"type:string;hash"`
+		SK   string `diinamo:"type:string;range"`
+		Date string `diinamo:"type:string;lsi:DateIndex;keyPairs:SK=Date"`
+	}
+
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	client := server.NewClient()
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "invalids",
+		Environment: "testing",
+		Client:      client,
+		Table:       table.MustNewTable("invalids", invalid{}),
+	})
+
+	t.Run("should validate the schema before creating the table", func(t *testing.T) {
+		err := d.CreateTable()
+		assert.EqualError(t, err, "create table: table invalids: invalid schema: lsi DateIndex has the hash key SK, expected the table hash key PK")
+
+		_, err = client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("invalids")})
+		assert.NotNil(t, err)
+	})
+	t.Run("should validate the schema before migrating", func(t *testing.T) {
+		var schemaErr *tagManager.SchemaError
+		assert.ErrorAs(t, d.Migrate(), &schemaErr)
+	})
+	t.Run("should create a table keyed by time and pointers", func(t *testing.T) {
+		type event struct {
+			PK        *int      `diinamo:"type:number;hash"`
+			CreatedAt time.Time `diinamo:"type:string;range"`
+		}
+
+		events := drivers.NewDynamoClient(context.Background(), &domain.Config{
+			TableName:   "events",
+			Environment: "testing",
+			Client:      client,
+			Table:       table.MustNewTable("events", event{}),
+		})
+		assert.Nil(t, events.CreateTable())
+
+		out, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("events")})
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []types.AttributeDefinition{
+			{AttributeName: aws.String("PK"), AttributeType: types.ScalarAttributeTypeN},
+			{AttributeName: aws.String("CreatedAt"), AttributeType: types.ScalarAttributeTypeS},
+		}, out.Table.AttributeDefinitions)
+
+		id := 1
+		sql := events.NewExpressionBuilder().SetItem(event{PK: &id, CreatedAt: time.Now()})
+		assert.Nil(t, events.Perform(drivers.PUT, sql, &event{}))
+	})
+}
+
+func TestDynamoClient_AttributeNames(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	client := server.NewClient()
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "orders",
+		Environment: "testing",
+		Client:      client,
+		Table:       table.MustNewTable("orders", order{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	key := func(sql domain.SqlExpression) domain.SqlExpression {
+		return sql.
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META"))
+	}
+
+	t.Run("should create the schema with the attribute names", func(t *testing.T) {
+		out, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("orders")})
+		assert.Nil(t, err)
+
+		assert.Equal(t, "pk", *out.Table.KeySchema[0].AttributeName)
+		assert.Equal(t, "sk", *out.Table.KeySchema[1].AttributeName)
+		assert.Equal(t, "customer_id", *out.Table.GlobalSecondaryIndexes[0].KeySchema[0].AttributeName)
+		assert.Equal(t, "sk", *out.Table.GlobalSecondaryIndexes[0].KeySchema[1].AttributeName)
+	})
+	t.Run("should write and read with the attribute names", func(t *testing.T) {
+		var created order
+		sql := d.NewExpressionBuilder().SetItem(order{PK: "ORDER#1", SK: "META", Customer: "C#1", Total: 10, Internal: "secret"})
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &created))
+
+		out, err := client.GetItem(context.Background(), &dynamodb.GetItemInput{
+			TableName: aws.String("orders"),
+			Key:       key(d.NewExpressionBuilder()).Key(),
+		})
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"pk", "sk", "customer_id", "total"}, attributeNames(out.Item))
+
+		var found order
+		assert.Nil(t, d.Perform(drivers.GET, key(d.NewExpressionBuilder()), &found))
+		assert.Equal(t, order{PK: "ORDER#1", SK: "META", Customer: "C#1", Total: 10}, found)
+
+		var byCustomer []order
+		sql = d.NewExpressionBuilder().SetIndex("CustomerIndex").Where(expressions.NewKeyCondition("Customer", "C#1"))
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &byCustomer))
+		assert.Equal(t, []order{found}, byCustomer)
+	})
+	t.Run("should update with the field names", func(t *testing.T) {
+		var updated order
+		sql := key(d.NewExpressionBuilder()).Update(expressions.NewKeyCondition("Customer", "C#2"))
+
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, "C#2", updated.Customer)
+		assert.Equal(t, map[string]string{"#n0": "customer_id"}, sql.AttributeNames())
+	})
+}
+
+func attributeNames(item map[string]types.AttributeValue) []string {
+	var names []string
+	for name := range item {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestDynamoClient_Prepare(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "orders",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("orders", order{}),
+	})
+	assert.Nil(t, d.CreateTable())
+	assert.Nil(t, d.Seed(
+		order{PK: "ORDER#1", SK: "ITEM#1", Customer: "C#1"},
+		order{PK: "ORDER#1", SK: "ITEM#2", Customer: "C#1"},
+		order{PK: "ORDER#1", SK: "META", Customer: "C#2"},
+	))
+
+	t.Run("should query with a prepared expression", func(t *testing.T) {
+		sql, err := d.Prepare("PK = ? AND begins_with(SK, ?)", "ORDER#1", "ITEM#")
+		assert.Nil(t, err)
+
+		var items []order
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &items))
+		assert.Len(t, items, 2)
+	})
+	t.Run("should query an index with between", func(t *testing.T) {
+		sql, err := d.Prepare("Customer = ? AND SK BETWEEN ? AND ?", "C#1", "ITEM#1", "ITEM#9")
+		assert.Nil(t, err)
+		assert.Equal(t, "CustomerIndex", *sql.IndexName())
+
+		var items []order
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &items))
+		assert.Len(t, items, 2)
+	})
+	t.Run("should infer the index without SetIndex", func(t *testing.T) {
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("Customer", "C#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").StarsWith("ITEM#"))
+
+		var items []order
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &items))
+		assert.Len(t, items, 2)
+		assert.Equal(t, "CustomerIndex", *sql.IndexName())
+	})
+	t.Run("should not send a query that no index serves", func(t *testing.T) {
+		sql := d.NewExpressionBuilder().Where(expressions.NewKeyCondition("Total", 10))
+
+		var items []order
+		var indexErr *expressions.IndexError
+		assert.ErrorAs(t, d.Perform(drivers.QUERY, sql, &items), &indexErr)
+	})
+	t.Run("should not query with an invalid expression", func(t *testing.T) {
+		_, err := d.Prepare("Total = ?", 10)
+		assert.EqualError(t, err, `query "Total = ?": total is not the partition key of the table or of an index at column 1`)
+	})
+}
+
+func TestDynamoClient_Filter(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "orders",
+		Environment: "production",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("orders", order{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	for i, total := range []int{5, 15, 25} {
+		sql := d.NewExpressionBuilder().SetItem(order{PK: "ORDER#1", SK: "ITEM#" + strconv.Itoa(i), Customer: "C#1", Total: total})
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &order{}))
+	}
+
+	t.Run("should filter a query", func(t *testing.T) {
+		var result []order
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			Filter(expressions.NewSortKeyCondition("Total").GreaterThan(10))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 2)
+	})
+	t.Run("should allow filtered scans in protected environments", func(t *testing.T) {
+		var result []order
+		sql := d.NewExpressionBuilder().
+			Filter(expressions.NewSortKeyCondition("Total").LessThan(10))
+
+		assert.Nil(t, d.Perform(drivers.SCAN, sql, &result))
+		assert.Equal(t, []order{{PK: "ORDER#1", SK: "ITEM#0", Customer: "C#1", Total: 5}}, result)
+
+		err := d.Perform(drivers.SCAN, d.NewExpressionBuilder(), &result)
+		assert.True(t, errors.Is(err, drivers.ErrDestructiveOperation))
+	})
+}
+
+func TestDynamoClient_Scan(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "orders",
+		Environment: "development",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("orders", order{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	for i := 0; i < 3; i++ {
+		sql := d.NewExpressionBuilder().SetItem(order{PK: "ORDER#1", SK: "ITEM#" + strconv.Itoa(i), Customer: "C#1"})
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &order{}))
+	}
+
+	t.Run("should scan the whole table without expression", func(t *testing.T) {
+		var result []order
+
+		assert.Nil(t, d.Scan(nil, &result))
+		assert.Len(t, result, 3)
+	})
+}
+
+func TestDynamoClient_Update(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "orders",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("orders", order{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	sql := d.NewExpressionBuilder().SetItem(order{PK: "ORDER#1", SK: "ITEM#1", Customer: "C#1", Total: 10})
+	assert.Nil(t, d.Perform(drivers.PUT, sql, &order{}))
+
+	t.Run("should return the whole item after the update", func(t *testing.T) {
+		var updated order
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("ITEM#1")).
+			Update(expressions.NewKeyCondition("Total", 20))
+
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, order{PK: "ORDER#1", SK: "ITEM#1", Customer: "C#1", Total: 20}, updated)
+	})
+	t.Run("should return the marshal errors of the key and the update", func(t *testing.T) {
+		var updated order
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("ITEM#1")).
+			Update(expressions.NewKeyCondition("Customer", invalidName("?")))
+
+		assert.EqualError(t, d.Perform(drivers.UPDATE, sql, &updated), `validation: attribute customer_id: MarshalDiinamo: invalid name "?"`)
+
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", invalidName("?"))).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("ITEM#1"))
+
+		assert.EqualError(t, d.Perform(drivers.GET, sql, &updated), `get item: attribute pk: MarshalDiinamo: invalid name "?"`)
+	})
+}
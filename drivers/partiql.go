@@ -0,0 +1,510 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+)
+
+// BatchStatementLimit é o máximo de instruções de um BatchExecute
+const BatchStatementLimit = 25
+
+type (
+	// SQLClient implementa domain.DynamoSQL com instruções PartiQL, via
+	// ExecuteStatement, BatchExecuteStatement e ExecuteTransaction.
+	//
+	// Get, Put, Update e Delete montam as instruções a partir das
+	// expressões do SqlBuilder. Diferente do PutItem e do UpdateItem, o
+	// INSERT falha quando o item já existe e o UPDATE quando ele não existe.
+	// Os erros do SDK são encadeados, então errors.As encontra, por
+	// exemplo, o *types.DuplicateItemException
+	SQLClient struct {
+		Client    *dynamodb.Client
+		Ctx       context.Context
+		TableName *string
+
+		domain.Table
+		logger.Log
+	}
+
+	// StatementError é o erro de uma das instruções de um BatchExecute
+	StatementError struct {
+		Index   int
+		Code    string
+		Message string
+	}
+
+	// BatchError reúne as instruções de um BatchExecute que falharam. As
+	// demais instruções foram executadas e os seus itens decodificados
+	BatchError struct {
+		Errors []StatementError
+	}
+)
+
+// SQLClient deve sempre satisfazer o contrato domain.DynamoSQL
+var _ domain.DynamoSQL = &SQLClient{}
+
+func NewSQLClient(ctx context.Context, conf *domain.Config) *SQLClient {
+	if conf.Log == nil {
+		conf.Log = logger.NewLogger()
+	}
+
+	return &SQLClient{
+		Client:    conf.Client,
+		Ctx:       ctx,
+		TableName: aws.String(conf.TableName),
+		Table:     conf.Table,
+		Log:       conf.Log,
+	}
+}
+
+func (b *BatchError) Error() string {
+	messages := make([]string, 0, len(b.Errors))
+	for _, e := range b.Errors {
+		messages = append(messages, fmt.Sprintf("statement %d: %s: %s", e.Index, e.Code, e.Message))
+	}
+
+	return fmt.Sprintf("batch execute statement: %s", strings.Join(messages, "; "))
+}
+
+func (d *SQLClient) NewExpressionBuilder() domain.SqlExpression {
+	return expressions.NewSqlBuilder(&domain.Config{
+		TableName: *d.TableName,
+		Table:     d.Table,
+		Log:       d.Log,
+	})
+}
+
+// Execute executa uma instrução PartiQL. Ex:
+//
+// 	var orders []Order
+// 	statement := domain.NewStatement(`SELECT * FROM "orders" WHERE PK = ?`, "USER#1")
+// 	for {
+// 		var page []Order
+// 		next, err := d.Execute(statement, &page)
+// 		...
+// 		orders = append(orders, page...)
+// 		if next == nil {
+// 			break
+// 		}
+// 		statement.NextToken = next
+// 	}
+func (d *SQLClient) Execute(statement domain.Statement, target interface{}) (*string, error) {
+	params, err := StatementParams(statement.Params)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement:      aws.String(statement.Query),
+		Parameters:     params,
+		NextToken:      statement.NextToken,
+		ConsistentRead: aws.Bool(statement.ConsistentRead),
+	}
+
+	if statement.Limit > 0 {
+		input.Limit = aws.Int32(statement.Limit)
+	}
+
+	out, err := d.Client.ExecuteStatement(d.Ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+
+	if target != nil {
+		if err = expressions.UnmarshalListOfMaps(out.Items, target); err != nil {
+			return nil, fmt.Errorf("UnmarshalMap: %v", err)
+		}
+	}
+
+	return out.NextToken, nil
+}
+
+// BatchExecute executa até BatchStatementLimit instruções independentes.
+// Os SELECT devem informar a chave completa do item. As instruções que
+// falharam voltam em um *BatchError
+func (d *SQLClient) BatchExecute(statements []domain.Statement, target interface{}) error {
+	if len(statements) == 0 || len(statements) > BatchStatementLimit {
+		return fmt.Errorf("batch execute statement: expected between 1 and %d statements, got %d", BatchStatementLimit, len(statements))
+	}
+
+	requests := make([]types.BatchStatementRequest, 0, len(statements))
+	for i, statement := range statements {
+		params, err := StatementParams(statement.Params)
+		if err != nil {
+			return fmt.Errorf("batch execute statement %d: %w", i, err)
+		}
+
+		requests = append(requests, types.BatchStatementRequest{
+			Statement:      aws.String(statement.Query),
+			Parameters:     params,
+			ConsistentRead: aws.Bool(statement.ConsistentRead),
+		})
+	}
+
+	out, err := d.Client.BatchExecuteStatement(d.Ctx, &dynamodb.BatchExecuteStatementInput{
+		Statements: requests,
+	})
+	if err != nil {
+		return fmt.Errorf("batch execute statement: %w", err)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(out.Responses))
+	batchErr := &BatchError{}
+
+	for i, response := range out.Responses {
+		items = append(items, response.Item)
+
+		if response.Error != nil {
+			batchErr.Errors = append(batchErr.Errors, StatementError{
+				Index:   i,
+				Code:    string(response.Error.Code),
+				Message: aws.ToString(response.Error.Message),
+			})
+		}
+	}
+
+	if err = UnmarshalStatementItems(items, target); err != nil {
+		return err
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
+// ExecuteTransaction executa as instruções em uma transação. Todas devem
+// ser leituras ou todas escritas
+func (d *SQLClient) ExecuteTransaction(statements []domain.Statement, target interface{}) error {
+	transact := make([]types.ParameterizedStatement, 0, len(statements))
+	for i, statement := range statements {
+		params, err := StatementParams(statement.Params)
+		if err != nil {
+			return fmt.Errorf("execute transaction statement %d: %w", i, err)
+		}
+
+		transact = append(transact, types.ParameterizedStatement{
+			Statement:  aws.String(statement.Query),
+			Parameters: params,
+		})
+	}
+
+	out, err := d.Client.ExecuteTransaction(d.Ctx, &dynamodb.ExecuteTransactionInput{
+		TransactStatements: transact,
+	})
+	if err != nil {
+		return fmt.Errorf("execute transaction: %w", err)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(out.Responses))
+	for _, response := range out.Responses {
+		items = append(items, response.Item)
+	}
+
+	return UnmarshalStatementItems(items, target)
+}
+
+// Get busca o item da chave da expressão com um SELECT. Quando o item não
+// existe o target não é alterado
+func (d *SQLClient) Get(expression domain.SqlExpression, target interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+
+	where, params := whereKey(expression.Key())
+
+	var items []map[string]types.AttributeValue
+	if _, err := d.Execute(domain.Statement{
+		Query:  fmt.Sprintf("SELECT * FROM %s WHERE %s", QuoteName(*d.TableName), where),
+		Params: params,
+	}, &items); err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := expressions.UnmarshalMap(items[0], target); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
+
+// Put grava o item com um INSERT. item pode ser uma struct com as tags
+// diinamo ou uma SqlExpression com SetItem. Assim como no Perform, os
+// hooks e as regras de validação da entidade são executados antes do
+// INSERT. Veja RunBeforeHooks e RunValidation
+func (d *SQLClient) Put(item interface{}, result interface{}) error {
+	sql, err := d.itemExpression(item)
+	if err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	if err = RunBeforeHooks(PUT, sql, result); err != nil {
+		return err
+	}
+
+	if err = RunValidation(PUT, sql, d.Table); err != nil {
+		return err
+	}
+
+	values, err := ItemValues(sql)
+	if err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	names := sortedNames(values)
+	fields := make([]string, 0, len(names))
+	params := make([]interface{}, 0, len(names))
+
+	for _, name := range names {
+		fields = append(fields, fmt.Sprintf("%s: ?", quoteString(name)))
+		params = append(params, values[name])
+	}
+
+	if _, err = d.Execute(domain.Statement{
+		Query:  fmt.Sprintf("INSERT INTO %s VALUE {%s}", QuoteName(*d.TableName), strings.Join(fields, ", ")),
+		Params: params,
+	}, nil); err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	if err = expressions.UnmarshalMap(values, result); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return RunAfterHooks(PUT, result)
+}
+
+// Update atualiza o item da chave da expressão com um UPDATE. Os
+// atributos de item, quando informado, e os valores do SET da expressão
+// são atribuídos. result recebe o item atualizado.
+//
+// Assim como no Perform, o BeforeUpdate e o Validate da entidade são
+// executados antes do UPDATE, no item quando informado ou no result, e
+// as regras de validação são verificadas em todos os atributos atribuídos
+func (d *SQLClient) Update(expression interface{}, item interface{}, result interface{}) error {
+	sql, ok := expression.(domain.SqlExpression)
+	if !ok {
+		return fmt.Errorf("update item: expression should be a domain.SqlExpression, got %T", expression)
+	}
+
+	if err := sql.Err(); err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
+	var itemSql domain.SqlExpression
+	if item != nil {
+		var err error
+		if itemSql, err = d.itemExpression(item); err != nil {
+			return fmt.Errorf("update item: %w", err)
+		}
+
+		if err = runItemHooks(itemSql, func(entity interface{}) error {
+			if err := beforeUpdate(entity, sql); err != nil {
+				return err
+			}
+
+			return validate(entity)
+		}); err != nil {
+			return err
+		}
+	} else if err := RunBeforeHooks(UPDATE, sql, result); err != nil {
+		return err
+	}
+
+	if err := RunValidation(UPDATE, sql, d.Table); err != nil {
+		return err
+	}
+
+	key := sql.Key()
+	assignments, err := setAssignments(sql)
+	if err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
+	if itemSql != nil {
+		values, err := ItemValues(itemSql)
+		if err != nil {
+			return fmt.Errorf("update item: %w", err)
+		}
+
+		fields := map[string]types.AttributeValue{}
+		for name, value := range values {
+			if _, isKey := key[name]; !isKey {
+				fields[name] = value
+				assignments[name] = value
+			}
+		}
+
+		if err = validateAssignments(d.Table, fields); err != nil {
+			return err
+		}
+	}
+
+	if len(assignments) == 0 {
+		return errors.New("update item: there are no attributes to update")
+	}
+
+	var sets []string
+	var params []interface{}
+	for _, name := range sortedNames(assignments) {
+		sets = append(sets, fmt.Sprintf("SET %s = ?", QuoteName(name)))
+		params = append(params, assignments[name])
+	}
+
+	where, keyParams := whereKey(key)
+
+	var items []map[string]types.AttributeValue
+	if _, err := d.Execute(domain.Statement{
+		Query:  fmt.Sprintf("UPDATE %s %s WHERE %s RETURNING ALL NEW *", QuoteName(*d.TableName), strings.Join(sets, " "), where),
+		Params: append(params, keyParams...),
+	}, &items); err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
+	if result == nil || len(items) == 0 {
+		return nil
+	}
+
+	if err := expressions.UnmarshalMap(items[0], result); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return RunAfterHooks(UPDATE, result)
+}
+
+// Delete remove o item da chave da expressão com um DELETE
+func (d *SQLClient) Delete(expression domain.SqlExpression) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+
+	where, params := whereKey(expression.Key())
+
+	if _, err := d.Execute(domain.Statement{
+		Query:  fmt.Sprintf("DELETE FROM %s WHERE %s", QuoteName(*d.TableName), where),
+		Params: params,
+	}, nil); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+
+	return nil
+}
+
+// QuoteName escreve um nome de tabela, índice ou atributo como
+// identificador PartiQL. Ex: "orders"
+func QuoteName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteString escreve um texto literal PartiQL. Ex: 'PK'
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// StatementParams converte os parâmetros de uma Statement com o reflector
+func StatementParams(params []interface{}) ([]types.AttributeValue, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	values := make([]types.AttributeValue, 0, len(params))
+	for i, param := range params {
+		value, err := expressions.Marshal(param)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %d: %w", i, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// itemExpression devolve a SqlExpression com SetItem de uma struct, de um
+// ponteiro de struct ou a própria SqlExpression, para os hooks e as
+// regras de validação do item
+func (d *SQLClient) itemExpression(item interface{}) (domain.SqlExpression, error) {
+	if sql, ok := item.(domain.SqlExpression); ok {
+		return sql, nil
+	}
+
+	value := reflect.ValueOf(item)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported item type %T", item)
+	}
+
+	return d.NewExpressionBuilder().SetItem(value.Interface()), nil
+}
+
+// whereKey monta a condição de igualdade da chave, com os parâmetros na
+// ordem dos nomes
+func whereKey(key map[string]types.AttributeValue) (string, []interface{}) {
+	var conditions []string
+	var params []interface{}
+
+	for _, name := range sortedNames(key) {
+		conditions = append(conditions, fmt.Sprintf("%s = ?", QuoteName(name)))
+		params = append(params, key[name])
+	}
+
+	return strings.Join(conditions, " AND "), params
+}
+
+// UnmarshalStatementItems decodifica um item por instrução de um lote ou
+// transação em target. As instruções sem item viram o valor zero do
+// elemento
+func UnmarshalStatementItems(items []map[string]types.AttributeValue, target interface{}) error {
+	if target == nil {
+		return nil
+	}
+
+	list := make([]types.AttributeValue, 0, len(items))
+	for _, item := range items {
+		if item == nil {
+			list = append(list, &types.AttributeValueMemberNULL{Value: true})
+			continue
+		}
+
+		list = append(list, &types.AttributeValueMemberM{Value: item})
+	}
+
+	if err := expressions.Unmarshal(&types.AttributeValueMemberL{Value: list}, target); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
+
+func sortedNames(item map[string]types.AttributeValue) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,281 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSQLClient(t *testing.T) (*drivers.SQLClient, func()) {
+	server := inmemory.NewServer()
+
+	conf := &domain.Config{
+		TableName:   "orders",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("orders", order{}),
+	}
+	assert.Nil(t, drivers.NewDynamoClient(context.Background(), conf).CreateTable())
+
+	return drivers.NewSQLClient(context.Background(), conf), server.Close
+}
+
+func TestSQLClient_Crud(t *testing.T) {
+	d, closeServer := newSQLClient(t)
+	defer closeServer()
+
+	key := func() domain.SqlExpression {
+		return d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META"))
+	}
+
+	t.Run("should insert and select an item", func(t *testing.T) {
+		var created order
+		assert.Nil(t, d.Put(order{PK: "ORDER#1", SK: "META", Customer: "C#1", Total: 10}, &created))
+		assert.Equal(t, "C#1", created.Customer)
+
+		var found order
+		assert.Nil(t, d.Get(key(), &found))
+		assert.Equal(t, order{PK: "ORDER#1", SK: "META", Customer: "C#1", Total: 10}, found)
+	})
+	t.Run("should not insert an existing item", func(t *testing.T) {
+		err := d.Put(order{PK: "ORDER#1", SK: "META"}, nil)
+
+		var duplicate *types.DuplicateItemException
+		assert.True(t, errors.As(err, &duplicate))
+	})
+	t.Run("should update with the item and the expression", func(t *testing.T) {
+		var updated order
+		sql := key().Update(expressions.NewKeyCondition("Total", 25))
+
+		assert.Nil(t, d.Update(sql, order{Customer: "C#2"}, &updated))
+		assert.Equal(t, order{PK: "ORDER#1", SK: "META", Customer: "C#2", Total: 25}, updated)
+	})
+	t.Run("should not update a missing item", func(t *testing.T) {
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#9")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META")).
+			Update(expressions.NewKeyCondition("Total", 1))
+
+		var conditional *types.ConditionalCheckFailedException
+		assert.True(t, errors.As(d.Update(sql, nil, nil), &conditional))
+	})
+	t.Run("should delete the item", func(t *testing.T) {
+		assert.Nil(t, d.Delete(key()))
+
+		found := order{}
+		assert.Nil(t, d.Get(key(), &found))
+		assert.Equal(t, order{}, found)
+	})
+}
+
+func TestSQLClient_Execute(t *testing.T) {
+	d, closeServer := newSQLClient(t)
+	defer closeServer()
+
+	for _, sk := range []string{"ITEM#1", "ITEM#2", "ITEM#3", "META"} {
+		_, err := d.Execute(domain.NewStatement(`INSERT INTO "orders" VALUE {'pk': ?, 'sk': ?, 'customer_id': ?, 'total': ?}`, "ORDER#1", sk, "C#1", 5), nil)
+		assert.Nil(t, err)
+	}
+
+	t.Run("should bind parameters and decode into tagged structs", func(t *testing.T) {
+		var result []order
+		next, err := d.Execute(domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ? AND begins_with(sk, ?)`, "ORDER#1", "ITEM#"), &result)
+
+		assert.Nil(t, err)
+		assert.Nil(t, next)
+		assert.Len(t, result, 3)
+		assert.Equal(t, order{PK: "ORDER#1", SK: "ITEM#1", Customer: "C#1", Total: 5}, result[0])
+	})
+	t.Run("should paginate with the NextToken", func(t *testing.T) {
+		statement := domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ?`, "ORDER#1")
+		statement.Limit = 3
+
+		var first, second []order
+		next, err := d.Execute(statement, &first)
+		assert.Nil(t, err)
+		assert.NotNil(t, next)
+		assert.Len(t, first, 3)
+
+		statement.NextToken = next
+		next, err = d.Execute(statement, &second)
+		assert.Nil(t, err)
+		assert.Nil(t, next)
+		assert.Equal(t, []order{{PK: "ORDER#1", SK: "META", Customer: "C#1", Total: 5}}, second)
+	})
+	t.Run("should select from an index with a projection", func(t *testing.T) {
+		var result []map[string]interface{}
+		_, err := d.Execute(domain.NewStatement(`SELECT sk, total FROM "orders"."CustomerIndex" WHERE customer_id = ? AND sk = ?`, "C#1", "META"), &result)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []map[string]interface{}{{"sk": "META", "total": float64(5)}}, result)
+	})
+	t.Run("should return the updated item", func(t *testing.T) {
+		var result []order
+		_, err := d.Execute(domain.NewStatement(`UPDATE "orders" SET total = total + ? WHERE pk = ? AND sk = ? RETURNING ALL NEW *`, 10, "ORDER#1", "META"), &result)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 15, result[0].Total)
+	})
+	t.Run("should fail when the parameters do not match", func(t *testing.T) {
+		_, err := d.Execute(domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ?`), nil)
+
+		assert.Contains(t, err.Error(), "number of parameters")
+	})
+}
+
+func TestSQLClient_BatchExecute(t *testing.T) {
+	d, closeServer := newSQLClient(t)
+	defer closeServer()
+
+	insert := `INSERT INTO "orders" VALUE {'pk': ?, 'sk': ?}`
+
+	t.Run("should report the statements that failed", func(t *testing.T) {
+		err := d.BatchExecute([]domain.Statement{
+			domain.NewStatement(insert, "ORDER#1", "META"),
+			domain.NewStatement(insert, "ORDER#1", "META"),
+			domain.NewStatement(insert, "ORDER#2", "META"),
+		}, nil)
+
+		var batchErr *drivers.BatchError
+		assert.True(t, errors.As(err, &batchErr))
+		assert.Len(t, batchErr.Errors, 1)
+		assert.Equal(t, 1, batchErr.Errors[0].Index)
+		assert.Equal(t, "DuplicateItem", batchErr.Errors[0].Code)
+	})
+	t.Run("should read one item per statement", func(t *testing.T) {
+		var result []*order
+		err := d.BatchExecute([]domain.Statement{
+			domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ? AND sk = ?`, "ORDER#2", "META"),
+			domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ? AND sk = ?`, "ORDER#3", "META"),
+		}, &result)
+
+		assert.Nil(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, "ORDER#2", result[0].PK)
+		assert.Nil(t, result[1])
+	})
+	t.Run("should limit the number of statements", func(t *testing.T) {
+		assert.NotNil(t, d.BatchExecute(make([]domain.Statement, drivers.BatchStatementLimit+1), nil))
+	})
+}
+
+func TestSQLClient_ExecuteTransaction(t *testing.T) {
+	d, closeServer := newSQLClient(t)
+	defer closeServer()
+
+	assert.Nil(t, d.Put(order{PK: "ORDER#1", SK: "META", Total: 10}, nil))
+
+	t.Run("should apply all the writes", func(t *testing.T) {
+		err := d.ExecuteTransaction([]domain.Statement{
+			domain.NewStatement(`UPDATE "orders" SET total = ? WHERE pk = ? AND sk = ?`, 20, "ORDER#1", "META"),
+			domain.NewStatement(`INSERT INTO "orders" VALUE {'pk': ?, 'sk': ?}`, "ORDER#1", "ITEM#1"),
+		}, nil)
+		assert.Nil(t, err)
+
+		var result []order
+		err = d.ExecuteTransaction([]domain.Statement{
+			domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ? AND sk = ?`, "ORDER#1", "META"),
+			domain.NewStatement(`SELECT * FROM "orders" WHERE pk = ? AND sk = ?`, "ORDER#1", "ITEM#1"),
+		}, &result)
+		assert.Nil(t, err)
+		assert.Equal(t, []order{{PK: "ORDER#1", SK: "META", Total: 20}, {PK: "ORDER#1", SK: "ITEM#1"}}, result)
+	})
+	t.Run("should apply none of the writes when one fails", func(t *testing.T) {
+		err := d.ExecuteTransaction([]domain.Statement{
+			domain.NewStatement(`UPDATE "orders" SET total = ? WHERE pk = ? AND sk = ?`, 30, "ORDER#1", "META"),
+			domain.NewStatement(`INSERT INTO "orders" VALUE {'pk': ?, 'sk': ?}`, "ORDER#1", "ITEM#1"),
+		}, nil)
+
+		var canceled *types.TransactionCanceledException
+		assert.True(t, errors.As(err, &canceled))
+		assert.Equal(t, "DuplicateItem", *canceled.CancellationReasons[1].Code)
+
+		var found order
+		assert.Nil(t, d.Get(d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ORDER#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META")), &found))
+		assert.Equal(t, 20, found.Total)
+	})
+}
+
+func TestSQLClient_HooksAndValidation(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	newClient := func(name string, entity interface{}) *drivers.SQLClient {
+		conf := &domain.Config{
+			TableName:   name,
+			Environment: "testing",
+			Client:      server.NewClient(),
+			Table:       table.MustNewTable(name, entity),
+		}
+		assert.Nil(t, drivers.NewDynamoClient(context.Background(), conf).CreateTable())
+
+		return drivers.NewSQLClient(context.Background(), conf)
+	}
+
+	accounts := newClient("accounts", account{})
+	campaigns := newClient("campaigns", campaign{})
+
+	t.Run("should run BeforePut and Validate before inserting", func(t *testing.T) {
+		var created account
+		assert.Nil(t, accounts.Put(account{SK: "PROFILE", Email: "  Jane@Example.com "}, &created))
+		assert.Equal(t, "ACCOUNT#jane@example.com", created.PK)
+		assert.Equal(t, "jane@example.com", created.Email)
+
+		err := accounts.Put(account{SK: "PROFILE", Email: "invalid"}, nil)
+		assert.EqualError(t, err, "Validate: invalid email")
+	})
+	t.Run("should update the SET fields without an item", func(t *testing.T) {
+		var updated account
+		sql := accounts.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "ACCOUNT#jane@example.com")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("PROFILE")).
+			Update(expressions.NewKeyCondition("Blocked", true))
+
+		assert.Nil(t, accounts.Update(sql, nil, &updated))
+		assert.True(t, updated.Blocked)
+		assert.Equal(t, "jane@example.com", updated.Email)
+	})
+	t.Run("should reject a put with every violation", func(t *testing.T) {
+		err := campaigns.Put(campaign{PK: "C#1", SK: "META", Status: "DONE"}, nil)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Name", Rule: "required", Message: "is required"},
+			{Field: "Status", Rule: "enum", Message: "must be one of ACTIVE, PAUSED"},
+		}, violations(t, err))
+	})
+	t.Run("should check the assigned fields of an update", func(t *testing.T) {
+		assert.Nil(t, campaigns.Put(campaign{PK: "C#1", SK: "META", Name: "Launch", Status: "ACTIVE"}, nil))
+
+		key := func() domain.SqlExpression {
+			return campaigns.NewExpressionBuilder().
+				Where(expressions.NewKeyCondition("PK", "C#1")).
+				AndWhere(expressions.NewSortKeyCondition("SK").Equal("META"))
+		}
+
+		err := campaigns.Update(key().Update(expressions.NewKeyCondition("Status", "STOPPED")), nil, nil)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Status", Rule: "enum", Message: "must be one of ACTIVE, PAUSED"},
+		}, violations(t, err))
+
+		err = campaigns.Update(key(), campaign{Name: "A name longer than twenty", Status: "PAUSED"}, nil)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Name", Rule: "max", Message: "must have at most 20 characters"},
+		}, violations(t, err))
+
+		var found campaign
+		assert.Nil(t, campaigns.Get(key(), &found))
+		assert.Equal(t, campaign{PK: "C#1", SK: "META", Name: "Launch", Status: "ACTIVE"}, found)
+	})
+}
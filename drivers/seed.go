@@ -0,0 +1,273 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// batchWriteLimit é o máximo de itens aceitos por BatchWriteItem
+	batchWriteLimit = 25
+	// batchWriteRetries é o máximo de tentativas de reenviar os
+	// UnprocessedItems de um BatchWriteItem
+	batchWriteRetries = 8
+)
+
+// Seed popula a tabela com os itens recebidos. Cada item pode ser:
+//
+//   - uma struct (ou ponteiro de struct) com as tags diinamo
+//   - um map[string]types.AttributeValue
+//   - um domain.FixtureFile com itens em JSON / YAML simples ou DynamoDB JSON
+//   - um domain.FixtureDir com fixtures separadas por ambiente
+//
+// Os itens são gravados com PutItem em lotes de BatchWriteItem, então não
+// há limite de quantidade e rodar o Seed mais de uma vez é idempotente.
+//
+// Em ambientes protegidos a operação é recusada, a menos que o client
+// tenha sido configurado com AllowDestructive
+func (d *DynamoClient) Seed(items ...interface{}) error {
+	if err := d.guard("Seed"); err != nil {
+		return err
+	}
+
+	if len(items) <= 0 {
+		d.Info("no items to seed")
+		return nil
+	}
+
+	var attributes []map[string]types.AttributeValue
+	for _, item := range items {
+		parsed, err := d.seedItems(item)
+		if err != nil {
+			return err
+		}
+
+		attributes = append(attributes, parsed...)
+	}
+
+	d.Debug("seeding table with %d items\n", len(attributes))
+
+	for start := 0; start < len(attributes); start += batchWriteLimit {
+		end := start + batchWriteLimit
+		if end > len(attributes) {
+			end = len(attributes)
+		}
+
+		if err := d.batchWrite(d.Ctx, d.putRequests(attributes[start:end])); err != nil {
+			return fmt.Errorf("seed: %v", err)
+		}
+	}
+
+	d.Debug("seed complete: %+v seeded\n", len(attributes))
+
+	return nil
+}
+
+// seedItems converte uma das formas de entrada de Seed em itens
+func (d *DynamoClient) seedItems(item interface{}) ([]map[string]types.AttributeValue, error) {
+	switch v := item.(type) {
+	case map[string]types.AttributeValue:
+		return []map[string]types.AttributeValue{v}, nil
+	case *dynamodb.PutItemInput:
+		return []map[string]types.AttributeValue{v.Item}, nil
+	case domain.FixtureFile:
+		return LoadFixtureFile(string(v))
+	case domain.FixtureDir:
+		return LoadFixtureDir(string(v), d.Environment)
+	}
+
+	value := reflect.ValueOf(item)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("seed: unsupported item type %T", item)
+	}
+
+	attributes, err := expressions.MarshalMap(value.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+
+	return []map[string]types.AttributeValue{attributes}, nil
+}
+
+// putRequests monta os PutRequest de um lote. Itens com a mesma chave no
+// lote são gravados apenas uma vez (o último vence), já que o
+// BatchWriteItem recusa chaves duplicadas
+func (d *DynamoClient) putRequests(items []map[string]types.AttributeValue) []types.WriteRequest {
+	var requests []types.WriteRequest
+	position := map[string]int{}
+
+	for _, item := range items {
+		key := d.itemKey(item)
+		request := types.WriteRequest{PutRequest: &types.PutRequest{Item: item}}
+
+		if i, ok := position[key]; ok {
+			requests[i] = request
+			continue
+		}
+
+		position[key] = len(requests)
+		requests = append(requests, request)
+	}
+
+	return requests
+}
+
+// itemKey serializa a chave primária de um item para comparação
+func (d *DynamoClient) itemKey(item map[string]types.AttributeValue) string {
+	key := ""
+	for _, name := range []string{d.GetMetadata().GetHash(), d.GetMetadata().GetRange()} {
+		if name != "" {
+			key += fmt.Sprintf("%v|", expressions.AttributeValueToDynamoJSON(item[name]))
+		}
+	}
+
+	return key
+}
+
+// batchWrite envia um lote de até 25 WriteRequest, reenviando os
+// UnprocessedItems com backoff exponencial
+func (d *DynamoClient) batchWrite(ctx context.Context, requests []types.WriteRequest) error {
+	backoff := 50 * time.Millisecond
+
+	for attempt := 0; len(requests) > 0; attempt++ {
+		if attempt >= batchWriteRetries {
+			return fmt.Errorf("%d unprocessed items after %d attempts", len(requests), attempt)
+		}
+
+		if attempt > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		out, err := d.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{*d.TableName: requests},
+		})
+		if err != nil {
+			return fmt.Errorf("batch write item: %v", err)
+		}
+
+		requests = out.UnprocessedItems[*d.TableName]
+	}
+
+	return nil
+}
+
+// LoadFixtureDir carrega os arquivos de fixtures da raiz do diretório e
+// do subdiretório do ambiente, em ordem alfabética
+func LoadFixtureDir(dir string, environment domain.Environment) ([]map[string]types.AttributeValue, error) {
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if environment != "" {
+		envDir := filepath.Join(dir, string(environment))
+		if info, err := os.Stat(envDir); err == nil && info.IsDir() {
+			envFiles, err := fixtureFiles(envDir)
+			if err != nil {
+				return nil, err
+			}
+
+			files = append(files, envFiles...)
+		}
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, file := range files {
+		fileItems, err := LoadFixtureFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, fileItems...)
+	}
+
+	return items, nil
+}
+
+// fixtureFiles lista os arquivos .json, .yaml e .yml de um diretório
+func fixtureFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture dir: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// LoadFixtureFile lê um arquivo de fixtures. O arquivo pode conter uma
+// lista de itens ou um único item, em JSON / YAML simples ou DynamoDB JSON
+func LoadFixtureFile(path string) ([]map[string]types.AttributeValue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture: %v", err)
+	}
+
+	var content interface{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&content)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &content)
+	default:
+		return nil, fmt.Errorf("unsupported fixture file: %s", path)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parse fixture %s: %v", path, err)
+	}
+
+	rawItems, ok := content.([]interface{})
+	if !ok {
+		rawItems = []interface{}{content}
+	}
+
+	var items []map[string]types.AttributeValue
+	for i, raw := range rawItems {
+		rawItem, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("fixture %s: item %d should be an object", path, i)
+		}
+
+		item, err := expressions.ItemFromJSON(rawItem)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: item %d: %v", path, i, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,352 @@
+package drivers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	profile struct {
+		PK   string `diinamo:"type:string;hash"`
+		SK   string `diinamo:"type:string;range"`
+		Name string
+	}
+
+	// invalidName falha ao ser gravado, como uma fixture inválida
+	invalidName string
+
+	invalidProfile struct {
+		PK   string `diinamo:"type:string;hash"`
+		SK   string `diinamo:"type:string;range"`
+		Name invalidName
+	}
+
+	// batchRecorder repassa as requisições para o servidor em memória e
+	// registra os BatchWriteItem. Com unprocessed, o primeiro
+	// BatchWriteItem não é gravado e volta inteiro em UnprocessedItems
+	batchRecorder struct {
+		mu          sync.Mutex
+		server      *inmemory.Server
+		unprocessed bool
+		batches     [][]string
+	}
+)
+
+func (n invalidName) MarshalDiinamo() (types.AttributeValue, error) {
+	return nil, fmt.Errorf("invalid name %q", string(n))
+}
+
+func (r *batchRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !strings.HasSuffix(req.Header.Get("X-Amz-Target"), ".BatchWriteItem") {
+		r.server.ServeHTTP(w, req)
+		return
+	}
+
+	body, _ := io.ReadAll(req.Body)
+
+	var input struct {
+		RequestItems map[string][]struct {
+			PutRequest struct {
+				Item map[string]map[string]interface{}
+			}
+		}
+	}
+	_ = json.Unmarshal(body, &input)
+
+	r.mu.Lock()
+	var keys []string
+	for _, requests := range input.RequestItems {
+		for _, request := range requests {
+			keys = append(keys, fmt.Sprint(request.PutRequest.Item["PK"]["S"], "|", request.PutRequest.Item["SK"]["S"]))
+		}
+	}
+	r.batches = append(r.batches, keys)
+
+	unprocessed := r.unprocessed
+	r.unprocessed = false
+	r.mu.Unlock()
+
+	if unprocessed {
+		var raw struct{ RequestItems json.RawMessage }
+		_ = json.Unmarshal(body, &raw)
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		_, _ = fmt.Fprintf(w, `{"UnprocessedItems": %s}`, raw.RequestItems)
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	r.server.ServeHTTP(w, req)
+}
+
+func (r *batchRecorder) sizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+
+	return sizes
+}
+
+func newSeedClient(t *testing.T, recorder *batchRecorder) *drivers.DynamoClient {
+	recorder.server = inmemory.NewServer()
+	t.Cleanup(recorder.server.Close)
+
+	proxy := httptest.NewServer(recorder)
+	t.Cleanup(proxy.Close)
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "profiles",
+		Environment: "testing",
+		Client: dynamodb.New(dynamodb.Options{
+			Region:           "us-east-1",
+			Credentials:      credentials.NewStaticCredentialsProvider("local", "local", ""),
+			EndpointResolver: dynamodb.EndpointResolverFromURL(proxy.URL),
+			Retryer:          aws.NopRetryer{},
+		}),
+		Table: table.MustNewTable("profiles", profile{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	return d
+}
+
+func scanProfiles(t *testing.T, d *drivers.DynamoClient) []profile {
+	var result []profile
+	assert.Nil(t, d.Scan(nil, &result))
+
+	return result
+}
+
+func TestLoadFixtureFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		expected []map[string]types.AttributeValue
+	}{
+		{
+			name: "plain json list",
+			path: "testdata/fixtures/01-users.json",
+			expected: []map[string]types.AttributeValue{
+				{
+					"PK":     &types.AttributeValueMemberS{Value: "USER#1"},
+					"SK":     &types.AttributeValueMemberS{Value: "PROFILE"},
+					"Name":   &types.AttributeValueMemberS{Value: "Jane"},
+					"Age":    &types.AttributeValueMemberN{Value: "30"},
+					"Active": &types.AttributeValueMemberBOOL{Value: true},
+					"Tags": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+						&types.AttributeValueMemberS{Value: "admin"},
+						&types.AttributeValueMemberS{Value: "author"},
+					}},
+					"Address": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+						"City": &types.AttributeValueMemberS{Value: "São Paulo"},
+					}},
+					"DeletedAt": &types.AttributeValueMemberNULL{Value: true},
+				},
+				{
+					"PK":   &types.AttributeValueMemberS{Value: "USER#2"},
+					"SK":   &types.AttributeValueMemberS{Value: "PROFILE"},
+					"Name": &types.AttributeValueMemberS{Value: "John"},
+					"Age":  &types.AttributeValueMemberN{Value: "25.5"},
+				},
+			},
+		},
+		{
+			name: "yaml list",
+			path: "testdata/fixtures/02-orders.yaml",
+			expected: []map[string]types.AttributeValue{
+				{
+					"PK":    &types.AttributeValueMemberS{Value: "ORDER#1"},
+					"SK":    &types.AttributeValueMemberS{Value: "ITEM#1"},
+					"Total": &types.AttributeValueMemberN{Value: "10"},
+					"Paid":  &types.AttributeValueMemberBOOL{Value: false},
+					"Items": &types.AttributeValueMemberL{Value: []types.AttributeValue{
+						&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+							"Name":     &types.AttributeValueMemberS{Value: "Book"},
+							"Quantity": &types.AttributeValueMemberN{Value: "2"},
+						}},
+					}},
+				},
+			},
+		},
+		{
+			name: "single dynamodb json item",
+			path: "testdata/fixtures/03-dynamo.json",
+			expected: []map[string]types.AttributeValue{
+				{
+					"PK":     &types.AttributeValueMemberS{Value: "USER#3"},
+					"SK":     &types.AttributeValueMemberS{Value: "PROFILE"},
+					"Age":    &types.AttributeValueMemberN{Value: "40"},
+					"Roles":  &types.AttributeValueMemberSS{Value: []string{"admin", "viewer"}},
+					"Avatar": &types.AttributeValueMemberB{Value: []byte("hello")},
+					"Settings": &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+						"Theme": &types.AttributeValueMemberS{Value: "dark"},
+						"Beta":  &types.AttributeValueMemberBOOL{Value: true},
+					}},
+				},
+			},
+		},
+		{
+			name: "single yaml item",
+			path: "testdata/fixtures/development/users.yml",
+			expected: []map[string]types.AttributeValue{
+				{
+					"PK":   &types.AttributeValueMemberS{Value: "USER#DEV"},
+					"SK":   &types.AttributeValueMemberS{Value: "PROFILE"},
+					"Name": &types.AttributeValueMemberS{Value: "Developer"},
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			items, err := drivers.LoadFixtureFile(c.path)
+
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, items)
+		})
+	}
+
+	t.Run("should fail with invalid fixtures", func(t *testing.T) {
+		errs := map[string]string{
+			"testdata/fixtures/notes.txt":  "unsupported fixture file: testdata/fixtures/notes.txt",
+			"testdata/invalid/list.json":   "fixture testdata/invalid/list.json: item 0 should be an object",
+			"testdata/invalid/broken.json": "parse fixture testdata/invalid/broken.json: unexpected EOF",
+			"testdata/missing.json":        "read fixture: open testdata/missing.json: no such file or directory",
+		}
+
+		for path, expected := range errs {
+			_, err := drivers.LoadFixtureFile(path)
+			assert.EqualError(t, err, expected, path)
+		}
+	})
+}
+
+func TestLoadFixtureDir(t *testing.T) {
+	cases := []struct {
+		environment domain.Environment
+		expected    []string
+	}{
+		{"", []string{"USER#1", "USER#2", "ORDER#1", "USER#3"}},
+		{"development", []string{"USER#1", "USER#2", "ORDER#1", "USER#3", "USER#DEV"}},
+		{"testing", []string{"USER#1", "USER#2", "ORDER#1", "USER#3", "USER#TEST"}},
+		{"production", []string{"USER#1", "USER#2", "ORDER#1", "USER#3"}},
+	}
+
+	for _, c := range cases {
+		t.Run("environment "+string(c.environment), func(t *testing.T) {
+			items, err := drivers.LoadFixtureDir("testdata/fixtures", c.environment)
+			assert.Nil(t, err)
+
+			var keys []string
+			for _, item := range items {
+				keys = append(keys, item["PK"].(*types.AttributeValueMemberS).Value)
+			}
+
+			assert.Equal(t, c.expected, keys)
+		})
+	}
+
+	t.Run("should fail with a missing directory", func(t *testing.T) {
+		_, err := drivers.LoadFixtureDir("testdata/missing", "testing")
+		assert.EqualError(t, err, "read fixture dir: open testdata/missing: no such file or directory")
+	})
+}
+
+func TestDynamoClient_Seed(t *testing.T) {
+	many := make([]interface{}, 60)
+	for i := range many {
+		many[i] = profile{PK: fmt.Sprintf("USER#%02d", i), SK: "PROFILE", Name: "user"}
+	}
+
+	t.Run("should write in batches of 25 items", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		d := newSeedClient(t, recorder)
+
+		assert.Nil(t, d.Seed(many...))
+		assert.Equal(t, []int{25, 25, 10}, recorder.sizes())
+		assert.Len(t, scanProfiles(t, d), 60)
+	})
+	t.Run("should be idempotent", func(t *testing.T) {
+		d := newSeedClient(t, &batchRecorder{})
+
+		assert.Nil(t, d.Seed(many...))
+		assert.Nil(t, d.Seed(many...))
+		assert.Len(t, scanProfiles(t, d), 60)
+
+		assert.Nil(t, d.Seed(domain.FixtureDir("testdata/fixtures")))
+		assert.Nil(t, d.Seed(domain.FixtureDir("testdata/fixtures")))
+		assert.Len(t, scanProfiles(t, d), 65)
+	})
+	t.Run("should write a repeated key once per batch, the last one wins", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		d := newSeedClient(t, recorder)
+
+		assert.Nil(t, d.Seed(
+			profile{PK: "USER#1", SK: "PROFILE", Name: "first"},
+			profile{PK: "USER#2", SK: "PROFILE", Name: "other"},
+			profile{PK: "USER#1", SK: "PROFILE", Name: "last"},
+		))
+		assert.Equal(t, [][]string{{"USER#1|PROFILE", "USER#2|PROFILE"}}, recorder.batches)
+		assert.ElementsMatch(t, []profile{
+			{PK: "USER#1", SK: "PROFILE", Name: "last"},
+			{PK: "USER#2", SK: "PROFILE", Name: "other"},
+		}, scanProfiles(t, d))
+	})
+	t.Run("should retry the unprocessed items", func(t *testing.T) {
+		recorder := &batchRecorder{unprocessed: true}
+		d := newSeedClient(t, recorder)
+
+		assert.Nil(t, d.Seed(many[:3]...))
+		assert.Equal(t, []int{3, 3}, recorder.sizes())
+		assert.Len(t, scanProfiles(t, d), 3)
+	})
+	t.Run("should seed fixture files and the environment directory", func(t *testing.T) {
+		d := newSeedClient(t, &batchRecorder{})
+
+		assert.Nil(t, d.Seed(domain.FixtureFile("testdata/fixtures/03-dynamo.json"), domain.FixtureDir("testdata/fixtures")))
+
+		names := map[string]string{}
+		for _, p := range scanProfiles(t, d) {
+			names[p.PK] = p.Name
+		}
+
+		assert.Len(t, names, 5)
+		assert.Equal(t, "Tester", names["USER#TEST"])
+	})
+	t.Run("should fail with unsupported items", func(t *testing.T) {
+		d := newSeedClient(t, &batchRecorder{})
+		assert.EqualError(t, d.Seed("USER#1"), "seed: unsupported item type string")
+	})
+	t.Run("should return the marshal errors of struct items", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		d := newSeedClient(t, recorder)
+
+		err := d.Seed(profile{PK: "USER#1", SK: "PROFILE"}, invalidProfile{PK: "USER#2", SK: "PROFILE", Name: "?"})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid name "?"`)
+		assert.Empty(t, recorder.sizes())
+	})
+}
@@ -0,0 +1,133 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TruncateSegments é a quantidade de segmentos do Scan paralelo
+// executado por Truncate
+var TruncateSegments = 4
+
+// Truncate remove todos os itens da tabela, mantendo a tabela e os
+// seus índices.
+//
+// A tabela é lida com um Scan paralelo de TruncateSegments segmentos,
+// projetando apenas as chaves, e os itens são removidos em lotes de
+// BatchWriteItem. Retorna a quantidade de itens removidos.
+//
+// Em ambientes protegidos a operação é recusada, a menos que o client
+// tenha sido configurado com AllowDestructive
+func (d *DynamoClient) Truncate() (int64, error) {
+	if err := d.guard("Truncate"); err != nil {
+		return 0, err
+	}
+
+	return d.truncate()
+}
+
+// truncate remove os itens sem passar pelo guard, para as operações que
+// já foram autorizadas
+func (d *DynamoClient) truncate() (int64, error) {
+	ctx, cancel := context.WithCancel(d.Ctx)
+	defer cancel()
+
+	var (
+		deleted  int64
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+
+	for segment := 0; segment < TruncateSegments; segment++ {
+		wg.Add(1)
+
+		go func(segment int32) {
+			defer wg.Done()
+
+			count, err := d.truncateSegment(ctx, segment, int32(TruncateSegments))
+			atomic.AddInt64(&deleted, count)
+
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(int32(segment))
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return deleted, fmt.Errorf("truncate: %v", firstErr)
+	}
+
+	d.Info("table `%s` truncated: %d items deleted\n", *d.TableName, deleted)
+
+	return deleted, nil
+}
+
+// truncateSegment remove os itens de um segmento do Scan paralelo
+func (d *DynamoClient) truncateSegment(ctx context.Context, segment, totalSegments int32) (int64, error) {
+	projection, names := d.keyProjection()
+
+	p := dynamodb.NewScanPaginator(d.Client, &dynamodb.ScanInput{
+		TableName:                d.TableName,
+		Segment:                  aws.Int32(segment),
+		TotalSegments:            aws.Int32(totalSegments),
+		ProjectionExpression:     aws.String(projection),
+		ExpressionAttributeNames: names,
+	})
+
+	var deleted int64
+
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("scan segment %d: %v", segment, err)
+		}
+
+		for start := 0; start < len(page.Items); start += batchWriteLimit {
+			end := start + batchWriteLimit
+			if end > len(page.Items) {
+				end = len(page.Items)
+			}
+
+			var requests []types.WriteRequest
+			for _, item := range page.Items[start:end] {
+				requests = append(requests, types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{Key: item},
+				})
+			}
+
+			if err = d.batchWrite(ctx, requests); err != nil {
+				return deleted, err
+			}
+
+			deleted += int64(len(requests))
+		}
+	}
+
+	return deleted, nil
+}
+
+// keyProjection monta a ProjectionExpression com as chaves da tabela.
+// Tabelas sem range key projetam apenas o hash
+func (d *DynamoClient) keyProjection() (string, map[string]string) {
+	projection := "#hash"
+	names := map[string]string{"#hash": d.GetMetadata().GetHash()}
+
+	if rangeKey := d.GetMetadata().GetRange(); rangeKey != "" {
+		projection += ", #range"
+		names["#range"] = rangeKey
+	}
+
+	return projection, names
+}
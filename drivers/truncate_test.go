@@ -0,0 +1,115 @@
+package drivers_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type session struct {
+	Token string `diinamo:"type:string;hash"`
+	User  string
+}
+
+func TestDynamoClient_Truncate(t *testing.T) {
+	t.Run("should delete every item in batches and keep the table and its indexes", func(t *testing.T) {
+		recorder := &batchRecorder{}
+		d := newSeedClient(t, recorder)
+
+		items := make([]interface{}, 60)
+		for i := range items {
+			items[i] = profile{PK: fmt.Sprintf("USER#%02d", i), SK: "PROFILE"}
+		}
+		assert.Nil(t, d.Seed(items...))
+
+		recorder.batches = nil
+
+		deleted, err := d.Truncate()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(60), deleted)
+		assert.Empty(t, scanProfiles(t, d))
+
+		total := 0
+		for _, size := range recorder.sizes() {
+			assert.LessOrEqual(t, size, 25)
+			total += size
+		}
+		assert.Equal(t, 60, total)
+
+		assert.Nil(t, d.Seed(profile{PK: "USER#1", SK: "PROFILE"}))
+		assert.Len(t, scanProfiles(t, d), 1)
+	})
+	t.Run("should keep the global secondary indexes", func(t *testing.T) {
+		server := inmemory.NewServer()
+		defer server.Close()
+
+		client := server.NewClient()
+		d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+			TableName:   "orders",
+			Environment: "testing",
+			Client:      client,
+			Table:       table.MustNewTable("orders", order{}),
+		})
+		assert.Nil(t, d.CreateTable())
+		assert.Nil(t, d.Seed(order{PK: "ORDER#1", SK: "ITEM#1", Customer: "C#1"}))
+
+		deleted, err := d.Truncate()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		out, err := client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String("orders")})
+		assert.Nil(t, err)
+		assert.Equal(t, "CustomerIndex", *out.Table.GlobalSecondaryIndexes[0].IndexName)
+
+		assert.Nil(t, d.Seed(order{PK: "ORDER#2", SK: "ITEM#1", Customer: "C#1"}))
+
+		var result []order
+		sql := d.NewExpressionBuilder().Where(expressions.NewKeyCondition("Customer", "C#1"))
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 1)
+	})
+	t.Run("should truncate a table without range key", func(t *testing.T) {
+		server := inmemory.NewServer()
+		defer server.Close()
+
+		d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+			TableName:   "sessions",
+			Environment: "testing",
+			Client:      server.NewClient(),
+			Table:       table.MustNewTable("sessions", session{}),
+		})
+		assert.Equal(t, "Token", *d.HashKey)
+		assert.Equal(t, "", *d.RangeKey)
+		assert.Nil(t, d.CreateTable())
+
+		items := make([]interface{}, 30)
+		for i := range items {
+			items[i] = session{Token: fmt.Sprintf("TOKEN#%02d", i), User: "jane"}
+		}
+		assert.Nil(t, d.Seed(items...))
+
+		deleted, err := d.Truncate()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(30), deleted)
+
+		var result []session
+		assert.Nil(t, d.Scan(nil, &result))
+		assert.Empty(t, result)
+	})
+	t.Run("should return zero for an empty table", func(t *testing.T) {
+		d := newSeedClient(t, &batchRecorder{})
+
+		deleted, err := d.Truncate()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), deleted)
+	})
+}
@@ -0,0 +1,79 @@
+package drivers
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/validation"
+)
+
+// RunValidation verifica as regras de validação das tags diinamo antes do
+// PUT e do UPDATE. Com SetItem o item inteiro é verificado, no UPDATE sem
+// item apenas os campos do SET. As violações voltam em um
+// *validation.Error
+func RunValidation(action domain.Action, sql domain.SqlExpression, tb domain.Table) error {
+	if tb == nil || tb.GetMetadata() == nil {
+		return nil
+	}
+
+	switch action {
+	case PUT, UPDATE:
+	default:
+		return nil
+	}
+
+	if item := sql.Item(); item != nil {
+		return validation.Struct(tb.GetMetadata(), item)
+	}
+
+	if action != UPDATE {
+		return nil
+	}
+
+	assignments, err := setAssignments(sql)
+	if err != nil {
+		return fmt.Errorf("validation: %w", err)
+	}
+
+	return validateAssignments(tb, assignments)
+}
+
+// validateAssignments verifica as regras de validação dos atributos
+// atribuídos em um update, indexados pelo nome do atributo
+func validateAssignments(tb domain.Table, assignments map[string]types.AttributeValue) error {
+	if tb == nil || tb.GetMetadata() == nil {
+		return nil
+	}
+
+	fields := map[string]interface{}{}
+
+	for name, av := range assignments {
+		var value interface{}
+		if err := expressions.Unmarshal(av, &value); err != nil {
+			return fmt.Errorf("validation: decode %s: %v", name, err)
+		}
+
+		fields[name] = value
+	}
+
+	return validation.Fields(tb.GetMetadata(), fields)
+}
+
+// setAssignments retorna os valores do SET da expressão de update,
+// indexados pelo nome do atributo
+func setAssignments(sql domain.SqlExpression) (map[string]types.AttributeValue, error) {
+	assignments := map[string]types.AttributeValue{}
+
+	for _, update := range sql.Updates() {
+		value, err := update.Value()
+		if err != nil {
+			return nil, err
+		}
+
+		assignments[update.Name()] = value
+	}
+
+	return assignments, nil
+}
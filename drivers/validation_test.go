@@ -0,0 +1,86 @@
+package drivers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type campaign struct {
+	PK     string `diinamo:"type:string;hash"`
+	SK     string `diinamo:"type:string;range"`
+	Name   string `diinamo:"type:string;required;max:20"`
+	Status string `diinamo:"type:string;enum:ACTIVE|PAUSED"`
+}
+
+func violations(t *testing.T, err error) []validation.Violation {
+	var validationErr *validation.Error
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	return validationErr.Violations
+}
+
+func TestDynamoClient_Validation(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "campaigns",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("campaigns", campaign{}),
+	})
+	assert.Nil(t, d.CreateTable())
+
+	t.Run("should reject a put with every violation", func(t *testing.T) {
+		var created campaign
+		sql := d.NewExpressionBuilder().SetItem(campaign{PK: "C#1", SK: "META", Status: "DONE"})
+
+		err := d.Perform(drivers.PUT, sql, &created)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Name", Rule: "required", Message: "is required"},
+			{Field: "Status", Rule: "enum", Message: "must be one of ACTIVE, PAUSED"},
+		}, violations(t, err))
+
+		var found campaign
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "C#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META"))
+		assert.Nil(t, d.Perform(drivers.GET, sql, &found))
+		assert.Zero(t, found)
+	})
+	t.Run("should check the fields of a partial update", func(t *testing.T) {
+		var created campaign
+		sql := d.NewExpressionBuilder().SetItem(campaign{PK: "C#1", SK: "META", Name: "Launch", Status: "ACTIVE"})
+		assert.Nil(t, d.Perform(drivers.PUT, sql, &created))
+
+		var updated campaign
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "C#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META")).
+			Update(expressions.NewKeyCondition("Status", "STOPPED"))
+
+		err := d.Perform(drivers.UPDATE, sql, &updated)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Status", Rule: "enum", Message: "must be one of ACTIVE, PAUSED"},
+		}, violations(t, err))
+
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "C#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("META")).
+			Update(expressions.NewKeyCondition("Status", "PAUSED"))
+
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, "PAUSED", updated.Status)
+	})
+}
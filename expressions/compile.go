@@ -0,0 +1,497 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+)
+
+type (
+	// QueryError é o erro de Compile. Column é a coluna (a partir de 1)
+	// do trecho inválido da query, ou 0 quando o erro é da query inteira
+	QueryError struct {
+		Query   string
+		Column  int
+		Message string
+	}
+
+	// IndexError indica que nenhum schema serve as chaves da query, com o
+	// atributo inválido em Attribute, ou que mais de um índice serve, com
+	// os nomes em Indexes
+	IndexError struct {
+		Attribute string
+		Indexes   []string
+		Message   string
+	}
+
+	tokenKind int
+
+	token struct {
+		kind tokenKind
+		text string
+		pos  int
+	}
+
+	// queryCondition é uma condição da query, como SK BETWEEN ? AND ?
+	queryCondition struct {
+		name     string
+		operator string
+		args     []interface{}
+		pos      int
+	}
+
+	// keySchema é o par de chaves da tabela ou de um índice
+	keySchema struct {
+		index     string
+		hashKey   string
+		rangeKey  string
+		secondary bool
+	}
+
+	queryParser struct {
+		query  string
+		tokens []token
+		cursor int
+		args   []interface{}
+		used   int
+	}
+)
+
+const (
+	tokenEOF tokenKind = iota
+	tokenName
+	tokenPlaceholder
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+// Operadores das condições de chave
+const (
+	beginsWith = "begins_with"
+	between    = "between"
+)
+
+// Error implementa a interface error
+func (e *IndexError) Error() string {
+	return "resolve index: " + e.Message
+}
+
+func (e *QueryError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("query %q: %s at column %d", e.Query, e.Message, e.Column)
+	}
+
+	return fmt.Sprintf("query %q: %s", e.Query, e.Message)
+}
+
+// Compile monta uma SqlExpression a partir de uma condição de chave em
+// texto, com os valores posicionais em args. Ex:
+//
+// 	Compile(config, "PK = ?", "USER#1")
+// 	Compile(config, "PK = ? AND begins_with(SK, ?)", "USER#1", "ORDER#")
+// 	Compile(config, "GSI1PK = ? AND SK BETWEEN ? AND ?", "ORG#1", "A", "M")
+//
+// Os operadores aceitos na sort key são =, <, <=, >, >=, BETWEEN e
+// begins_with. Os nomes podem ser os campos da estrutura ou os nomes dos
+// atributos e são verificados contra as chaves da tabela e dos índices
+// do TagsModel. Quando as chaves são de um índice, ele é definido com
+// SetIndex. Os erros de sintaxe e de chave voltam como *QueryError
+func Compile(config *domain.Config, query string, args ...interface{}) (domain.SqlExpression, error) {
+	parser, err := newQueryParser(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := config.Table.GetMetadata()
+	for i := range conditions {
+		conditions[i].name = metadata.GetAttributeName(conditions[i].name)
+	}
+
+	schemas := keySchemas(metadata.GetMapper().GetModel())
+
+	partition, sort, err := parser.splitConditions(conditions, schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := parser.resolveSchema(partition, sort, schemas)
+	if err != nil {
+		return nil, err
+	}
+
+	sql := NewSqlBuilder(config).Where(NewKeyCondition(partition.name, partition.args[0]))
+	if schema.secondary {
+		sql.SetIndex(schema.index)
+	}
+
+	if sort != nil {
+		sql.AndWhere(sortKeyCondition(sort))
+	}
+
+	return sql, nil
+}
+
+func newQueryParser(query string, args []interface{}) (*queryParser, error) {
+	p := &queryParser{query: query, args: args}
+
+	for pos := 0; pos < len(query); {
+		c := query[pos]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c == '?':
+			p.tokens = append(p.tokens, token{kind: tokenPlaceholder, text: "?", pos: pos})
+			pos++
+		case c == '(':
+			p.tokens = append(p.tokens, token{kind: tokenLeftParen, text: "(", pos: pos})
+			pos++
+		case c == ')':
+			p.tokens = append(p.tokens, token{kind: tokenRightParen, text: ")", pos: pos})
+			pos++
+		case c == ',':
+			p.tokens = append(p.tokens, token{kind: tokenComma, text: ",", pos: pos})
+			pos++
+		case c == '=':
+			p.tokens = append(p.tokens, token{kind: tokenOperator, text: "=", pos: pos})
+			pos++
+		case c == '<' || c == '>':
+			operator := string(c)
+			if pos+1 < len(query) && query[pos+1] == '=' {
+				operator += "="
+			}
+
+			p.tokens = append(p.tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		case isNameChar(c):
+			start := pos
+			for pos < len(query) && isNameChar(query[pos]) {
+				pos++
+			}
+
+			p.tokens = append(p.tokens, token{kind: tokenName, text: query[start:pos], pos: start})
+		default:
+			return nil, p.errorAt(pos, fmt.Sprintf("unexpected character %q", c))
+		}
+	}
+
+	p.tokens = append(p.tokens, token{kind: tokenEOF, pos: len(query)})
+
+	return p, nil
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// parse lê até duas condições separadas por AND
+func (p *queryParser) parse() ([]queryCondition, error) {
+	var conditions []queryCondition
+
+	for {
+		condition, err := p.parseCondition()
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, condition)
+
+		if p.peek().kind == tokenEOF {
+			break
+		}
+
+		if !p.isKeyword(p.peek(), "and") {
+			return nil, p.unexpected(p.peek(), "AND")
+		}
+
+		if len(conditions) == 2 {
+			return nil, p.errorAt(p.peek().pos, "a key condition accepts at most a partition key and a sort key condition")
+		}
+
+		p.next()
+	}
+
+	if p.used != len(p.args) {
+		return nil, &QueryError{Query: p.query, Message: fmt.Sprintf("expects %d args, got %d", p.used, len(p.args))}
+	}
+
+	return conditions, nil
+}
+
+// parseCondition lê NAME op ?, NAME BETWEEN ? AND ? ou begins_with(NAME, ?)
+func (p *queryParser) parseCondition() (queryCondition, error) {
+	start := p.next()
+	if start.kind != tokenName || p.isKeyword(start, "and") || p.isKeyword(start, between) {
+		return queryCondition{}, p.unexpected(start, "an attribute name")
+	}
+
+	if p.isKeyword(start, beginsWith) {
+		return p.parseBeginsWith(start)
+	}
+
+	condition := queryCondition{name: start.text, pos: start.pos}
+
+	operator := p.next()
+	switch {
+	case operator.kind == tokenOperator:
+		condition.operator = operator.text
+
+		arg, err := p.placeholder()
+		if err != nil {
+			return queryCondition{}, err
+		}
+
+		condition.args = []interface{}{arg}
+	case p.isKeyword(operator, between):
+		condition.operator = between
+
+		startArg, err := p.placeholder()
+		if err != nil {
+			return queryCondition{}, err
+		}
+
+		if and := p.next(); !p.isKeyword(and, "and") {
+			return queryCondition{}, p.unexpected(and, "AND")
+		}
+
+		endArg, err := p.placeholder()
+		if err != nil {
+			return queryCondition{}, err
+		}
+
+		condition.args = []interface{}{startArg, endArg}
+	default:
+		return queryCondition{}, p.unexpected(operator, "an operator (=, <, <=, >, >=, BETWEEN)")
+	}
+
+	return condition, nil
+}
+
+func (p *queryParser) parseBeginsWith(start token) (queryCondition, error) {
+	if paren := p.next(); paren.kind != tokenLeftParen {
+		return queryCondition{}, p.unexpected(paren, `"("`)
+	}
+
+	name := p.next()
+	if name.kind != tokenName {
+		return queryCondition{}, p.unexpected(name, "an attribute name")
+	}
+
+	if comma := p.next(); comma.kind != tokenComma {
+		return queryCondition{}, p.unexpected(comma, `","`)
+	}
+
+	arg, err := p.placeholder()
+	if err != nil {
+		return queryCondition{}, err
+	}
+
+	if paren := p.next(); paren.kind != tokenRightParen {
+		return queryCondition{}, p.unexpected(paren, `")"`)
+	}
+
+	return queryCondition{name: name.text, operator: beginsWith, args: []interface{}{arg}, pos: start.pos}, nil
+}
+
+// placeholder lê um ? e consome o próximo arg
+func (p *queryParser) placeholder() (interface{}, error) {
+	tok := p.next()
+	if tok.kind != tokenPlaceholder {
+		return nil, p.unexpected(tok, `"?"`)
+	}
+
+	p.used++
+	if p.used > len(p.args) {
+		return nil, nil
+	}
+
+	if p.args[p.used-1] == nil {
+		return nil, p.errorAt(tok.pos, fmt.Sprintf("arg %d is nil", p.used))
+	}
+
+	return p.args[p.used-1], nil
+}
+
+// splitConditions separa a condição da partition key, que deve ser uma
+// igualdade, da condição da sort key
+func (p *queryParser) splitConditions(conditions []queryCondition, schemas []keySchema) (queryCondition, *queryCondition, error) {
+	if len(conditions) == 1 {
+		if conditions[0].operator != "=" {
+			return queryCondition{}, nil, p.errorAt(conditions[0].pos, fmt.Sprintf("the partition key %s must use the = operator", conditions[0].name))
+		}
+
+		return conditions[0], nil, nil
+	}
+
+	first, second := conditions[0], conditions[1]
+	if first.name == second.name {
+		return queryCondition{}, nil, p.errorAt(second.pos, fmt.Sprintf("%s is used twice", second.name))
+	}
+
+	// A partition key pode vir depois da sort key. Ex: SK > ? AND PK = ?
+	if second.operator == "=" && !isHashKey(first.name, schemas) && isHashKey(second.name, schemas) {
+		first, second = second, first
+	}
+
+	if first.operator != "=" {
+		return queryCondition{}, nil, p.errorAt(first.pos, fmt.Sprintf("the partition key %s must use the = operator", first.name))
+	}
+
+	return first, &second, nil
+}
+
+// resolveSchema encontra a tabela ou o índice com as chaves da query.
+// Veja resolveKeySchema
+func (p *queryParser) resolveSchema(partition queryCondition, sort *queryCondition, schemas []keySchema) (keySchema, error) {
+	var sortName *string
+	if sort != nil {
+		sortName = &sort.name
+	}
+
+	schema, err := resolveKeySchema(partition.name, sortName, schemas)
+
+	var indexErr *IndexError
+	if !errors.As(err, &indexErr) {
+		return schema, err
+	}
+
+	switch {
+	case len(indexErr.Indexes) > 0:
+		return keySchema{}, &QueryError{Query: p.query, Message: indexErr.Message}
+	case indexErr.Attribute == partition.name:
+		return keySchema{}, p.errorAt(partition.pos, indexErr.Message)
+	}
+
+	return keySchema{}, p.errorAt(sort.pos, indexErr.Message)
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.cursor]
+}
+
+func (p *queryParser) next() token {
+	tok := p.tokens[p.cursor]
+	if tok.kind != tokenEOF {
+		p.cursor++
+	}
+
+	return tok
+}
+
+func (p *queryParser) isKeyword(tok token, keyword string) bool {
+	return tok.kind == tokenName && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *queryParser) unexpected(tok token, expected string) error {
+	if tok.kind == tokenEOF {
+		return p.errorAt(tok.pos, fmt.Sprintf("unexpected end of query, expected %s", expected))
+	}
+
+	return p.errorAt(tok.pos, fmt.Sprintf("unexpected %q, expected %s", tok.text, expected))
+}
+
+func (p *queryParser) errorAt(pos int, message string) error {
+	return &QueryError{Query: p.query, Column: pos + 1, Message: message}
+}
+
+// keySchemas lista as chaves da tabela, dos GSI e dos LSI
+func keySchemas(model *tagManager.TagsModel) []keySchema {
+	if model == nil {
+		return nil
+	}
+
+	schemas := []keySchema{{hashKey: model.Hash, rangeKey: model.Range}}
+
+	for _, gsi := range model.GSI {
+		schemas = append(schemas, keySchema{index: gsi.IndexName, hashKey: gsi.Hash, rangeKey: gsi.Range, secondary: true})
+	}
+
+	for _, lsi := range model.LSI {
+		schemas = append(schemas, keySchema{index: lsi.IndexName, hashKey: lsi.Hash, rangeKey: lsi.Range, secondary: true})
+	}
+
+	return schemas
+}
+
+// resolveKeySchema encontra a tabela ou o índice com a partition key e a
+// sort key informadas. A tabela tem prioridade. Mais de um índice com as
+// mesmas chaves é um erro
+func resolveKeySchema(partition string, sort *string, schemas []keySchema) (keySchema, error) {
+	var matches []keySchema
+	partitionFound := false
+
+	for _, schema := range schemas {
+		if schema.hashKey != partition {
+			continue
+		}
+
+		partitionFound = true
+
+		if sort != nil && schema.rangeKey != *sort {
+			continue
+		}
+
+		if !schema.secondary {
+			return schema, nil
+		}
+
+		matches = append(matches, schema)
+	}
+
+	switch {
+	case !partitionFound:
+		return keySchema{}, &IndexError{Attribute: partition, Message: fmt.Sprintf("%s is not the partition key of the table or of an index", partition)}
+	case len(matches) == 0:
+		return keySchema{}, &IndexError{Attribute: *sort, Message: fmt.Sprintf("%s is not a sort key paired with %s", *sort, partition)}
+	case len(matches) > 1:
+		names := make([]string, 0, len(matches))
+		for _, match := range matches {
+			names = append(names, match.index)
+		}
+
+		return keySchema{}, &IndexError{Indexes: names, Message: fmt.Sprintf("ambiguous keys, indexes %s match", strings.Join(names, ", "))}
+	}
+
+	return matches[0], nil
+}
+
+func isHashKey(name string, schemas []keySchema) bool {
+	for _, schema := range schemas {
+		if schema.hashKey == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortKeyCondition(condition *queryCondition) domain.WithSortKeyCondition {
+	sort := NewSortKeyCondition(condition.name)
+
+	switch condition.operator {
+	case "=":
+		return sort.Equal(condition.args[0])
+	case "<":
+		return sort.LessThan(condition.args[0])
+	case "<=":
+		return sort.LessThanOrEqual(condition.args[0])
+	case ">":
+		return sort.GreaterThan(condition.args[0])
+	case ">=":
+		return sort.GreaterThanOrEqual(condition.args[0])
+	case between:
+		return sort.Between(condition.args[0], condition.args[1])
+	}
+
+	return sort.StarsWith(condition.args[0])
+}
@@ -0,0 +1,194 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type ticket struct {
+	PK       string `diinamo:"type:string;hash;name:pk"`
+	SK       string `diinamo:"type:string;range"`
+	Owner    string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+	Status   string `diinamo:"type:string;gsi:StatusIndex;keyPairs:Status=Priority"`
+	Priority int    `diinamo:"type:number;lsi:PriorityIndex;keyPairs:PK=Priority"`
+	Title    string
+}
+
+func compileConfig() *domain.Config {
+	return &domain.Config{TableName: "tickets", Table: table.MustNewTable("tickets", ticket{})}
+}
+
+func TestCompile(t *testing.T) {
+	config := compileConfig()
+
+	t.Run("should compile a partition key condition", func(t *testing.T) {
+		sql, err := expressions.Compile(config, "PK = ?", "TICKET#1")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "#n0 = :v0", *sql.KeyCondition())
+		assert.Equal(t, map[string]string{"#n0": "pk"}, sql.AttributeNames())
+		assert.Nil(t, sql.IndexName())
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "TICKET#1"}, sql.ExpressionAttributeValues()[":v0"])
+	})
+	t.Run("should compile begins_with", func(t *testing.T) {
+		sql, err := expressions.Compile(config, "pk = ? and begins_with(SK, ?)", "TICKET#1", "COMMENT#")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "#n0 = :v0 and begins_with(#n1, :v1)", *sql.KeyCondition())
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "COMMENT#"}, sql.ExpressionAttributeValues()[":v1"])
+	})
+	t.Run("should compile between with spaces", func(t *testing.T) {
+		sql, err := expressions.Compile(config, "Owner = ? AND SK BETWEEN ? AND ?", "jane", "A", "M")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "#n0 = :v0 and #n1 BETWEEN :v1 AND :v2", *sql.KeyCondition())
+		assert.Equal(t, map[string]string{"#n0": "Owner", "#n1": "SK"}, sql.AttributeNames())
+		assert.Equal(t, "OwnerIndex", *sql.IndexName())
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "M"}, sql.ExpressionAttributeValues()[":v2"])
+	})
+	t.Run("should render between like the builder", func(t *testing.T) {
+		compiled, err := expressions.Compile(config, "PK = ? AND SK BETWEEN ? AND ?", "TICKET#1", "A", "M")
+		assert.Nil(t, err)
+
+		built := expressions.NewSqlBuilder(config).
+			Where(expressions.NewKeyCondition("PK", "TICKET#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Between("A", "M"))
+
+		assert.Equal(t, "#n0 = :v0 and #n1 BETWEEN :v1 AND :v2", *compiled.KeyCondition())
+		assert.Equal(t, *built.KeyCondition(), *compiled.KeyCondition())
+		assert.Equal(t, built.ExpressionAttributeValues(), compiled.ExpressionAttributeValues())
+	})
+	t.Run("should resolve the index by the sort key", func(t *testing.T) {
+		sql, err := expressions.Compile(config, "Status = ? AND Priority >= ?", "OPEN", 2)
+		assert.Nil(t, err)
+		assert.Equal(t, "StatusIndex", *sql.IndexName())
+
+		sql, err = expressions.Compile(config, "Priority < ? AND PK = ?", 3, "TICKET#1")
+		assert.Nil(t, err)
+		assert.Equal(t, "PriorityIndex", *sql.IndexName())
+		assert.Equal(t, map[string]string{"#n0": "pk", "#n1": "Priority"}, sql.AttributeNames())
+	})
+	t.Run("should return clear errors", func(t *testing.T) {
+		cases := []struct {
+			query string
+			args  []interface{}
+			err   string
+		}{
+			{"PK = ? AND", []interface{}{"A"}, `query "PK = ? AND": unexpected end of query, expected an attribute name at column 11`},
+			{"PK == ?", []interface{}{"A"}, `query "PK == ?": unexpected "=", expected "?" at column 5`},
+			{"PK = ? OR SK = ?", []interface{}{"A", "B"}, `query "PK = ? OR SK = ?": unexpected "OR", expected AND at column 8`},
+			{"PK = ? AND SK = ?", []interface{}{"A"}, `query "PK = ? AND SK = ?": expects 2 args, got 1`},
+			{"PK = ?", []interface{}{"A", "B"}, `query "PK = ?": expects 1 args, got 2`},
+			{"PK = ? AND SK = ? AND Title = ?", []interface{}{"A", "B", "C"}, `query "PK = ? AND SK = ? AND Title = ?": a key condition accepts at most a partition key and a sort key condition at column 19`},
+			{"PK > ?", []interface{}{"A"}, `query "PK > ?": the partition key pk must use the = operator at column 1`},
+			{"Title = ?", []interface{}{"A"}, `query "Title = ?": Title is not the partition key of the table or of an index at column 1`},
+			{"PK = ? AND Title = ?", []interface{}{"A", "B"}, `query "PK = ? AND Title = ?": Title is not a sort key paired with pk at column 12`},
+			{"PK = ? AND begins_with(SK ?)", []interface{}{"A", "B"}, `query "PK = ? AND begins_with(SK ?)": unexpected "?", expected "," at column 27`},
+			{"PK = $1", []interface{}{"A"}, `query "PK = $1": unexpected character '$' at column 6`},
+			{"PK = ?", []interface{}{nil}, `query "PK = ?": arg 1 is nil at column 6`},
+		}
+
+		for _, c := range cases {
+			_, err := expressions.Compile(config, c.query, c.args...)
+
+			var queryErr *expressions.QueryError
+			assert.ErrorAs(t, err, &queryErr)
+			assert.EqualError(t, err, c.err)
+		}
+	})
+	t.Run("should fail on ambiguous indexes", func(t *testing.T) {
+		type ambiguous struct {
+			PK    string `diinamo:"type:string;hash"`
+			SK    string `diinamo:"type:string;range"`
+			Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+			Title string `diinamo:"gsi:OwnerCopyIndex;keyPairs:Owner=SK"`
+		}
+
+		config := &domain.Config{Table: table.MustNewTable("ambiguous", ambiguous{})}
+
+		_, err := expressions.Compile(config, "Owner = ?", "jane")
+		assert.EqualError(t, err, `query "Owner = ?": ambiguous keys, indexes OwnerIndex, OwnerCopyIndex match`)
+	})
+}
+
+func TestExpression_ResolveIndex(t *testing.T) {
+	config := compileConfig()
+
+	t.Run("should prefer the table keys", func(t *testing.T) {
+		sql := expressions.NewSqlBuilder(config).
+			Where(expressions.NewKeyCondition("PK", "TICKET#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").StarsWith("COMMENT#"))
+
+		assert.Nil(t, sql.ResolveIndex())
+		assert.Nil(t, sql.IndexName())
+	})
+	t.Run("should infer the index from the key attributes", func(t *testing.T) {
+		sql := expressions.NewSqlBuilder(config).
+			Where(expressions.NewKeyCondition("Status", "OPEN")).
+			AndWhere(expressions.NewSortKeyCondition("Priority").GreaterThan(1))
+
+		assert.Nil(t, sql.ResolveIndex())
+		assert.Equal(t, "StatusIndex", *sql.IndexName())
+
+		sql = expressions.NewSqlBuilder(config).
+			Where(expressions.NewKeyCondition("PK", "TICKET#1")).
+			AndWhere(expressions.NewSortKeyCondition("Priority").Equal(2))
+
+		assert.Nil(t, sql.ResolveIndex())
+		assert.Equal(t, "PriorityIndex", *sql.IndexName())
+	})
+	t.Run("should keep the index of SetIndex", func(t *testing.T) {
+		sql := expressions.NewSqlBuilder(config).
+			SetIndex("OwnerIndex").
+			Where(expressions.NewKeyCondition("PK", "TICKET#1"))
+
+		assert.Nil(t, sql.ResolveIndex())
+		assert.Equal(t, "OwnerIndex", *sql.IndexName())
+	})
+	t.Run("should fail when no index serves the keys", func(t *testing.T) {
+		cases := []struct {
+			sql domain.SqlExpression
+			err string
+		}{
+			{expressions.NewSqlBuilder(config), "resolve index: the query has no partition key condition"},
+			{expressions.NewSqlBuilder(config).Where(expressions.NewKeyCondition("Title", "A")), "resolve index: Title is not the partition key of the table or of an index"},
+			{
+				expressions.NewSqlBuilder(config).
+					Where(expressions.NewKeyCondition("Owner", "jane")).
+					AndWhere(expressions.NewSortKeyCondition("Priority").Equal(1)),
+				"resolve index: Priority is not a sort key paired with Owner",
+			},
+		}
+
+		for _, c := range cases {
+			err := c.sql.ResolveIndex()
+
+			var indexErr *expressions.IndexError
+			assert.ErrorAs(t, err, &indexErr)
+			assert.EqualError(t, err, c.err)
+			assert.Nil(t, c.sql.IndexName())
+		}
+	})
+	t.Run("should fail on ambiguous indexes", func(t *testing.T) {
+		type ambiguous struct {
+			PK    string `diinamo:"type:string;hash"`
+			SK    string `diinamo:"type:string;range"`
+			Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+			Title string `diinamo:"gsi:OwnerCopyIndex;keyPairs:Owner=SK"`
+		}
+
+		sql := expressions.NewSqlBuilder(&domain.Config{Table: table.MustNewTable("ambiguous", ambiguous{})}).
+			Where(expressions.NewKeyCondition("Owner", "jane"))
+
+		err := sql.ResolveIndex()
+
+		var indexErr *expressions.IndexError
+		assert.ErrorAs(t, err, &indexErr)
+		assert.Equal(t, []string{"OwnerIndex", "OwnerCopyIndex"}, indexErr.Indexes)
+	})
+}
@@ -0,0 +1,239 @@
+package expressions
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// dynamoJSONTypes são os descritores de tipo do formato DynamoDB JSON
+var dynamoJSONTypes = map[string]bool{
+	"S": true, "N": true, "B": true, "BOOL": true, "NULL": true,
+	"M": true, "L": true, "SS": true, "NS": true, "BS": true,
+}
+
+// IsDynamoJSON verifica se um item decodificado de JSON / YAML está no
+// formato DynamoDB JSON, ou seja, se todos os valores são objetos com
+// um único descritor de tipo. Ex: {"PK": {"S": "USER#1"}}
+func IsDynamoJSON(item map[string]interface{}) bool {
+	if len(item) == 0 {
+		return false
+	}
+
+	for _, value := range item {
+		descriptor, ok := value.(map[string]interface{})
+		if !ok || len(descriptor) != 1 {
+			return false
+		}
+
+		for key := range descriptor {
+			if !dynamoJSONTypes[key] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ItemFromJSON converte um item decodificado de JSON / YAML, em JSON
+// simples ou DynamoDB JSON, em um item do DynamoDB
+func ItemFromJSON(item map[string]interface{}) (map[string]types.AttributeValue, error) {
+	convert := PlainToAttributeValue
+	if IsDynamoJSON(item) {
+		convert = DynamoJSONToAttributeValue
+	}
+
+	attributes := map[string]types.AttributeValue{}
+	for name, value := range item {
+		attr, err := convert(value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", name, err)
+		}
+
+		attributes[name] = attr
+	}
+
+	return attributes, nil
+}
+
+// PlainToAttributeValue converte um valor de JSON / YAML simples em um
+// types.AttributeValue. Strings viram S, números N, listas L e objetos M
+func PlainToAttributeValue(value interface{}) (types.AttributeValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case string:
+		return &types.AttributeValueMemberS{Value: v}, nil
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: v}, nil
+	case json.Number:
+		return &types.AttributeValueMemberN{Value: v.String()}, nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return &types.AttributeValueMemberN{Value: fmt.Sprint(v)}, nil
+	case []interface{}:
+		list := make([]types.AttributeValue, 0, len(v))
+		for _, item := range v {
+			attr, err := PlainToAttributeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, attr)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case map[string]interface{}:
+		members := map[string]types.AttributeValue{}
+		for name, item := range v {
+			attr, err := PlainToAttributeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			members[name] = attr
+		}
+		return &types.AttributeValueMemberM{Value: members}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value %v (%T)", value, value)
+}
+
+// DynamoJSONToAttributeValue converte um descritor do formato DynamoDB
+// JSON, como {"S": "value"} ou {"N": "10"}, em um types.AttributeValue
+func DynamoJSONToAttributeValue(value interface{}) (types.AttributeValue, error) {
+	descriptor, ok := value.(map[string]interface{})
+	if !ok || len(descriptor) != 1 {
+		return nil, fmt.Errorf("invalid dynamodb json value %v", value)
+	}
+
+	for kind, raw := range descriptor {
+		switch kind {
+		case "S":
+			return &types.AttributeValueMemberS{Value: fmt.Sprint(raw)}, nil
+		case "N":
+			return &types.AttributeValueMemberN{Value: fmt.Sprint(raw)}, nil
+		case "B":
+			b, err := base64.StdEncoding.DecodeString(fmt.Sprint(raw))
+			if err != nil {
+				return nil, err
+			}
+			return &types.AttributeValueMemberB{Value: b}, nil
+		case "BOOL":
+			b, ok := raw.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid BOOL value %v", raw)
+			}
+			return &types.AttributeValueMemberBOOL{Value: b}, nil
+		case "NULL":
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		case "SS", "NS", "BS":
+			list, ok := raw.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid %s value %v", kind, raw)
+			}
+			return setFromDynamoJSON(kind, list)
+		case "L":
+			list, ok := raw.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid L value %v", raw)
+			}
+			members := make([]types.AttributeValue, 0, len(list))
+			for _, item := range list {
+				attr, err := DynamoJSONToAttributeValue(item)
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, attr)
+			}
+			return &types.AttributeValueMemberL{Value: members}, nil
+		case "M":
+			mapping, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("invalid M value %v", raw)
+			}
+			members := map[string]types.AttributeValue{}
+			for name, item := range mapping {
+				attr, err := DynamoJSONToAttributeValue(item)
+				if err != nil {
+					return nil, err
+				}
+				members[name] = attr
+			}
+			return &types.AttributeValueMemberM{Value: members}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid dynamodb json value %v", value)
+}
+
+// AttributeValueToDynamoJSON converte um types.AttributeValue no
+// descritor do formato DynamoDB JSON
+func AttributeValueToDynamoJSON(attr types.AttributeValue) interface{} {
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberS:
+		return map[string]interface{}{"S": v.Value}
+	case *types.AttributeValueMemberN:
+		return map[string]interface{}{"N": v.Value}
+	case *types.AttributeValueMemberB:
+		return map[string]interface{}{"B": base64.StdEncoding.EncodeToString(v.Value)}
+	case *types.AttributeValueMemberBOOL:
+		return map[string]interface{}{"BOOL": v.Value}
+	case *types.AttributeValueMemberNULL:
+		return map[string]interface{}{"NULL": true}
+	case *types.AttributeValueMemberSS:
+		return map[string]interface{}{"SS": v.Value}
+	case *types.AttributeValueMemberNS:
+		return map[string]interface{}{"NS": v.Value}
+	case *types.AttributeValueMemberBS:
+		encoded := make([]string, len(v.Value))
+		for i, b := range v.Value {
+			encoded[i] = base64.StdEncoding.EncodeToString(b)
+		}
+		return map[string]interface{}{"BS": encoded}
+	case *types.AttributeValueMemberL:
+		list := make([]interface{}, len(v.Value))
+		for i, item := range v.Value {
+			list[i] = AttributeValueToDynamoJSON(item)
+		}
+		return map[string]interface{}{"L": list}
+	case *types.AttributeValueMemberM:
+		return map[string]interface{}{"M": ItemToDynamoJSON(v.Value)}
+	}
+
+	return nil
+}
+
+// ItemToDynamoJSON converte um item do DynamoDB no formato DynamoDB JSON
+func ItemToDynamoJSON(item map[string]types.AttributeValue) map[string]interface{} {
+	out := make(map[string]interface{}, len(item))
+	for name, attr := range item {
+		out[name] = AttributeValueToDynamoJSON(attr)
+	}
+
+	return out
+}
+
+func setFromDynamoJSON(kind string, list []interface{}) (types.AttributeValue, error) {
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		values = append(values, fmt.Sprint(item))
+	}
+
+	switch kind {
+	case "SS":
+		return &types.AttributeValueMemberSS{Value: values}, nil
+	case "NS":
+		return &types.AttributeValueMemberNS{Value: values}, nil
+	}
+
+	binaries := make([][]byte, 0, len(values))
+	for _, value := range values {
+		b, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		binaries = append(binaries, b)
+	}
+
+	return &types.AttributeValueMemberBS{Value: binaries}, nil
+}
@@ -0,0 +1,88 @@
+package expressions_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeJSON(t *testing.T, raw string) map[string]interface{} {
+	var item map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+	assert.Nil(t, decoder.Decode(&item))
+
+	return item
+}
+
+func TestIsDynamoJSON(t *testing.T) {
+	cases := []struct {
+		name     string
+		item     string
+		expected bool
+	}{
+		{"dynamodb json", `{"PK": {"S": "USER#1"}, "Age": {"N": "30"}}`, true},
+		{"nested dynamodb json", `{"Meta": {"M": {"Theme": {"S": "dark"}}}}`, true},
+		{"empty item", `{}`, false},
+		{"plain values", `{"PK": "USER#1", "Age": 30}`, false},
+		{"mixed values", `{"PK": {"S": "USER#1"}, "Name": "Jane"}`, false},
+		{"plain object", `{"Address": {"City": "São Paulo"}}`, false},
+		{"object with two descriptors", `{"Value": {"S": "a", "N": "1"}}`, false},
+		{"unknown descriptor", `{"Value": {"X": "a"}}`, false},
+		// Um objeto simples cujos campos são todos descritores é
+		// ambíguo e é tratado como DynamoDB JSON
+		{"plain object keyed by a descriptor", `{"Size": {"N": 1}}`, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, expressions.IsDynamoJSON(decodeJSON(t, c.item)))
+		})
+	}
+}
+
+func TestItemFromJSON(t *testing.T) {
+	t.Run("should convert plain json", func(t *testing.T) {
+		item, err := expressions.ItemFromJSON(decodeJSON(t, `{"PK": "USER#1", "Age": 30.5, "Tags": ["a"], "Active": true, "Deleted": null}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]types.AttributeValue{
+			"PK":      &types.AttributeValueMemberS{Value: "USER#1"},
+			"Age":     &types.AttributeValueMemberN{Value: "30.5"},
+			"Tags":    &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "a"}}},
+			"Active":  &types.AttributeValueMemberBOOL{Value: true},
+			"Deleted": &types.AttributeValueMemberNULL{Value: true},
+		}, item)
+	})
+	t.Run("should round trip dynamodb json", func(t *testing.T) {
+		item := map[string]types.AttributeValue{
+			"PK":     &types.AttributeValueMemberS{Value: "USER#1"},
+			"Age":    &types.AttributeValueMemberN{Value: "30"},
+			"Avatar": &types.AttributeValueMemberB{Value: []byte("hello")},
+			"Roles":  &types.AttributeValueMemberSS{Value: []string{"admin"}},
+			"Scores": &types.AttributeValueMemberNS{Value: []string{"1", "2"}},
+			"Keys":   &types.AttributeValueMemberBS{Value: [][]byte{[]byte("k")}},
+			"List":   &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberBOOL{Value: false}}},
+			"Meta":   &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{"Gone": &types.AttributeValueMemberNULL{Value: true}}},
+		}
+
+		raw, err := json.Marshal(expressions.ItemToDynamoJSON(item))
+		assert.Nil(t, err)
+
+		decoded, err := expressions.ItemFromJSON(decodeJSON(t, string(raw)))
+		assert.Nil(t, err)
+		assert.Equal(t, item, decoded)
+	})
+	t.Run("should fail with invalid dynamodb json", func(t *testing.T) {
+		_, err := expressions.ItemFromJSON(decodeJSON(t, `{"Avatar": {"B": "%%%"}}`))
+		assert.EqualError(t, err, "attribute Avatar: illegal base64 data at input byte 0")
+
+		_, err = expressions.ItemFromJSON(decodeJSON(t, `{"Active": {"BOOL": "yes"}}`))
+		assert.EqualError(t, err, "attribute Active: invalid BOOL value yes")
+	})
+}
@@ -3,10 +3,12 @@ package expressions
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
 )
 
 type (
@@ -14,14 +16,23 @@ type (
 		indexName *string
 		hashKey   *string
 		rangeKey  *string
+		metadata  tagManager.Manager
 
 		item interface{}
 
-		expressions      map[string]domain.WithCondition
-		updateExpression string
+		expressions map[string]domain.WithCondition
+		filters     []domain.WithCondition
+		updates     []domain.WithCondition
+	}
 
-		attributeNames  map[string]string
-		attributeValues map[string]types.AttributeValue
+	// rendered são as expressões de uma requisição com os placeholders
+	rendered struct {
+		keyCondition string
+		filter       string
+		update       string
+		placeholders *Placeholders
+		// err é o primeiro erro de conversão dos valores
+		err error
 	}
 )
 
@@ -29,6 +40,7 @@ func NewSqlBuilder(config *domain.Config) domain.SqlExpression {
 	return &Expression{
 		hashKey:     aws.String(config.Table.GetMetadata().GetHash()),
 		rangeKey:    aws.String(config.Table.GetMetadata().GetRange()),
+		metadata:    config.Table.GetMetadata(),
 		expressions: map[string]domain.WithCondition{},
 	}
 }
@@ -44,75 +56,130 @@ func (e *Expression) IndexName() *string {
 	return e.indexName
 }
 
+// ResolveIndex infere o índice da Query pelos atributos usados em Where e
+// AndWhere, comparando com as chaves da tabela, dos GSI e dos LSI do
+// TagsModel. A tabela tem prioridade. Um índice definido com SetIndex é
+// mantido. Volta um *IndexError quando nenhum índice, ou mais de um,
+// serve as chaves, em vez de enviar uma Query que o DynamoDB recusa
+func (e *Expression) ResolveIndex() error {
+	if e.indexName != nil {
+		return nil
+	}
+
+	key := e.expressions["key"]
+	if key == nil {
+		return &IndexError{Message: "the query has no partition key condition"}
+	}
+
+	var sort *string
+	if sortKey := e.expressions["sortKey"]; sortKey != nil {
+		sort = aws.String(sortKey.Name())
+	}
+
+	var model *tagManager.TagsModel
+	if e.metadata != nil {
+		model = e.metadata.GetMapper().GetModel()
+	}
+
+	schema, err := resolveKeySchema(key.Name(), sort, keySchemas(model))
+	if err != nil {
+		return err
+	}
+
+	if schema.secondary {
+		e.indexName = aws.String(schema.index)
+	}
+
+	return nil
+}
+
 func (e *Expression) Where(condition domain.WithCondition) domain.SqlExpression {
-	e.expressions["key"] = condition
+	e.expressions["key"] = e.attributeName(condition)
 	return e
 }
 
 func (e *Expression) AndWhere(keyCondition domain.WithSortKeyCondition) domain.SqlExpression {
 	if keyCondition.HasSortKey() {
-		e.expressions["sortKey"] = keyCondition
+		e.expressions["sortKey"] = e.attributeName(keyCondition)
 	}
 
 	return e
 }
 
-func (e *Expression) ExpressionAttributeValues() map[string]types.AttributeValue {
-	if e.updateExpression != "" {
-		return e.attributeValues
-	}
+// Filter adiciona uma condição ao FilterExpression da Query ou do Scan.
+// As condições são combinadas com AND. Ex:
+//
+// 	sql.Filter(expressions.NewSortKeyCondition("Status").Equal("ACTIVE"))
+func (e *Expression) Filter(condition domain.WithCondition) domain.SqlExpression {
+	e.filters = append(e.filters, e.attributeName(condition))
+	return e
+}
 
-	if e.expressions["sortKey"] == nil {
-		return map[string]types.AttributeValue{":key": e.expressions["key"].Value()}
+// FilterExpression retorna a condição de Filter ou nil sem filtros
+func (e *Expression) FilterExpression() *string {
+	if filter := e.render().filter; filter != "" {
+		return aws.String(filter)
 	}
 
-	sortKeyCondition := e.expressions["sortKey"].(domain.WithSortKeyCondition)
-
-	if sortKeyCondition.SimpleCondition() {
-		return map[string]types.AttributeValue{
-			":key":     e.expressions["key"].Value(),
-			":sortVal": sortKeyCondition.Value(),
-		}
-	}
+	return nil
+}
 
-	return map[string]types.AttributeValue{
-		":key": e.expressions["key"].Value(),
-		":start": e.getAttributeValueMember(
-			reflect.ValueOf(sortKeyCondition.StartValue()),
-		),
-		":end": e.getAttributeValueMember(
-			reflect.ValueOf(sortKeyCondition.EndValue()),
-		),
-	}
+// ExpressionAttributeValues retorna os valores dos placeholders :v das
+// expressões. Veja render
+func (e *Expression) ExpressionAttributeValues() map[string]types.AttributeValue {
+	return e.render().placeholders.Values()
 }
 
+// Key retorna a chave do item das condições de Where e AndWhere. Os
+// valores que falham na conversão ficam fora da chave, veja Err
 func (e *Expression) Key() map[string]types.AttributeValue {
+	keys, _ := e.key()
+	return keys
+}
+
+func (e *Expression) key() (map[string]types.AttributeValue, error) {
 	keys := map[string]types.AttributeValue{}
 
-	for key, expr := range e.expressions {
-		switch key {
-		case "key", "sortKey":
-			if expr.Name() != "" {
-				keys[expr.Name()] = expr.Value()
-			}
+	for _, key := range []string{"key", "sortKey"} {
+		expr := e.expressions[key]
+		if expr == nil || expr.Name() == "" {
+			continue
 		}
+
+		value, err := expr.Value()
+		if err != nil {
+			return keys, err
+		}
+
+		keys[expr.Name()] = value
 	}
 
-	return keys
+	return keys, nil
 }
 
-func (e *Expression) KeyCondition() *string {
-	if e.expressions["sortKey"] == nil {
-		return aws.String(e.expressions["key"].KeyCondition())
+// Err retorna o primeiro erro de conversão dos valores da chave, das
+// condições, do update ou do item de SetItem. As operações verificam Err
+// antes de chamar o DynamoDB
+func (e *Expression) Err() error {
+	if _, err := e.key(); err != nil {
+		return err
+	}
+
+	if err := e.render().err; err != nil {
+		return err
+	}
+
+	if e.item != nil {
+		if _, err := MarshalMap(e.item); err != nil {
+			return err
+		}
 	}
 
-	sortKeyCondition := e.expressions["sortKey"].KeyCondition()
+	return nil
+}
 
-	return aws.String(fmt.Sprintf(
-		"%s and %s",
-		e.expressions["key"].KeyCondition(),
-		sortKeyCondition,
-	))
+func (e *Expression) KeyCondition() *string {
+	return aws.String(e.render().keyCondition)
 }
 
 func (e *Expression) SetItem(item interface{}) domain.SqlExpression {
@@ -125,66 +192,127 @@ func (e *Expression) SetItem(item interface{}) domain.SqlExpression {
 	return e
 }
 
+// Item retorna o item de SetItem ou nil
+func (e *Expression) Item() interface{} {
+	return e.item
+}
+
 func (e *Expression) Names() map[string]types.AttributeValue {
 	return map[string]types.AttributeValue{}
 }
 
+// Values converte o item de SetItem no item do DynamoDB. Veja Marshal.
+// Quando a conversão falha, Values retorna nil e o erro volta em Err
 func (e *Expression) Values() map[string]types.AttributeValue {
 	if e.item == nil {
 		panic("to call Values, before call SetItem")
 	}
 
-	item := reflect.ValueOf(e.item)
-
-	attributes := map[string]types.AttributeValue{}
-
-	for i := 0; i < item.NumField(); i++ {
-		field := item.Field(i)
-		name := item.Type().Field(i).Name
-
-		attributes[name] = e.getAttributeValueMember(field)
+	attributes, err := MarshalMap(e.item)
+	if err != nil {
+		return nil
 	}
 
 	return attributes
 }
 
-func (e *Expression) getAttributeValueMember(val reflect.Value) types.AttributeValue {
-	return GetAttributeValueMemberType(val)
+// attributeName troca o nome do campo da estrutura usado na condição pelo
+// nome do atributo no DynamoDB (tag name ou dynamodbav)
+func (e *Expression) attributeName(condition domain.WithCondition) domain.WithCondition {
+	if e.metadata != nil {
+		condition.SetName(e.metadata.GetAttributeName(condition.Name()))
+	}
+
+	return condition
 }
 
+// Update define os atributos do SET da UpdateExpression
 func (e *Expression) Update(keys ...domain.WithCondition) domain.SqlExpression {
-	setExpression := ""
-	attributeNames := map[string]string{}
-	attributeValues := map[string]types.AttributeValue{}
-
-	for key, expr := range keys {
-		express := fmt.Sprintf("#%s = :%s", expr.Name(), expr.Name())
-		if key != 0 {
-			setExpression += ", "
-		}
-		setExpression += express
-
-		attrKey := fmt.Sprintf("#%s", expr.Name())
-		attrValue := fmt.Sprintf(":%s", expr.Name())
-		attributeNames[attrKey] = expr.Name()
-		attributeValues[attrValue] = expr.Value()
-	}
-
-	if setExpression == "" {
+	if len(keys) == 0 {
 		panic(fmt.Errorf("update expression is empty"))
 	}
 
-	e.updateExpression = "SET " + setExpression
-	e.attributeNames = attributeNames
-	e.attributeValues = attributeValues
+	e.updates = make([]domain.WithCondition, 0, len(keys))
+	for _, expr := range keys {
+		e.updates = append(e.updates, e.attributeName(expr))
+	}
 
 	return e
 }
 
 func (e *Expression) UpdateExpression() *string {
-	return aws.String(e.updateExpression)
+	return aws.String(e.render().update)
+}
+
+// Updates retorna as condições recebidas em Update, já com o nome do
+// atributo no DynamoDB
+func (e *Expression) Updates() []domain.WithCondition {
+	return e.updates
 }
 
+// AttributeNames retorna os nomes dos placeholders #n das expressões.
+// Veja render
 func (e *Expression) AttributeNames() map[string]string {
-	return e.attributeNames
+	return e.render().placeholders.Names()
+}
+
+// render monta as expressões com placeholders novos a cada chamada, de
+// forma determinística, então os nomes e valores batem com as expressões.
+// Com Update apenas a UpdateExpression é montada, já que o UpdateItem
+// recebe a chave em Key e o DynamoDB recusa placeholders não usados.
+// O primeiro erro de conversão interrompe a montagem e fica em err
+func (e *Expression) render() rendered {
+	r := rendered{placeholders: NewPlaceholders()}
+
+	if len(e.updates) > 0 {
+		sets := make([]string, 0, len(e.updates))
+		for _, update := range e.updates {
+			value, err := update.Value()
+			if err != nil {
+				r.err = err
+				return r
+			}
+
+			sets = append(sets, fmt.Sprintf("%s = %s", r.placeholders.Name(update.Name()), r.placeholders.Value(value)))
+		}
+
+		r.update = "SET " + strings.Join(sets, ", ")
+
+		return r
+	}
+
+	if key := e.expressions["key"]; key != nil {
+		if r.keyCondition, r.err = key.KeyCondition(r.placeholders); r.err != nil {
+			return r
+		}
+
+		if sortKey := e.expressions["sortKey"]; sortKey != nil {
+			condition, err := sortKey.KeyCondition(r.placeholders)
+			if err != nil {
+				r.err = err
+				return r
+			}
+
+			if condition != "" {
+				r.keyCondition = fmt.Sprintf("%s and %s", r.keyCondition, condition)
+			}
+		}
+	}
+
+	filters := make([]string, 0, len(e.filters))
+	for _, filter := range e.filters {
+		condition, err := filter.KeyCondition(r.placeholders)
+		if err != nil {
+			r.err = err
+			return r
+		}
+
+		if condition != "" {
+			filters = append(filters, condition)
+		}
+	}
+
+	r.filter = strings.Join(filters, " AND ")
+
+	return r
 }
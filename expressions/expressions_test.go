@@ -0,0 +1,124 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type event struct {
+	Name   string `diinamo:"type:string;hash"`
+	Date   string `diinamo:"type:string;range"`
+	Status string
+	Size   int `dynamodbav:"size"`
+}
+
+func eventBuilder() domain.SqlExpression {
+	return expressions.NewSqlBuilder(&domain.Config{TableName: "events", Table: table.MustNewTable("events", event{})})
+}
+
+func TestExpression(t *testing.T) {
+	t.Run("should escape reserved words in the key condition", func(t *testing.T) {
+		sql := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Between("2022-01", "2022-06"))
+
+		assert.Equal(t, "#n0 = :v0 and #n1 BETWEEN :v1 AND :v2", *sql.KeyCondition())
+		assert.Equal(t, map[string]string{"#n0": "Name", "#n1": "Date"}, sql.AttributeNames())
+		assert.Len(t, sql.ExpressionAttributeValues(), 3)
+		assert.Nil(t, sql.FilterExpression())
+	})
+	t.Run("should share the placeholders between key condition and filters", func(t *testing.T) {
+		sql := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			Filter(expressions.NewSortKeyCondition("Status").Equal("OPEN")).
+			Filter(expressions.NewSortKeyCondition("Size").GreaterThan(10)).
+			Filter(expressions.NewSortKeyCondition("Status").LessThan("Z"))
+
+		assert.Equal(t, "#n0 = :v0", *sql.KeyCondition())
+		assert.Equal(t, "#n1 = :v1 AND #n2 > :v2 AND #n1 < :v3", *sql.FilterExpression())
+		assert.Equal(t, map[string]string{"#n0": "Name", "#n1": "Status", "#n2": "size"}, sql.AttributeNames())
+		assert.Equal(t, map[string]types.AttributeValue{
+			":v0": &types.AttributeValueMemberS{Value: "launch"},
+			":v1": &types.AttributeValueMemberS{Value: "OPEN"},
+			":v2": &types.AttributeValueMemberN{Value: "10"},
+			":v3": &types.AttributeValueMemberS{Value: "Z"},
+		}, sql.ExpressionAttributeValues())
+	})
+	t.Run("should only render the update placeholders on updates", func(t *testing.T) {
+		sql := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Equal("2022-01")).
+			Update(expressions.NewKeyCondition("Status", "DONE"), expressions.NewKeyCondition("Size", 3))
+
+		assert.Equal(t, "SET #n0 = :v0, #n1 = :v1", *sql.UpdateExpression())
+		assert.Equal(t, map[string]string{"#n0": "Status", "#n1": "size"}, sql.AttributeNames())
+		assert.Len(t, sql.ExpressionAttributeValues(), 2)
+		assert.Len(t, sql.Key(), 2)
+	})
+	t.Run("should expose the update assignments", func(t *testing.T) {
+		sql := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Equal("2022-01")).
+			Update(expressions.NewKeyCondition("Status", "a = b, c"), expressions.NewKeyCondition("Size", 3))
+
+		updates := sql.Updates()
+		if assert.Len(t, updates, 2) {
+			status, err := updates[0].Value()
+			assert.Nil(t, err)
+			assert.Equal(t, "Status", updates[0].Name())
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "a = b, c"}, status)
+
+			size, err := updates[1].Value()
+			assert.Nil(t, err)
+			assert.Equal(t, "size", updates[1].Name())
+			assert.Equal(t, &types.AttributeValueMemberN{Value: "3"}, size)
+		}
+
+		assert.Empty(t, eventBuilder().Updates())
+	})
+}
+
+func TestExpression_Err(t *testing.T) {
+	t.Run("should map a nil condition value to NULL", func(t *testing.T) {
+		value, err := expressions.NewKeyCondition("Status", nil).Value()
+
+		assert.Nil(t, err)
+		assert.Equal(t, &types.AttributeValueMemberNULL{Value: true}, value)
+	})
+	t.Run("should return the marshal errors of conditions, updates and items", func(t *testing.T) {
+		key := eventBuilder().Where(expressions.NewKeyCondition("Name", email("")))
+		assert.EqualError(t, key.Err(), "attribute Name: MarshalDiinamo: empty email")
+		assert.Empty(t, key.Key())
+
+		between := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Between("2022-01", make(chan int)))
+		assert.Error(t, between.Err())
+
+		filter := eventBuilder().Filter(expressions.NewSortKeyCondition("Status").Equal(email("")))
+		assert.EqualError(t, filter.Err(), "attribute Status: MarshalDiinamo: empty email")
+		assert.Nil(t, filter.FilterExpression())
+
+		update := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Equal("2022-01")).
+			Update(expressions.NewKeyCondition("Status", email("")))
+		assert.EqualError(t, update.Err(), "attribute Status: MarshalDiinamo: empty email")
+
+		item := eventBuilder().SetItem(order{})
+		assert.EqualError(t, item.Err(), "marshal Contact: MarshalDiinamo: empty email")
+		assert.Nil(t, item.Values())
+	})
+	t.Run("should have no error with valid values", func(t *testing.T) {
+		sql := eventBuilder().
+			Where(expressions.NewKeyCondition("Name", "launch")).
+			AndWhere(expressions.NewSortKeyCondition("Date").Between("2022-01", "2022-06"))
+
+		assert.Nil(t, sql.Err())
+	})
+}
@@ -2,8 +2,6 @@ package expressions
 
 import (
 	"fmt"
-	"log"
-	"reflect"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -51,16 +49,19 @@ func (k *KeyCondition) Name() string {
 	return *k.name
 }
 
-func (k *KeyCondition) Value() types.AttributeValue {
-	if k.Val == nil {
-		log.Fatalf("%s has a nil value", k.Name())
-	}
-
-	return GetAttributeValueMemberType(reflect.ValueOf(k.Val))
+// Value converte o valor da condição. Um valor nil vira NULL e os erros
+// do codec ou de MarshalDiinamo voltam com o nome do atributo
+func (k *KeyCondition) Value() (types.AttributeValue, error) {
+	return conditionValue(k.Name(), k.Val)
 }
 
-func (k *KeyCondition) KeyCondition() string {
-	return fmt.Sprintf("%s = :key", *k.name)
+func (k *KeyCondition) KeyCondition(placeholders domain.Placeholders) (string, error) {
+	value, err := k.Value()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s = %s", placeholders.Name(*k.name), placeholders.Value(value)), nil
 }
 
 /* SortKeyCondition */
@@ -82,12 +83,9 @@ func (k *SortKeyCondition) Name() string {
 	return *k.name
 }
 
-func (k *SortKeyCondition) Value() types.AttributeValue {
-	if k.Val == nil {
-		log.Fatalf("%s has a nil value", k.Name())
-	}
-
-	return GetAttributeValueMemberType(reflect.ValueOf(k.Val))
+// Value converte o valor da condição. Veja KeyCondition.Value
+func (k *SortKeyCondition) Value() (types.AttributeValue, error) {
+	return conditionValue(k.Name(), k.Val)
 }
 
 func (k *SortKeyCondition) HasSortKey() bool {
@@ -96,7 +94,7 @@ func (k *SortKeyCondition) HasSortKey() bool {
 
 func (k *SortKeyCondition) StarsWith(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("begins_with(%s, :sortVal)", *k.name),
+		expression: "begins_with(%s, %s)",
 		condition:  StartsWith,
 	}
 	k.Val = value
@@ -105,7 +103,7 @@ func (k *SortKeyCondition) StarsWith(value interface{}) domain.WithSortKeyCondit
 
 func (k *SortKeyCondition) Equal(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%s = :sortVal", *k.name),
+		expression: "%s = %s",
 		condition:  Equal,
 	}
 	k.Val = value
@@ -114,7 +112,7 @@ func (k *SortKeyCondition) Equal(value interface{}) domain.WithSortKeyCondition
 
 func (k *SortKeyCondition) LessThan(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%s < :sortVal", *k.name),
+		expression: "%s < %s",
 		condition:  LessThan,
 	}
 	k.Val = value
@@ -123,7 +121,7 @@ func (k *SortKeyCondition) LessThan(value interface{}) domain.WithSortKeyConditi
 
 func (k *SortKeyCondition) LessThanOrEqual(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%s <= :sortVal", *k.name),
+		expression: "%s <= %s",
 		condition:  LessThanOrEqual,
 	}
 	k.Val = value
@@ -133,7 +131,7 @@ func (k *SortKeyCondition) LessThanOrEqual(value interface{}) domain.WithSortKey
 
 func (k *SortKeyCondition) GreaterThan(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%s > :sortVal", *k.name),
+		expression: "%s > %s",
 		condition:  GreaterThan,
 	}
 	k.Val = value
@@ -143,7 +141,7 @@ func (k *SortKeyCondition) GreaterThan(value interface{}) domain.WithSortKeyCond
 
 func (k *SortKeyCondition) GreaterThanOrEqual(value interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%s >= :sortVal", *k.name),
+		expression: "%s >= %s",
 		condition:  GreaterThanOrEqual,
 	}
 	k.Val = value
@@ -153,7 +151,7 @@ func (k *SortKeyCondition) GreaterThanOrEqual(value interface{}) domain.WithSort
 
 func (k *SortKeyCondition) Between(start, end interface{}) domain.WithSortKeyCondition {
 	k.condition = condition{
-		expression: fmt.Sprintf("%sBETWEEN:startAND:end", *k.name),
+		expression: "%s BETWEEN %s AND %s",
 		condition:  Between,
 	}
 	k.betweenStart = start
@@ -162,8 +160,38 @@ func (k *SortKeyCondition) Between(start, end interface{}) domain.WithSortKeyCon
 	return k
 }
 
-func (k *SortKeyCondition) KeyCondition() string {
-	return k.condition.expression
+// KeyCondition monta a condição com o nome atual da chave, que pode ser
+// trocado pelo nome do atributo depois da condição definida
+func (k *SortKeyCondition) KeyCondition(placeholders domain.Placeholders) (string, error) {
+	if k.condition.expression == "" {
+		return "", nil
+	}
+
+	if !k.SimpleCondition() {
+		start, err := conditionValue(k.Name(), k.betweenStart)
+		if err != nil {
+			return "", err
+		}
+
+		end, err := conditionValue(k.Name(), k.betweenEnd)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf(
+			k.condition.expression,
+			placeholders.Name(*k.name),
+			placeholders.Value(start),
+			placeholders.Value(end),
+		), nil
+	}
+
+	value, err := k.Value()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(k.condition.expression, placeholders.Name(*k.name), placeholders.Value(value)), nil
 }
 
 func (k *SortKeyCondition) SimpleCondition() bool {
@@ -177,3 +205,13 @@ func (k *SortKeyCondition) StartValue() interface{} {
 func (k *SortKeyCondition) EndValue() interface{} {
 	return k.betweenEnd
 }
+
+// conditionValue converte o valor de uma condição com o codec de Marshal
+func conditionValue(name string, value interface{}) (types.AttributeValue, error) {
+	attr, err := Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("attribute %s: %w", name, err)
+	}
+
+	return attr, nil
+}
@@ -0,0 +1,69 @@
+package expressions
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+)
+
+type (
+	// Placeholders aloca os placeholders #n0, #n1... e :v0, :v1... de uma
+	// requisição. Um atributo repetido reaproveita o mesmo placeholder de
+	// nome, enquanto cada valor recebe um placeholder novo
+	Placeholders struct {
+		names      map[string]string
+		attributes map[string]string
+		values     map[string]types.AttributeValue
+	}
+)
+
+// Placeholders deve sempre satisfazer o contrato domain.Placeholders
+var _ domain.Placeholders = &Placeholders{}
+
+func NewPlaceholders() *Placeholders {
+	return &Placeholders{
+		names:      map[string]string{},
+		attributes: map[string]string{},
+		values:     map[string]types.AttributeValue{},
+	}
+}
+
+// Name retorna o placeholder do nome do atributo
+func (p *Placeholders) Name(attribute string) string {
+	if placeholder, ok := p.attributes[attribute]; ok {
+		return placeholder
+	}
+
+	placeholder := fmt.Sprintf("#n%d", len(p.names))
+	p.names[placeholder] = attribute
+	p.attributes[attribute] = placeholder
+
+	return placeholder
+}
+
+// Value retorna um novo placeholder para o valor
+func (p *Placeholders) Value(value types.AttributeValue) string {
+	placeholder := fmt.Sprintf(":v%d", len(p.values))
+	p.values[placeholder] = value
+
+	return placeholder
+}
+
+// Names retorna os ExpressionAttributeNames, nil quando não há nomes
+func (p *Placeholders) Names() map[string]string {
+	if len(p.names) == 0 {
+		return nil
+	}
+
+	return p.names
+}
+
+// Values retorna os ExpressionAttributeValues, nil quando não há valores
+func (p *Placeholders) Values() map[string]types.AttributeValue {
+	if len(p.values) == 0 {
+		return nil
+	}
+
+	return p.values
+}
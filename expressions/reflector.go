@@ -2,23 +2,378 @@ package expressions
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
 )
 
-func GetAttributeValueMemberType(val reflect.Value) types.AttributeValue {
+type (
+	// structField é um campo da estrutura com o nome do atributo e as
+	// opções de codificação
+	structField struct {
+		index     []int
+		name      string
+		omitEmpty bool
+		unixTime  bool
+	}
+)
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	attributeValueType = reflect.TypeOf((*types.AttributeValue)(nil)).Elem()
+	marshalerType      = reflect.TypeOf((*domain.Marshaler)(nil)).Elem()
+	unmarshalerType    = reflect.TypeOf((*domain.Unmarshaler)(nil)).Elem()
+
+	// fieldsCache guarda os campos já resolvidos de cada tipo de estrutura
+	fieldsCache sync.Map
+)
+
+// GetAttributeValueMemberType converte um valor Go em types.AttributeValue.
+// Veja Marshal para o mapeamento de tipos. Um valor sem representação no
+// DynamoDB, como chan ou func, ou um erro de MarshalDiinamo volta como
+// error
+func GetAttributeValueMemberType(val reflect.Value) (types.AttributeValue, error) {
+	return marshalValue(val, structField{})
+}
+
+// Marshal converte um valor Go em types.AttributeValue:
+//
+// 	int, uint, float         -> N
+// 	string                   -> S
+// 	bool                     -> BOOL
+// 	[]byte                   -> B
+// 	[]string                 -> SS
+// 	[]int, []float64, ...    -> NS
+// 	[][]byte                 -> BS
+// 	demais slices e arrays   -> L
+// 	map[string]T e structs   -> M
+// 	time.Time                -> S (RFC3339Nano), ou N com ttl / unixtime
+// 	nil e ponteiros nil      -> NULL
+//
+// Tipos que implementam domain.Marshaler definem a própria representação.
+// Nos campos de structs e maps os valores nil e os sets vazios são
+// omitidos do item, assim como os campos com dynamodbav:"-" ou
+// dynamodbav:",omitempty" vazios. Os nomes dos atributos seguem
+// tagManager.AttributeName
+func Marshal(value interface{}) (types.AttributeValue, error) {
+	return marshalValue(reflect.ValueOf(value), structField{})
+}
+
+// MarshalMap converte uma estrutura ou map[string]T no item do DynamoDB
+func MarshalMap(item interface{}) (map[string]types.AttributeValue, error) {
+	attr, err := Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	members, ok := attr.(*types.AttributeValueMemberM)
+	if !ok {
+		return nil, fmt.Errorf("marshal: %T is not a struct or map", item)
+	}
+
+	return members.Value, nil
+}
+
+func marshalValue(val reflect.Value, field structField) (types.AttributeValue, error) {
+	if !val.IsValid() {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+
+	if val.Type().Implements(attributeValueType) && !(val.Kind() == reflect.Interface && val.IsNil()) {
+		return val.Interface().(types.AttributeValue), nil
+	}
+
+	if marshaler, ok := asMarshaler(val); ok {
+		attr, err := marshaler.MarshalDiinamo()
+		if err != nil {
+			return nil, fmt.Errorf("MarshalDiinamo: %w", err)
+		}
+
+		if attr == nil {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return attr, nil
+	}
+
 	switch val.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", val.Interface())}
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return marshalValue(val.Elem(), field)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(val.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &types.AttributeValueMemberN{Value: strconv.FormatUint(val.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return marshalFloat(val)
 	case reflect.Bool:
-		return &types.AttributeValueMemberBOOL{Value: val.Bool()}
-	//case reflect.Map:
+		return &types.AttributeValueMemberBOOL{Value: val.Bool()}, nil
+	case reflect.String:
+		return &types.AttributeValueMemberS{Value: val.String()}, nil
+	case reflect.Slice:
+		if val.IsNil() {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return marshalList(val)
+	case reflect.Array:
+		return marshalList(val)
+	case reflect.Map:
+		if val.IsNil() {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return marshalMap(val)
+	case reflect.Struct:
+		if val.Type() == timeType {
+			return marshalTime(val.Interface().(time.Time), field), nil
+		}
+
+		return marshalStruct(val)
+	}
+
+	return nil, fmt.Errorf("marshal: unsupported type %s", val.Type())
+}
+
+// asMarshaler retorna o domain.Marshaler de val, inclusive quando o
+// método tem receiver de ponteiro. Ponteiros nil viram NULL sem chamar o
+// MarshalDiinamo
+func asMarshaler(val reflect.Value) (domain.Marshaler, bool) {
+	if (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil() {
+		return nil, false
+	}
+
+	if val.Type().Implements(marshalerType) {
+		return val.Interface().(domain.Marshaler), true
+	}
+
+	if val.Kind() != reflect.Ptr && reflect.PtrTo(val.Type()).Implements(marshalerType) {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+
+		return ptr.Interface().(domain.Marshaler), true
+	}
+
+	return nil, false
+}
+
+func marshalFloat(val reflect.Value) (types.AttributeValue, error) {
+	f := val.Float()
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("marshal: %v is not a valid number", f)
+	}
+
+	bitSize := 64
+	if val.Kind() == reflect.Float32 {
+		bitSize = 32
+	}
+
+	return &types.AttributeValueMemberN{Value: strconv.FormatFloat(f, 'f', -1, bitSize)}, nil
+}
+
+func marshalTime(t time.Time, field structField) types.AttributeValue {
+	if field.unixTime {
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+	}
+
+	return &types.AttributeValueMemberS{Value: t.Format(time.RFC3339Nano)}
+}
+
+// marshalList converte slices e arrays em B, SS, NS, BS ou L. Os sets
+// vazios viram NULL, já que o DynamoDB não aceita sets vazios
+func marshalList(val reflect.Value) (types.AttributeValue, error) {
+	elem := val.Type().Elem()
+
+	if elem.Kind() == reflect.Uint8 {
+		bytes := make([]byte, val.Len())
+		reflect.Copy(reflect.ValueOf(bytes), val)
+
+		return &types.AttributeValueMemberB{Value: bytes}, nil
+	}
+
+	switch {
+	case elem.Kind() == reflect.String:
+		set := make([]string, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			set = append(set, val.Index(i).String())
+		}
+
+		if len(set) == 0 {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return &types.AttributeValueMemberSS{Value: set}, nil
+	case isNumber(elem.Kind()):
+		set := make([]string, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			attr, err := marshalValue(val.Index(i), structField{})
+			if err != nil {
+				return nil, err
+			}
+
+			set = append(set, attr.(*types.AttributeValueMemberN).Value)
+		}
+
+		if len(set) == 0 {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return &types.AttributeValueMemberNS{Value: set}, nil
+	case elem.Kind() == reflect.Slice && elem.Elem().Kind() == reflect.Uint8:
+		set := make([][]byte, 0, val.Len())
+		for i := 0; i < val.Len(); i++ {
+			set = append(set, val.Index(i).Bytes())
+		}
+
+		if len(set) == 0 {
+			return &types.AttributeValueMemberNULL{Value: true}, nil
+		}
+
+		return &types.AttributeValueMemberBS{Value: set}, nil
+	}
+
+	list := make([]types.AttributeValue, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		attr, err := marshalValue(val.Index(i), structField{})
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, attr)
+	}
+
+	return &types.AttributeValueMemberL{Value: list}, nil
+}
+
+func marshalMap(val reflect.Value) (types.AttributeValue, error) {
+	if val.Type().Key().Kind() != reflect.String {
+		return nil, fmt.Errorf("marshal: map key must be a string, got %s", val.Type().Key())
+	}
+
+	members := map[string]types.AttributeValue{}
+	iter := val.MapRange()
+	for iter.Next() {
+		attr, err := marshalValue(iter.Value(), structField{})
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", iter.Key().String(), err)
+		}
+
+		if !isNull(attr) {
+			members[iter.Key().String()] = attr
+		}
+	}
+
+	return &types.AttributeValueMemberM{Value: members}, nil
+}
+
+func marshalStruct(val reflect.Value) (types.AttributeValue, error) {
+	members := map[string]types.AttributeValue{}
+
+	for _, field := range structFields(val.Type()) {
+		fieldValue := val.FieldByIndex(field.index)
+		if field.omitEmpty && isEmpty(fieldValue) {
+			continue
+		}
+
+		attr, err := marshalValue(fieldValue, field)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", field.name, err)
+		}
+
+		if !isNull(attr) {
+			members[field.name] = attr
+		}
+	}
 
-	case reflect.Slice, reflect.Array:
-		return &types.AttributeValueMemberSS{Value: val.Interface().([]string)}
-	default:
-		return &types.AttributeValueMemberS{Value: val.String()}
+	return &types.AttributeValueMemberM{Value: members}, nil
+}
+
+// structFields resolve os campos exportados de uma estrutura. Estruturas
+// embutidas sem nome de atributo têm os campos promovidos, como no
+// encoding/json
+func structFields(t reflect.Type) []structField {
+	if cached, ok := fieldsCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if tagManager.Ignored(field) {
+			continue
+		}
+
+		name := tagManager.AttributeName(field)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Type != timeType && name == field.Name &&
+			!reflect.PtrTo(field.Type).Implements(marshalerType) {
+			for _, promoted := range structFields(field.Type) {
+				promoted.index = append([]int{i}, promoted.index...)
+				fields = append(fields, promoted)
+			}
+
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		options := strings.Split(field.Tag.Get("dynamodbav"), ",")[1:]
+		fields = append(fields, structField{
+			index:     []int{i},
+			name:      name,
+			omitEmpty: hasOption(options, "omitempty"),
+			unixTime:  hasOption(options, "unixtime") || tagManager.FieldOptions(field).Has("ttl"),
+		})
+	}
+
+	fieldsCache.Store(t, fields)
+
+	return fields
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
 	}
+
+	return false
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+func isNull(attr types.AttributeValue) bool {
+	_, ok := attr.(*types.AttributeValueMemberNULL)
+	return ok
+}
+
+func isEmpty(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return val.Len() == 0
+	}
+
+	return val.IsZero()
 }
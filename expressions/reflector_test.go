@@ -0,0 +1,258 @@
+package expressions_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	// money é gravado como N em centavos
+	money struct {
+		cents int64
+	}
+
+	// email valida o valor na gravação
+	email string
+
+	order struct {
+		Total   money
+		Refund  *money
+		Contact email
+	}
+
+	address struct {
+		Street string `diinamo:"name:street"`
+		Number int    `dynamodbav:"number"`
+	}
+
+	audit struct {
+		CreatedBy string
+	}
+
+	product struct {
+		audit
+
+		PK        string `diinamo:"type:string;hash;name:pk"`
+		Price     float64
+		Stock     uint16
+		Active    bool
+		Photo     []byte
+		Tags      []string
+		Sizes     []int
+		Chunks    [][]byte
+		Reviews   []address
+		Details   map[string]string
+		Address   address
+		Discount  *float64
+		Nickname  string `dynamodbav:",omitempty"`
+		CreatedAt time.Time
+		ExpiresAt time.Time `diinamo:"ttl"`
+		Secret    string    `dynamodbav:"-"`
+		internal  string
+	}
+)
+
+func (m money) MarshalDiinamo() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(m.cents, 10)}, nil
+}
+
+func (m *money) UnmarshalDiinamo(value types.AttributeValue) error {
+	n, ok := value.(*types.AttributeValueMemberN)
+	if !ok {
+		return fmt.Errorf("money must be a number, got %T", value)
+	}
+
+	cents, err := strconv.ParseInt(n.Value, 10, 64)
+	m.cents = cents
+
+	return err
+}
+
+func (e email) MarshalDiinamo() (types.AttributeValue, error) {
+	if e == "" {
+		return nil, errors.New("empty email")
+	}
+
+	return &types.AttributeValueMemberS{Value: string(e)}, nil
+}
+
+func TestGetAttributeValueMemberType(t *testing.T) {
+	now := time.Date(2022, 3, 4, 5, 6, 7, 8, time.UTC)
+	price := 1.5
+
+	cases := []struct {
+		name  string
+		value interface{}
+		want  types.AttributeValue
+	}{
+		{"int", 10, &types.AttributeValueMemberN{Value: "10"}},
+		{"negative int64", int64(-3), &types.AttributeValueMemberN{Value: "-3"}},
+		{"float64", 19.9, &types.AttributeValueMemberN{Value: "19.9"}},
+		{"float32", float32(0.1), &types.AttributeValueMemberN{Value: "0.1"}},
+		{"pointer", &price, &types.AttributeValueMemberN{Value: "1.5"}},
+		{"nil pointer", (*float64)(nil), &types.AttributeValueMemberNULL{Value: true}},
+		{"string", "value", &types.AttributeValueMemberS{Value: "value"}},
+		{"bool", true, &types.AttributeValueMemberBOOL{Value: true}},
+		{"bytes", []byte("raw"), &types.AttributeValueMemberB{Value: []byte("raw")}},
+		{"string set", []string{"a", "b"}, &types.AttributeValueMemberSS{Value: []string{"a", "b"}}},
+		{"number set", []int{1, 2}, &types.AttributeValueMemberNS{Value: []string{"1", "2"}}},
+		{"binary set", [][]byte{[]byte("a")}, &types.AttributeValueMemberBS{Value: [][]byte{[]byte("a")}}},
+		{"empty set", []string{}, &types.AttributeValueMemberNULL{Value: true}},
+		{"list", []interface{}{"a", 1}, &types.AttributeValueMemberL{Value: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "a"},
+			&types.AttributeValueMemberN{Value: "1"},
+		}}},
+		{"map", map[string]int{"a": 1}, &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"a": &types.AttributeValueMemberN{Value: "1"},
+		}}},
+		{"struct", address{Street: "Main", Number: 10}, &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"street": &types.AttributeValueMemberS{Value: "Main"},
+			"number": &types.AttributeValueMemberN{Value: "10"},
+		}}},
+		{"time", now, &types.AttributeValueMemberS{Value: "2022-03-04T05:06:07.000000008Z"}},
+		{"attribute value", &types.AttributeValueMemberS{Value: "raw"}, &types.AttributeValueMemberS{Value: "raw"}},
+	}
+
+	for _, c := range cases {
+		t.Run("should marshal "+c.name, func(t *testing.T) {
+			attr, err := expressions.GetAttributeValueMemberType(reflect.ValueOf(c.value))
+			assert.Nil(t, err)
+			assert.Equal(t, c.want, attr)
+		})
+	}
+
+	t.Run("should fail on unsupported types", func(t *testing.T) {
+		_, err := expressions.GetAttributeValueMemberType(reflect.ValueOf(make(chan int)))
+		assert.Error(t, err)
+	})
+}
+
+func TestMarshalMap(t *testing.T) {
+	now := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	discount := 0.25
+
+	item := product{
+		audit:     audit{CreatedBy: "jane"},
+		PK:        "PRODUCT#1",
+		Price:     10.5,
+		Stock:     3,
+		Active:    true,
+		Photo:     []byte{1, 2},
+		Tags:      []string{"new"},
+		Sizes:     []int{38, 40},
+		Chunks:    [][]byte{{1}},
+		Reviews:   []address{{Street: "Main", Number: 1}},
+		Details:   map[string]string{"color": "red"},
+		Address:   address{Street: "Second", Number: 2},
+		Discount:  &discount,
+		CreatedAt: now,
+		ExpiresAt: now,
+		Secret:    "secret",
+		internal:  "internal",
+	}
+
+	t.Run("should marshal every field with the attribute names", func(t *testing.T) {
+		attributes, err := expressions.MarshalMap(item)
+		assert.Nil(t, err)
+
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "jane"}, attributes["CreatedBy"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "PRODUCT#1"}, attributes["pk"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "10.5"}, attributes["Price"])
+		assert.Equal(t, &types.AttributeValueMemberNS{Value: []string{"38", "40"}}, attributes["Sizes"])
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2022-03-04T05:06:07Z"}, attributes["CreatedAt"])
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1646370367"}, attributes["ExpiresAt"])
+		assert.NotContains(t, attributes, "Nickname")
+		assert.NotContains(t, attributes, "Secret")
+		assert.NotContains(t, attributes, "internal")
+	})
+	t.Run("should omit nil fields", func(t *testing.T) {
+		attributes, err := expressions.MarshalMap(product{PK: "PRODUCT#2"})
+		assert.Nil(t, err)
+
+		assert.NotContains(t, attributes, "Discount")
+		assert.NotContains(t, attributes, "Tags")
+		assert.NotContains(t, attributes, "Details")
+	})
+	t.Run("should unmarshal what was marshaled", func(t *testing.T) {
+		attributes, err := expressions.MarshalMap(item)
+		assert.Nil(t, err)
+
+		var decoded product
+		assert.Nil(t, expressions.UnmarshalMap(attributes, &decoded))
+
+		want := item
+		want.Secret = ""
+		want.internal = ""
+		assert.Equal(t, want, decoded)
+	})
+	t.Run("should unmarshal a list of items", func(t *testing.T) {
+		attributes, err := expressions.MarshalMap(address{Street: "Main", Number: 1})
+		assert.Nil(t, err)
+
+		var decoded []*address
+		assert.Nil(t, expressions.UnmarshalListOfMaps([]map[string]types.AttributeValue{attributes}, &decoded))
+		assert.Equal(t, []*address{{Street: "Main", Number: 1}}, decoded)
+	})
+	t.Run("should unmarshal into interface values", func(t *testing.T) {
+		var decoded interface{}
+		err := expressions.Unmarshal(&types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+			"n": &types.AttributeValueMemberN{Value: "1.5"},
+			"l": &types.AttributeValueMemberL{Value: []types.AttributeValue{&types.AttributeValueMemberNULL{Value: true}}},
+		}}, &decoded)
+
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{"n": 1.5, "l": []interface{}{nil}}, decoded)
+	})
+	t.Run("should fail on mismatched types", func(t *testing.T) {
+		var decoded product
+		err := expressions.UnmarshalMap(map[string]types.AttributeValue{
+			"Price": &types.AttributeValueMemberS{Value: "ten"},
+		}, &decoded)
+
+		assert.EqualError(t, err, "unmarshal Price: unmarshal: cannot decode S into float64")
+	})
+}
+
+func TestMarshaler(t *testing.T) {
+	t.Run("should use MarshalDiinamo for values and fields", func(t *testing.T) {
+		attr, err := expressions.GetAttributeValueMemberType(reflect.ValueOf(money{cents: 1050}))
+		assert.Nil(t, err)
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1050"}, attr)
+
+		attributes, err := expressions.MarshalMap(order{Total: money{cents: 990}, Contact: "jane@example.com"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]types.AttributeValue{
+			"Total":   &types.AttributeValueMemberN{Value: "990"},
+			"Contact": &types.AttributeValueMemberS{Value: "jane@example.com"},
+		}, attributes)
+	})
+	t.Run("should use UnmarshalDiinamo on read", func(t *testing.T) {
+		var decoded order
+		err := expressions.UnmarshalMap(map[string]types.AttributeValue{
+			"Total":   &types.AttributeValueMemberN{Value: "990"},
+			"Refund":  &types.AttributeValueMemberN{Value: "100"},
+			"Contact": &types.AttributeValueMemberS{Value: "jane@example.com"},
+		}, &decoded)
+
+		assert.Nil(t, err)
+		assert.Equal(t, order{Total: money{cents: 990}, Refund: &money{cents: 100}, Contact: "jane@example.com"}, decoded)
+	})
+	t.Run("should return the marshaler errors", func(t *testing.T) {
+		_, err := expressions.MarshalMap(order{})
+		assert.EqualError(t, err, "marshal Contact: MarshalDiinamo: empty email")
+
+		var decoded order
+		err = expressions.UnmarshalMap(map[string]types.AttributeValue{
+			"Total": &types.AttributeValueMemberS{Value: "ten"},
+		}, &decoded)
+		assert.EqualError(t, err, "unmarshal Total: UnmarshalDiinamo: money must be a number, got *types.AttributeValueMemberS")
+	})
+}
@@ -0,0 +1,338 @@
+package expressions
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+)
+
+// Unmarshal decodifica um types.AttributeValue em target, que deve ser
+// um ponteiro. É o inverso de Marshal: N decodifica em qualquer número,
+// sets e L em slices, M em maps e structs e NULL no valor zero. Em um
+// interface{} os números viram float64 e os maps map[string]interface{}.
+// Tipos que implementam domain.Unmarshaler decodificam o próprio valor
+func Unmarshal(attr types.AttributeValue, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("unmarshal: target must be a non nil pointer, got %T", target)
+	}
+
+	return unmarshalValue(attr, value.Elem())
+}
+
+// UnmarshalMap decodifica um item em target respeitando os nomes de
+// atributo da tag diinamo, os mesmos usados por Values na escrita
+func UnmarshalMap(item map[string]types.AttributeValue, target interface{}) error {
+	return Unmarshal(&types.AttributeValueMemberM{Value: item}, target)
+}
+
+// UnmarshalListOfMaps decodifica uma lista de itens em um ponteiro para
+// slice respeitando os nomes de atributo da tag diinamo
+func UnmarshalListOfMaps(items []map[string]types.AttributeValue, target interface{}) error {
+	list := make([]types.AttributeValue, 0, len(items))
+	for _, item := range items {
+		list = append(list, &types.AttributeValueMemberM{Value: item})
+	}
+
+	return Unmarshal(&types.AttributeValueMemberL{Value: list}, target)
+}
+
+func unmarshalValue(attr types.AttributeValue, val reflect.Value) error {
+	if _, ok := attr.(*types.AttributeValueMemberNULL); ok || attr == nil {
+		val.Set(reflect.Zero(val.Type()))
+		return nil
+	}
+
+	if val.Type() == attributeValueType {
+		val.Set(reflect.ValueOf(attr))
+		return nil
+	}
+
+	if val.Kind() != reflect.Ptr && val.CanAddr() && val.Addr().Type().Implements(unmarshalerType) {
+		if err := val.Addr().Interface().(domain.Unmarshaler).UnmarshalDiinamo(attr); err != nil {
+			return fmt.Errorf("UnmarshalDiinamo: %w", err)
+		}
+
+		return nil
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			val.Set(reflect.New(val.Type().Elem()))
+		}
+
+		return unmarshalValue(attr, val.Elem())
+	case reflect.Interface:
+		if val.NumMethod() > 0 {
+			break
+		}
+
+		natural, err := naturalValue(attr)
+		if err != nil {
+			return err
+		}
+
+		if natural == nil {
+			val.Set(reflect.Zero(val.Type()))
+		} else {
+			val.Set(reflect.ValueOf(natural))
+		}
+
+		return nil
+	case reflect.Struct:
+		if val.Type() == timeType {
+			return unmarshalTime(attr, val)
+		}
+
+		if members, ok := attr.(*types.AttributeValueMemberM); ok {
+			return unmarshalStruct(members.Value, val)
+		}
+	case reflect.Map:
+		if members, ok := attr.(*types.AttributeValueMemberM); ok {
+			return unmarshalMap(members.Value, val)
+		}
+	case reflect.Slice, reflect.Array:
+		return unmarshalList(attr, val)
+	case reflect.String:
+		if s, ok := attr.(*types.AttributeValueMemberS); ok {
+			val.SetString(s.Value)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := attr.(*types.AttributeValueMemberBOOL); ok {
+			val.SetBool(b.Value)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if n, ok := attr.(*types.AttributeValueMemberN); ok {
+			return unmarshalNumber(n.Value, val)
+		}
+	}
+
+	return fmt.Errorf("unmarshal: cannot decode %s into %s", attributeType(attr), val.Type())
+}
+
+func unmarshalNumber(number string, val reflect.Value) error {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(number, 10, val.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+
+		val.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(number, 10, val.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+
+		val.SetUint(n)
+	default:
+		n, err := strconv.ParseFloat(number, val.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+
+		val.SetFloat(n)
+	}
+
+	return nil
+}
+
+// unmarshalTime aceita o S em RFC3339 gravado por Marshal e o N em
+// segundos Unix usado nos atributos de TTL
+func unmarshalTime(attr types.AttributeValue, val reflect.Value) error {
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberS:
+		t, err := time.Parse(time.RFC3339Nano, v.Value)
+		if err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+
+		val.Set(reflect.ValueOf(t))
+
+		return nil
+	case *types.AttributeValueMemberN:
+		seconds, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("unmarshal: %v", err)
+		}
+
+		val.Set(reflect.ValueOf(time.Unix(seconds, 0).UTC()))
+
+		return nil
+	}
+
+	return fmt.Errorf("unmarshal: cannot decode %s into %s", attributeType(attr), val.Type())
+}
+
+func unmarshalStruct(members map[string]types.AttributeValue, val reflect.Value) error {
+	for _, field := range structFields(val.Type()) {
+		attr, ok := members[field.name]
+		if !ok {
+			continue
+		}
+
+		if err := unmarshalValue(attr, val.FieldByIndex(field.index)); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", field.name, err)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalMap(members map[string]types.AttributeValue, val reflect.Value) error {
+	if val.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unmarshal: map key must be a string, got %s", val.Type().Key())
+	}
+
+	if val.IsNil() {
+		val.Set(reflect.MakeMapWithSize(val.Type(), len(members)))
+	}
+
+	for name, attr := range members {
+		item := reflect.New(val.Type().Elem()).Elem()
+		if err := unmarshalValue(attr, item); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", name, err)
+		}
+
+		val.SetMapIndex(reflect.ValueOf(name).Convert(val.Type().Key()), item)
+	}
+
+	return nil
+}
+
+// unmarshalList decodifica B, SS, NS, BS e L em slices e arrays
+func unmarshalList(attr types.AttributeValue, val reflect.Value) error {
+	var list []types.AttributeValue
+
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberB:
+		if val.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unmarshal: cannot decode B into %s", val.Type())
+		}
+
+		if val.Kind() == reflect.Slice {
+			val.SetBytes(append([]byte{}, v.Value...))
+		} else {
+			reflect.Copy(val, reflect.ValueOf(v.Value))
+		}
+
+		return nil
+	case *types.AttributeValueMemberL:
+		list = v.Value
+	case *types.AttributeValueMemberSS:
+		for _, s := range v.Value {
+			list = append(list, &types.AttributeValueMemberS{Value: s})
+		}
+	case *types.AttributeValueMemberNS:
+		for _, n := range v.Value {
+			list = append(list, &types.AttributeValueMemberN{Value: n})
+		}
+	case *types.AttributeValueMemberBS:
+		for _, b := range v.Value {
+			list = append(list, &types.AttributeValueMemberB{Value: b})
+		}
+	default:
+		return fmt.Errorf("unmarshal: cannot decode %s into %s", attributeType(attr), val.Type())
+	}
+
+	if val.Kind() == reflect.Slice {
+		val.Set(reflect.MakeSlice(val.Type(), len(list), len(list)))
+	} else if val.Len() < len(list) {
+		return fmt.Errorf("unmarshal: %d items do not fit in %s", len(list), val.Type())
+	}
+
+	for i, item := range list {
+		if err := unmarshalValue(item, val.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// naturalValue converte um types.AttributeValue no tipo Go natural, usado
+// quando o destino é um interface{}
+func naturalValue(attr types.AttributeValue) (interface{}, error) {
+	switch v := attr.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value, nil
+	case *types.AttributeValueMemberN:
+		n, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal: %v", err)
+		}
+
+		return n, nil
+	case *types.AttributeValueMemberB:
+		return v.Value, nil
+	case *types.AttributeValueMemberBOOL:
+		return v.Value, nil
+	case *types.AttributeValueMemberNULL:
+		return nil, nil
+	case *types.AttributeValueMemberSS:
+		return v.Value, nil
+	case *types.AttributeValueMemberBS:
+		return v.Value, nil
+	case *types.AttributeValueMemberNS:
+		var numbers []float64
+		if err := Unmarshal(v, &numbers); err != nil {
+			return nil, err
+		}
+
+		return numbers, nil
+	case *types.AttributeValueMemberL:
+		var list []interface{}
+		if err := Unmarshal(v, &list); err != nil {
+			return nil, err
+		}
+
+		return list, nil
+	case *types.AttributeValueMemberM:
+		var members map[string]interface{}
+		if err := Unmarshal(v, &members); err != nil {
+			return nil, err
+		}
+
+		return members, nil
+	}
+
+	return nil, fmt.Errorf("unmarshal: unsupported attribute value %T", attr)
+}
+
+// attributeType retorna o descritor do DynamoDB JSON de um valor, ex: S
+func attributeType(attr types.AttributeValue) string {
+	switch attr.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+
+	return fmt.Sprintf("%T", attr)
+}
@@ -0,0 +1,25 @@
+/*
+Package inmemory
+
+pacote criado para testes sem Docker.
+
+Dynamo implementa domain.Dynamo e domain.DynamoSQL em memória, respeitando
+as chaves e os índices da tabela, para testes unitários.
+
+Server é um servidor HTTP em processo compatível com o protocolo JSON do
+DynamoDB, para testes de integração com o *dynamodb.Client real:
+
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	client := drivers.NewDynamoClient(ctx, &domain.Config{
+		TableName: "users",
+		Client:    server.NewClient(),
+		Table:     table.MustNewTable("users", User{}),
+	})
+
+Os dois executam o subconjunto de PartiQL usado por drivers.SQLClient:
+SELECT, INSERT, UPDATE e DELETE com parâmetros posicionais, em instruções
+avulsas, em lote e em transações. Caminhos aninhados não são suportados
+*/
+package inmemory
@@ -0,0 +1,481 @@
+package inmemory
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+)
+
+type (
+	// Dynamo é um fake em memória de domain.Dynamo e domain.DynamoSQL.
+	//
+	// Os itens são guardados como types.AttributeValue e as operações
+	// respeitam as chaves da tabela e dos índices de domain.Table, então
+	// os testes exercitam a mesma semântica de key condition do DynamoDB
+	// sem precisar do DynamoDB Local. É seguro para uso concorrente
+	Dynamo struct {
+		TableName   string
+		Environment domain.Environment
+
+		domain.Table
+		logger.Log
+
+		store *store
+	}
+)
+
+// Dynamo deve sempre satisfazer os contratos domain.Dynamo e domain.DynamoSQL
+var (
+	_ domain.Dynamo    = &Dynamo{}
+	_ domain.DynamoSQL = &Dynamo{}
+)
+
+// NewDynamo inicializa um Dynamo vazio com o schema da tabela
+func NewDynamo(tb domain.Table) *Dynamo {
+	return &Dynamo{
+		TableName:   tableName(tb),
+		Environment: domain.Environment("testing"),
+		Table:       tb,
+		Log:         logger.NewLogger(),
+		store:       storeFor(tb),
+	}
+}
+
+// tableName retorna o nome da tabela quando a Table é uma table.Table
+func tableName(tb domain.Table) string {
+	switch t := tb.(type) {
+	case *table.Table:
+		if t != nil {
+			return t.TableName
+		}
+	}
+
+	return ""
+}
+
+func (d *Dynamo) Perform(action domain.Action, sql domain.SqlExpression, target interface{}) error {
+	if reflect.TypeOf(target).Kind() != reflect.Ptr {
+		return errors.New("target must be a pointer")
+	}
+
+	if err := drivers.RunBeforeHooks(action, sql, target); err != nil {
+		return err
+	}
+
+	if err := drivers.RunValidation(action, sql, d.Table); err != nil {
+		return err
+	}
+
+	if err := d.perform(action, sql, target); err != nil {
+		return err
+	}
+
+	return drivers.RunAfterHooks(action, target)
+}
+
+// perform executa a action sem os hooks da entidade
+func (d *Dynamo) perform(action domain.Action, sql domain.SqlExpression, target interface{}) error {
+	switch action {
+	case drivers.GET:
+		return d.Get(sql, target)
+	case drivers.PUT:
+		return d.Put(sql, target)
+	case drivers.QUERY:
+		return d.Query(sql, target)
+	case drivers.UPDATE:
+		return d.Update(sql, nil, target)
+	case drivers.DELETE:
+		return d.Delete(sql)
+	case drivers.SCAN:
+		return d.Scan(sql, target)
+	}
+
+	return nil
+}
+
+func (d *Dynamo) NewExpressionBuilder() domain.SqlExpression {
+	return expressions.NewSqlBuilder(&domain.Config{
+		TableName: d.TableName,
+		Table:     d.Table,
+		Log:       d.Log,
+	})
+}
+
+// Prepare monta a expressão de uma condição de chave em texto, com os
+// valores posicionais em args. Veja drivers.DynamoClient.Prepare
+func (d *Dynamo) Prepare(query string, args ...interface{}) (domain.SqlExpression, error) {
+	return expressions.Compile(&domain.Config{
+		TableName: d.TableName,
+		Table:     d.Table,
+		Log:       d.Log,
+	}, query, args...)
+}
+
+// Migrate não faz nada: a tabela em memória já nasce com o schema
+func (d *Dynamo) Migrate() error {
+	return nil
+}
+
+// Seed grava os itens aceitos por drivers.DynamoClient.Seed: structs com
+// as tags diinamo, map[string]types.AttributeValue, *dynamodb.PutItemInput,
+// domain.FixtureFile e domain.FixtureDir
+func (d *Dynamo) Seed(items ...interface{}) error {
+	for _, item := range items {
+		parsed, err := d.seedItems(item)
+		if err != nil {
+			return err
+		}
+
+		for _, attributes := range parsed {
+			if _, err = d.store.put(attributes, "", evaluator{}); err != nil {
+				return fmt.Errorf("seed: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedItems converte uma das formas de entrada de Seed em itens
+func (d *Dynamo) seedItems(item interface{}) ([]map[string]types.AttributeValue, error) {
+	switch v := item.(type) {
+	case map[string]types.AttributeValue:
+		return []map[string]types.AttributeValue{v}, nil
+	case *dynamodb.PutItemInput:
+		return []map[string]types.AttributeValue{v.Item}, nil
+	case domain.FixtureFile:
+		return drivers.LoadFixtureFile(string(v))
+	case domain.FixtureDir:
+		return drivers.LoadFixtureDir(string(v), d.Environment)
+	}
+
+	attributes, err := d.values(item)
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+
+	return []map[string]types.AttributeValue{attributes}, nil
+}
+
+// values converte uma struct, ponteiro de struct ou SqlExpression com
+// SetItem nos atributos do item
+func (d *Dynamo) values(item interface{}) (map[string]types.AttributeValue, error) {
+	if sql, ok := item.(domain.SqlExpression); ok {
+		return drivers.ItemValues(sql)
+	}
+
+	value := reflect.ValueOf(item)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unsupported item type %T", item)
+	}
+
+	return expressions.MarshalMap(value.Interface())
+}
+
+// Get busca um item pela chave. Quando o item não existe o target não é
+// alterado, assim como no drivers.DynamoClient
+func (d *Dynamo) Get(expression domain.SqlExpression, target interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+
+	item, err := d.store.get(expression.Key())
+	if err != nil {
+		return fmt.Errorf("get item: %w", err)
+	}
+
+	return unmarshalMap(item, target)
+}
+
+// Put grava um item. item pode ser uma struct com as tags diinamo ou uma
+// SqlExpression com SetItem
+func (d *Dynamo) Put(item interface{}, result interface{}) error {
+	attributes, err := d.values(item)
+	if err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	if _, err = d.store.put(attributes, "", evaluator{}); err != nil {
+		return fmt.Errorf("put item: %w", err)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return unmarshalMap(attributes, result)
+}
+
+// Query busca os itens da partição que satisfazem a key condition da
+// expressão, na tabela ou no índice de SetIndex. Sem SetIndex, o índice
+// é inferido pelas chaves de Where e AndWhere
+func (d *Dynamo) Query(expression domain.SqlExpression, target interface{}) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	if err := expression.ResolveIndex(); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	input := queryInput{
+		keyCondition:     *expression.KeyCondition(),
+		names:            expression.AttributeNames(),
+		values:           expression.ExpressionAttributeValues(),
+		scanIndexForward: true,
+	}
+
+	if filter := expression.FilterExpression(); filter != nil {
+		input.filter = *filter
+	}
+
+	if indexName := expression.IndexName(); indexName != nil {
+		input.indexName = *indexName
+	}
+
+	items, _, err := d.store.query(input)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+
+	return unmarshalListOfMaps(items, target)
+}
+
+// Scan lê todos os itens da tabela ou do índice de SetIndex que
+// satisfazem o Filter da expressão
+func (d *Dynamo) Scan(expression domain.SqlExpression, target interface{}) error {
+	input := scanInput{}
+
+	if expression != nil {
+		if err := expression.Err(); err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+
+		if indexName := expression.IndexName(); indexName != nil {
+			input.indexName = *indexName
+		}
+
+		if filter := expression.FilterExpression(); filter != nil {
+			input.filter = *filter
+			input.names = expression.AttributeNames()
+			input.values = expression.ExpressionAttributeValues()
+		}
+	}
+
+	items, _, err := d.store.scan(input)
+	if err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+
+	return unmarshalListOfMaps(items, target)
+}
+
+// Update atualiza o item da chave da expressão, criando-o caso não
+// exista. Os atributos de item, quando informado, são gravados antes da
+// UpdateExpression da expressão. result recebe o item atualizado
+func (d *Dynamo) Update(expression interface{}, item interface{}, result interface{}) error {
+	sql, ok := expression.(domain.SqlExpression)
+	if !ok {
+		return fmt.Errorf("update item: expression should be a domain.SqlExpression, got %T", expression)
+	}
+
+	if err := sql.Err(); err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
+	var merge map[string]types.AttributeValue
+	if item != nil {
+		var err error
+		if merge, err = d.values(item); err != nil {
+			return fmt.Errorf("update item: %w", err)
+		}
+	}
+
+	eval := evaluator{names: sql.AttributeNames()}
+
+	updateExpression := ""
+	if sql.UpdateExpression() != nil && *sql.UpdateExpression() != "" {
+		updateExpression = *sql.UpdateExpression()
+		eval.values = sql.ExpressionAttributeValues()
+	}
+
+	_, updated, err := d.store.update(sql.Key(), merge, updateExpression, "", eval)
+	if err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return unmarshalMap(updated, result)
+}
+
+// Delete remove o item da chave da expressão
+func (d *Dynamo) Delete(expression domain.SqlExpression) error {
+	if err := expression.Err(); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+
+	if _, err := d.store.delete(expression.Key(), "", evaluator{}); err != nil {
+		return fmt.Errorf("delete item: %w", err)
+	}
+
+	return nil
+}
+
+// Execute executa uma instrução PartiQL na tabela do fake. Veja
+// drivers.SQLClient.Execute para a paginação com NextToken
+func (d *Dynamo) Execute(statement domain.Statement, target interface{}) (*string, error) {
+	params, err := drivers.StatementParams(statement.Params)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+
+	nextToken := ""
+	if statement.NextToken != nil {
+		nextToken = *statement.NextToken
+	}
+
+	items, nextToken, err := executeStatement(statement.Query, params, int(statement.Limit), nextToken, d.resolve)
+	if err != nil {
+		return nil, fmt.Errorf("execute statement: %w", err)
+	}
+
+	if target != nil {
+		if err = unmarshalListOfMaps(items, target); err != nil {
+			return nil, err
+		}
+	}
+
+	if nextToken == "" {
+		return nil, nil
+	}
+
+	return &nextToken, nil
+}
+
+// BatchExecute executa as instruções de forma independente. As que
+// falharam voltam em um *drivers.BatchError
+func (d *Dynamo) BatchExecute(statements []domain.Statement, target interface{}) error {
+	queries, params, err := statementInputs(statements)
+	if err != nil {
+		return fmt.Errorf("batch execute statement: %w", err)
+	}
+
+	results, err := executeBatch(queries, params, d.resolve)
+	if err != nil {
+		return fmt.Errorf("batch execute statement: %w", err)
+	}
+
+	items := make([]map[string]types.AttributeValue, 0, len(results))
+	batchErr := &drivers.BatchError{}
+
+	for i, result := range results {
+		items = append(items, result.item)
+
+		if result.err != nil {
+			batchErr.Errors = append(batchErr.Errors, drivers.StatementError{
+				Index:   i,
+				Code:    batchErrorCode(result.err),
+				Message: result.err.Error(),
+			})
+		}
+	}
+
+	if err = drivers.UnmarshalStatementItems(items, target); err != nil {
+		return err
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
+// ExecuteTransaction executa todas as instruções ou nenhuma
+func (d *Dynamo) ExecuteTransaction(statements []domain.Statement, target interface{}) error {
+	queries, params, err := statementInputs(statements)
+	if err != nil {
+		return fmt.Errorf("execute transaction: %w", err)
+	}
+
+	items, err := executeTransaction(queries, params, d.resolve)
+	if err != nil {
+		return fmt.Errorf("execute transaction: %w", err)
+	}
+
+	return drivers.UnmarshalStatementItems(items, target)
+}
+
+// resolve retorna o store para as instruções na tabela do fake
+func (d *Dynamo) resolve(table string) (*store, error) {
+	if d.TableName != "" && table != d.TableName {
+		return nil, tableNotFound(table)
+	}
+
+	return d.store, nil
+}
+
+// statementInputs separa as instruções e os parâmetros convertidos
+func statementInputs(statements []domain.Statement) ([]string, [][]types.AttributeValue, error) {
+	queries := make([]string, 0, len(statements))
+	params := make([][]types.AttributeValue, 0, len(statements))
+
+	for i, statement := range statements {
+		values, err := drivers.StatementParams(statement.Params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+
+		queries = append(queries, statement.Query)
+		params = append(params, values)
+	}
+
+	return queries, params, nil
+}
+
+// Items retorna uma cópia de todos os itens guardados, em uma ordem
+// estável. Útil para asserções nos testes
+func (d *Dynamo) Items() []map[string]types.AttributeValue {
+	items, _, _ := d.store.scan(scanInput{})
+	return items
+}
+
+// Reset remove todos os itens guardados
+func (d *Dynamo) Reset() {
+	d.store.clear()
+}
+
+func unmarshalMap(item map[string]types.AttributeValue, target interface{}) error {
+	if err := expressions.UnmarshalMap(item, target); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
+
+func unmarshalListOfMaps(items []map[string]types.AttributeValue, target interface{}) error {
+	if items == nil {
+		items = []map[string]types.AttributeValue{}
+	}
+
+	if err := expressions.UnmarshalListOfMaps(items, target); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
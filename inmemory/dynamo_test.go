@@ -0,0 +1,220 @@
+package inmemory_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type lesson struct {
+	PK       string `diinamo:"type:string;hash"`
+	SK       string `diinamo:"type:string;range"`
+	Owner    string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK"`
+	Position int64  `diinamo:"type:number;lsi:PositionIndex;keyPairs:PK=Position"`
+	Title    string
+}
+
+func newDynamo(t *testing.T) *inmemory.Dynamo {
+	d := inmemory.NewDynamo(table.MustNewTable("lessons", lesson{}))
+
+	err := d.Seed(
+		lesson{PK: "COURSE#1", SK: "LESSON#01", Owner: "jane", Position: 3, Title: "Intro"},
+		lesson{PK: "COURSE#1", SK: "LESSON#02", Owner: "john", Position: 1, Title: "Setup"},
+		lesson{PK: "COURSE#1", SK: "LESSON#03", Owner: "jane", Position: 2, Title: "Deploy"},
+		lesson{PK: "COURSE#1", SK: "QUIZ#01", Owner: "jane", Position: 4, Title: "Quiz"},
+		lesson{PK: "COURSE#2", SK: "LESSON#01", Owner: "jane", Position: 1, Title: "Other"},
+	)
+	assert.Nil(t, err)
+
+	return d
+}
+
+func TestDynamo_Query(t *testing.T) {
+	d := newDynamo(t)
+
+	t.Run("should query by begins_with on the range key", func(t *testing.T) {
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").StarsWith("LESSON#"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 3)
+		assert.Equal(t, "LESSON#01", result[0].SK)
+		assert.Equal(t, "LESSON#03", result[2].SK)
+	})
+	t.Run("should query with comparisons and between", func(t *testing.T) {
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").GreaterThan("LESSON#01"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 3)
+
+		sql = d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Between("LESSON#02", "LESSON#03"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 2)
+		assert.Equal(t, "Setup", result[0].Title)
+	})
+	t.Run("should query the global secondary index", func(t *testing.T) {
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			SetIndex("OwnerIndex").
+			Where(expressions.NewKeyCondition("Owner", "jane")).
+			AndWhere(expressions.NewSortKeyCondition("SK").LessThanOrEqual("LESSON#01"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 2)
+	})
+	t.Run("should sort the local secondary index by its range key", func(t *testing.T) {
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			SetIndex("PositionIndex").
+			Where(expressions.NewKeyCondition("PK", "COURSE#1"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Equal(t, []string{"Setup", "Deploy", "Intro", "Quiz"}, titles(result))
+	})
+	t.Run("should refuse key conditions outside the index key schema", func(t *testing.T) {
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			SetIndex("OwnerIndex").
+			Where(expressions.NewKeyCondition("PK", "COURSE#1"))
+
+		err := d.Perform(drivers.QUERY, sql, &result)
+		assert.True(t, errors.Is(err, inmemory.ErrValidation))
+		assert.Contains(t, err.Error(), "missed key schema element")
+	})
+}
+
+func TestDynamo_Crud(t *testing.T) {
+	d := newDynamo(t)
+
+	t.Run("should get, update and delete an item", func(t *testing.T) {
+		var found lesson
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("LESSON#02"))
+
+		assert.Nil(t, d.Perform(drivers.GET, sql, &found))
+		assert.Equal(t, "Setup", found.Title)
+
+		var updated lesson
+		sql.Update(expressions.NewKeyCondition("Title", "Installation"))
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, "Installation", updated.Title)
+		assert.Equal(t, "john", updated.Owner)
+
+		assert.Nil(t, d.Delete(sql))
+		assert.Len(t, d.Items(), 4)
+	})
+	t.Run("should put items through DynamoSQL", func(t *testing.T) {
+		var result lesson
+		item := lesson{PK: "COURSE#3", SK: "LESSON#01", Title: "New"}
+
+		assert.Nil(t, d.Put(item, &result))
+		assert.Equal(t, item, result)
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "New"}, d.Items()[4]["Title"])
+	})
+	t.Run("should refuse items without the key attributes", func(t *testing.T) {
+		err := d.Seed(map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "COURSE#4"},
+		})
+
+		assert.True(t, errors.Is(err, inmemory.ErrValidation))
+		assert.Contains(t, err.Error(), "missing key attribute SK")
+	})
+}
+
+func TestDynamo_Execute(t *testing.T) {
+	d := newDynamo(t)
+
+	t.Run("should translate the PartiQL conditions", func(t *testing.T) {
+		var result []lesson
+		_, err := d.Execute(domain.NewStatement(
+			`SELECT * FROM lessons WHERE "PK" = 'COURSE#1' AND Owner IN [?, 'nobody'] AND Position != 3 AND NOT begins_with(SK, ?)`,
+			"jane", "QUIZ",
+		), &result)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Deploy"}, titles(result))
+	})
+	t.Run("should scan without an equality on the hash key", func(t *testing.T) {
+		var result []lesson
+		_, err := d.Execute(domain.NewStatement(`SELECT * FROM "lessons"."PositionIndex" WHERE Position BETWEEN 2 AND 3 AND Notes IS MISSING`), &result)
+
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"Intro", "Deploy"}, titles(result))
+	})
+	t.Run("should return the modified attributes", func(t *testing.T) {
+		var result []map[string]interface{}
+		_, err := d.Execute(domain.NewStatement(
+			`UPDATE lessons SET Title = ? REMOVE Owner WHERE PK = ? AND SK = ? RETURNING MODIFIED OLD *`,
+			"Welcome", "COURSE#1", "LESSON#01",
+		), &result)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []map[string]interface{}{{"Title": "Intro", "Owner": "jane"}}, result)
+	})
+	t.Run("should check the extra conditions of a delete", func(t *testing.T) {
+		_, err := d.Execute(domain.NewStatement(`DELETE FROM lessons WHERE PK = ? AND SK = ? AND Title = ?`, "COURSE#1", "LESSON#02", "Other"), nil)
+		assert.True(t, errors.Is(err, inmemory.ErrConditionalCheckFailed))
+
+		_, err = d.Execute(domain.NewStatement(`DELETE FROM lessons WHERE PK = ? AND SK = ?`, "COURSE#9", "LESSON#01"), nil)
+		assert.Nil(t, err)
+		assert.Len(t, d.Items(), 5)
+	})
+	t.Run("should require the key on writes", func(t *testing.T) {
+		_, err := d.Execute(domain.NewStatement(`DELETE FROM lessons WHERE PK = ?`, "COURSE#1"), nil)
+
+		assert.True(t, errors.Is(err, inmemory.ErrValidation))
+		assert.Contains(t, err.Error(), "mandatory equality on all key attributes")
+	})
+	t.Run("should refuse invalid statements and other tables", func(t *testing.T) {
+		_, err := d.Execute(domain.NewStatement(`SELECT * lessons`), nil)
+		assert.True(t, errors.Is(err, inmemory.ErrValidation))
+
+		_, err = d.Execute(domain.NewStatement(`SELECT * FROM courses`), nil)
+		assert.Contains(t, err.Error(), "Table: courses not found")
+	})
+}
+
+func TestDynamo_Concurrency(t *testing.T) {
+	d := inmemory.NewDynamo(table.MustNewTable("lessons", lesson{}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var result []lesson
+			_ = d.Put(lesson{PK: "COURSE#1", SK: string(rune('A' + i%26)), Position: int64(i)}, nil)
+			_ = d.Query(d.NewExpressionBuilder().Where(expressions.NewKeyCondition("PK", "COURSE#1")), &result)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, d.Items(), 26)
+}
+
+func titles(lessons []lesson) []string {
+	var result []string
+	for _, l := range lessons {
+		result = append(result, l.Title)
+	}
+
+	return result
+}
@@ -0,0 +1,668 @@
+package inmemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+)
+
+var (
+	// ErrConditionalCheckFailed é retornado quando a condition expression
+	// de uma escrita não é satisfeita
+	ErrConditionalCheckFailed = errors.New("the conditional request failed")
+	// ErrValidation é retornado para requisições que o DynamoDB
+	// recusaria com ValidationException
+	ErrValidation = errors.New("validation error")
+)
+
+type (
+	// keySchema são as chaves de uma tabela ou índice
+	keySchema struct {
+		hash      string
+		rangeName string
+	}
+
+	// store guarda os itens de uma tabela em memória. É seguro para uso
+	// concorrente
+	store struct {
+		mu sync.RWMutex
+
+		keySchema
+		indexes map[string]keySchema
+		items   map[string]map[string]types.AttributeValue
+	}
+
+	// write é uma escrita preparada, aplicada no store com commit
+	write struct {
+		id     string
+		old    map[string]types.AttributeValue
+		new    map[string]types.AttributeValue
+		remove bool
+	}
+
+	// page são os parâmetros de paginação de Query e Scan
+	page struct {
+		limit             int
+		exclusiveStartKey map[string]types.AttributeValue
+	}
+
+	// queryInput são os parâmetros de uma Query no store
+	queryInput struct {
+		page
+
+		indexName        string
+		keyCondition     string
+		filter           string
+		names            map[string]string
+		values           map[string]types.AttributeValue
+		scanIndexForward bool
+	}
+
+	// scanInput são os parâmetros de um Scan no store
+	scanInput struct {
+		page
+
+		indexName     string
+		filter        string
+		names         map[string]string
+		values        map[string]types.AttributeValue
+		segment       int
+		totalSegments int
+	}
+)
+
+// newStore cria um store vazio com o schema da tabela e dos seus índices
+func newStore(schema keySchema, indexes map[string]keySchema) *store {
+	return &store{
+		keySchema: schema,
+		indexes:   indexes,
+		items:     map[string]map[string]types.AttributeValue{},
+	}
+}
+
+// storeFor cria um store com o schema de uma domain.Table
+func storeFor(tb domain.Table) *store {
+	indexes := map[string]keySchema{}
+
+	for _, gsi := range tb.GetGSI() {
+		indexes[*gsi.IndexName] = schemaFrom(gsi.KeySchema)
+	}
+
+	for _, lsi := range tb.GetLSI() {
+		indexes[*lsi.IndexName] = schemaFrom(lsi.KeySchema)
+	}
+
+	return newStore(schemaFrom(tb.KeySchema()), indexes)
+}
+
+// schemaFrom extrai hash e range de um KeySchema
+func schemaFrom(elements []types.KeySchemaElement) keySchema {
+	var schema keySchema
+
+	for _, element := range elements {
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			schema.hash = *element.AttributeName
+		case types.KeyTypeRange:
+			schema.rangeName = *element.AttributeName
+		}
+	}
+
+	return schema
+}
+
+// key serializa a chave de um item segundo o schema
+func (k keySchema) key(item map[string]types.AttributeValue) (string, error) {
+	parts := []interface{}{}
+
+	for _, name := range []string{k.hash, k.rangeName} {
+		if name == "" {
+			continue
+		}
+
+		value, ok := item[name]
+		if !ok {
+			return "", fmt.Errorf("%w: missing key attribute %s", ErrValidation, name)
+		}
+
+		switch value.(type) {
+		case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		default:
+			return "", fmt.Errorf("%w: key attribute %s should be a string, number or binary", ErrValidation, name)
+		}
+
+		parts = append(parts, expressions.AttributeValueToDynamoJSON(value))
+	}
+
+	encoded, err := json.Marshal(parts)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// contains indica se o item pertence ao índice. Índices são esparsos:
+// itens sem algum dos atributos de chave ficam de fora
+func (k keySchema) contains(item map[string]types.AttributeValue) bool {
+	if _, ok := item[k.hash]; !ok {
+		return false
+	}
+
+	if k.rangeName != "" {
+		if _, ok := item[k.rangeName]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// copyItem faz uma cópia do item para que alterações fora do store não
+// afetem os dados guardados
+func copyItem(item map[string]types.AttributeValue) map[string]types.AttributeValue {
+	if item == nil {
+		return nil
+	}
+
+	copied := make(map[string]types.AttributeValue, len(item))
+	for name, value := range item {
+		copied[name] = value
+	}
+
+	return copied
+}
+
+// checkCondition avalia a condition expression de uma escrita sobre o
+// item atual, que pode ser nil
+func checkCondition(condition string, current map[string]types.AttributeValue, eval evaluator) error {
+	if condition == "" {
+		return nil
+	}
+
+	n, err := parseCondition(condition)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	matched, err := eval.match(n, current)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if !matched {
+		return ErrConditionalCheckFailed
+	}
+
+	return nil
+}
+
+// get retorna uma cópia do item com a chave informada ou nil
+func (s *store) get(key map[string]types.AttributeValue) (map[string]types.AttributeValue, error) {
+	id, err := s.keyID(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return copyItem(s.items[id]), nil
+}
+
+// put grava o item, substituindo o anterior, e retorna o item antigo
+func (s *store) put(item map[string]types.AttributeValue, condition string, eval evaluator) (map[string]types.AttributeValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.preparePut(item, condition, eval)
+	if err != nil {
+		return nil, err
+	}
+	s.commit(w)
+
+	return copyItem(w.old), nil
+}
+
+// update aplica a update expression no item, criando-o caso não exista,
+// e retorna o item antigo e o novo
+func (s *store) update(key map[string]types.AttributeValue, merge map[string]types.AttributeValue, updateExpression, condition string, eval evaluator) (map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.prepareUpdate(key, merge, updateExpression, condition, eval)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.commit(w)
+
+	return copyItem(w.old), copyItem(w.new), nil
+}
+
+// delete remove o item e retorna o item removido
+func (s *store) delete(key map[string]types.AttributeValue, condition string, eval evaluator) (map[string]types.AttributeValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.prepareDelete(key, condition, eval)
+	if err != nil {
+		return nil, err
+	}
+	s.commit(w)
+
+	return w.old, nil
+}
+
+// preparePut valida uma escrita de PutItem sem aplicá-la. Deve ser
+// chamado com o lock de escrita do store
+func (s *store) preparePut(item map[string]types.AttributeValue, condition string, eval evaluator) (write, error) {
+	id, err := s.key(item)
+	if err != nil {
+		return write{}, err
+	}
+
+	old := s.items[id]
+	if err = checkCondition(condition, old, eval); err != nil {
+		return write{}, err
+	}
+
+	return write{id: id, old: old, new: copyItem(item)}, nil
+}
+
+// prepareUpdate valida uma escrita de UpdateItem sem aplicá-la. Deve ser
+// chamado com o lock de escrita do store
+func (s *store) prepareUpdate(key map[string]types.AttributeValue, merge map[string]types.AttributeValue, updateExpression, condition string, eval evaluator) (write, error) {
+	id, err := s.keyID(key)
+	if err != nil {
+		return write{}, err
+	}
+
+	var actions []updateAction
+	if updateExpression != "" {
+		if actions, err = parseUpdate(updateExpression); err != nil {
+			return write{}, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+
+	old := s.items[id]
+	if err = checkCondition(condition, old, eval); err != nil {
+		return write{}, err
+	}
+
+	item := copyItem(old)
+	if item == nil {
+		item = copyItem(key)
+	}
+
+	for name, value := range merge {
+		if name != s.hash && name != s.rangeName {
+			item[name] = value
+		}
+	}
+
+	if err = eval.apply(actions, item); err != nil {
+		return write{}, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if newID, err := s.key(item); err != nil || newID != id {
+		return write{}, fmt.Errorf("%w: cannot update attribute that is part of the key", ErrValidation)
+	}
+
+	return write{id: id, old: old, new: item}, nil
+}
+
+// prepareDelete valida uma escrita de DeleteItem sem aplicá-la. Deve ser
+// chamado com o lock de escrita do store
+func (s *store) prepareDelete(key map[string]types.AttributeValue, condition string, eval evaluator) (write, error) {
+	id, err := s.keyID(key)
+	if err != nil {
+		return write{}, err
+	}
+
+	old := s.items[id]
+	if err = checkCondition(condition, old, eval); err != nil {
+		return write{}, err
+	}
+
+	return write{id: id, old: old, remove: true}, nil
+}
+
+// prepareCheck avalia a condição de um ConditionCheck. A escrita
+// retornada não altera o item
+func (s *store) prepareCheck(key map[string]types.AttributeValue, condition string, eval evaluator) (write, error) {
+	id, err := s.keyID(key)
+	if err != nil {
+		return write{}, err
+	}
+
+	old := s.items[id]
+	if err = checkCondition(condition, old, eval); err != nil {
+		return write{}, err
+	}
+
+	return write{id: id, old: old, new: old}, nil
+}
+
+// commit aplica uma escrita preparada. Deve ser chamado com o lock de
+// escrita do store
+func (s *store) commit(w write) {
+	if w.remove {
+		delete(s.items, w.id)
+		return
+	}
+
+	if w.new != nil {
+		s.items[w.id] = w.new
+	}
+}
+
+// keyID valida e serializa a chave de uma requisição
+func (s *store) keyID(key map[string]types.AttributeValue) (string, error) {
+	if err := s.validateKey(key); err != nil {
+		return "", err
+	}
+
+	return s.key(key)
+}
+
+// validateKey garante que a chave tem apenas os atributos da chave primária
+func (s *store) validateKey(key map[string]types.AttributeValue) error {
+	expected := 1
+	if s.rangeName != "" {
+		expected = 2
+	}
+
+	if len(key) != expected {
+		return fmt.Errorf("%w: the provided key element does not match the schema", ErrValidation)
+	}
+
+	return nil
+}
+
+// setIndex cria ou, com um schema vazio, remove um índice do store
+func (s *store) setIndex(name string, schema keySchema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if schema.hash == "" {
+		delete(s.indexes, name)
+		return
+	}
+
+	s.indexes[name] = schema
+}
+
+// len retorna a quantidade de itens guardados
+func (s *store) len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.items)
+}
+
+// schema retorna o schema da tabela ou do índice informado. Deve ser
+// chamado com o lock do store
+func (s *store) schema(indexName string) (keySchema, error) {
+	if indexName == "" {
+		return s.keySchema, nil
+	}
+
+	schema, ok := s.indexes[indexName]
+	if !ok {
+		return keySchema{}, fmt.Errorf("%w: the table does not have the specified index: %s", ErrValidation, indexName)
+	}
+
+	return schema, nil
+}
+
+// query retorna os itens da partição que satisfazem a key condition,
+// ordenados pela range key do índice, e a chave do último item avaliado
+// quando houver mais páginas
+func (s *store) query(input queryInput) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, err := s.schema(input.indexName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eval := evaluator{names: input.names, values: input.values}
+
+	keyCondition, err := parseCondition(input.keyCondition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err = validateKeyCondition(keyCondition, schema, eval); err != nil {
+		return nil, nil, err
+	}
+
+	filter, err := parseFilter(input.filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []map[string]types.AttributeValue
+	for _, item := range s.items {
+		if !schema.contains(item) {
+			continue
+		}
+
+		matched, err := eval.match(keyCondition, item)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if matched {
+			items = append(items, item)
+		}
+	}
+
+	s.sort(items, schema, input.scanIndexForward)
+
+	return s.page(items, schema, input.page, filter, eval)
+}
+
+// scan retorna os itens da tabela ou do índice que satisfazem o filtro,
+// em uma ordem estável, e a chave do último item avaliado quando houver
+// mais páginas
+func (s *store) scan(input scanInput) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	schema, err := s.schema(input.indexName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if input.totalSegments > 0 && (input.segment < 0 || input.segment >= input.totalSegments) {
+		return nil, nil, fmt.Errorf("%w: segment should be between 0 and %d", ErrValidation, input.totalSegments-1)
+	}
+
+	eval := evaluator{names: input.names, values: input.values}
+
+	filter, err := parseFilter(input.filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]string, 0, len(s.items))
+	for id := range s.items {
+		if input.totalSegments > 0 && segmentOf(id, input.totalSegments) != input.segment {
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var items []map[string]types.AttributeValue
+	for _, id := range ids {
+		if item := s.items[id]; schema.contains(item) {
+			items = append(items, item)
+		}
+	}
+
+	return s.page(items, schema, input.page, filter, eval)
+}
+
+// page aplica ExclusiveStartKey, Limit e o filtro em itens já ordenados.
+// Assim como no DynamoDB, o Limit conta os itens avaliados antes do filtro
+func (s *store) page(items []map[string]types.AttributeValue, schema keySchema, input page, filter *node, eval evaluator) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	if input.exclusiveStartKey != nil {
+		startID, err := s.key(input.exclusiveStartKey)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i, item := range items {
+			if id, _ := s.key(item); id == startID {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+
+	var lastEvaluatedKey map[string]types.AttributeValue
+	if input.limit > 0 && len(items) > input.limit {
+		items = items[:input.limit]
+		lastEvaluatedKey = s.keyAttributes(items[len(items)-1], schema)
+	}
+
+	result := []map[string]types.AttributeValue{}
+	for _, item := range items {
+		if filter != nil {
+			matched, err := eval.match(filter, item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %v", ErrValidation, err)
+			}
+
+			if !matched {
+				continue
+			}
+		}
+
+		result = append(result, copyItem(item))
+	}
+
+	return result, lastEvaluatedKey, nil
+}
+
+// keyAttributes retorna os atributos de chave da tabela e do índice do
+// item, usados como LastEvaluatedKey
+func (s *store) keyAttributes(item map[string]types.AttributeValue, schema keySchema) map[string]types.AttributeValue {
+	key := map[string]types.AttributeValue{}
+
+	for _, name := range []string{s.hash, s.rangeName, schema.hash, schema.rangeName} {
+		if value, ok := item[name]; ok && name != "" {
+			key[name] = value
+		}
+	}
+
+	return key
+}
+
+// parseFilter interpreta uma filter expression opcional
+func parseFilter(filter string) (*node, error) {
+	if filter == "" {
+		return nil, nil
+	}
+
+	n, err := parseCondition(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return n, nil
+}
+
+// segmentOf distribui os itens entre os segmentos de um Scan paralelo
+func segmentOf(id string, totalSegments int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+
+	return int(h.Sum32() % uint32(totalSegments))
+}
+
+// clear remove todos os itens
+func (s *store) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = map[string]map[string]types.AttributeValue{}
+}
+
+// sort ordena os itens pela range key do schema e, em seguida, pela
+// chave primária da tabela, para um resultado determinístico
+func (s *store) sort(items []map[string]types.AttributeValue, schema keySchema, forward bool) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if schema.rangeName != "" {
+			if cmp, ok := compare(items[i][schema.rangeName], items[j][schema.rangeName]); ok && cmp != 0 {
+				return (cmp < 0) == forward
+			}
+		}
+
+		a, _ := s.key(items[i])
+		b, _ := s.key(items[j])
+		return (a < b) == forward
+	})
+}
+
+// validateKeyCondition garante que a key condition tem uma igualdade na
+// hash key e, no máximo, uma condição na range key, como no DynamoDB
+func validateKeyCondition(n *node, schema keySchema, eval evaluator) error {
+	var conditions []*node
+
+	var flatten func(n *node)
+	flatten = func(n *node) {
+		if n.op == "AND" {
+			flatten(n.children[0])
+			flatten(n.children[1])
+			return
+		}
+
+		conditions = append(conditions, n)
+	}
+	flatten(n)
+
+	hasHash := false
+	for _, condition := range conditions {
+		if condition.op == "AND" || condition.op == "OR" || condition.op == "NOT" || len(condition.children) == 0 {
+			return fmt.Errorf("%w: invalid operator used in KeyConditionExpression: %s", ErrValidation, condition.op)
+		}
+
+		names, err := eval.references(condition)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if len(names) != 1 {
+			return fmt.Errorf("%w: key conditions should reference exactly one key attribute", ErrValidation)
+		}
+
+		switch names[0] {
+		case schema.hash:
+			if condition.op != "=" {
+				return fmt.Errorf("%w: query key condition not supported on hash key %s", ErrValidation, schema.hash)
+			}
+			hasHash = true
+		case schema.rangeName:
+		default:
+			return fmt.Errorf("%w: query condition missed key schema element: %s", ErrValidation, names[0])
+		}
+	}
+
+	if !hasHash {
+		return fmt.Errorf("%w: query condition missed key schema element: %s", ErrValidation, schema.hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,744 @@
+package inmemory
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type (
+	// token é um token de uma expressão do DynamoDB
+	token struct {
+		kind  string
+		value string
+	}
+
+	// node é um nó da árvore de uma condition expression
+	node struct {
+		op       string
+		children []*node
+		// operand é o nome do atributo (path) ou o placeholder de valor
+		operand string
+		isValue bool
+	}
+
+	// parser é o parser das condition / key condition / filter
+	// expressions e das update expressions
+	parser struct {
+		tokens []token
+		pos    int
+	}
+
+	// updateAction é uma ação de uma update expression
+	updateAction struct {
+		action string
+		path   string
+		value  *node
+	}
+)
+
+const (
+	tokenName    = "name"
+	tokenValue   = "value"
+	tokenSymbol  = "symbol"
+	tokenKeyword = "keyword"
+)
+
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+	"SET": true, "REMOVE": true, "ADD": true, "DELETE": true,
+}
+
+// tokenize separa uma expressão em tokens
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.ContainsRune("(),+-[]", rune(c)):
+			tokens = append(tokens, token{tokenSymbol, string(c)})
+			i++
+		case c == '=':
+			tokens = append(tokens, token{tokenSymbol, "="})
+			i++
+		case c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(expression) && (expression[i+1] == '=' || (c == '<' && expression[i+1] == '>')) {
+				op += string(expression[i+1])
+			}
+			tokens = append(tokens, token{tokenSymbol, op})
+			i += len(op)
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t\n\r(),=<>+-[]", rune(expression[i])) {
+				i++
+			}
+
+			word := expression[start:i]
+			switch {
+			case word == "":
+				return nil, fmt.Errorf("invalid character %q in expression", c)
+			case word[0] == ':':
+				tokens = append(tokens, token{tokenValue, word})
+			case keywords[strings.ToUpper(word)]:
+				tokens = append(tokens, token{tokenKeyword, strings.ToUpper(word)})
+			default:
+				tokens = append(tokens, token{tokenName, word})
+			}
+		}
+	}
+
+	return tokens, nil
+}
+
+func newParser(expression string) (*parser, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{}
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) expect(kind, value string) error {
+	t := p.next()
+	if t.kind != kind || (value != "" && t.value != value) {
+		return fmt.Errorf("expected %s %q, got %q", kind, value, t.value)
+	}
+
+	return nil
+}
+
+// parseCondition interpreta uma condition expression completa
+func parseCondition(expression string) (*node, error) {
+	p, err := newParser(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", expression, err)
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid expression %q: unexpected token %q", expression, p.peek().value)
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenKeyword && p.peek().value == "OR" {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &node{op: "OR", children: []*node{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenKeyword && p.peek().value == "AND" {
+		p.next()
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = &node{op: "AND", children: []*node{left, right}}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (*node, error) {
+	if p.peek().kind == tokenKeyword && p.peek().value == "NOT" {
+		p.next()
+
+		child, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return &node{op: "NOT", children: []*node{child}}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	t := p.peek()
+
+	if t.kind == tokenSymbol && t.value == "(" {
+		p.next()
+
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		return n, p.expect(tokenSymbol, ")")
+	}
+
+	// Funções: begins_with, contains, attribute_exists, attribute_not_exists
+	if t.kind == tokenName && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].value == "(" {
+		function := strings.ToLower(p.next().value)
+		p.next()
+
+		var args []*node
+		for {
+			arg, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+
+			if p.peek().value == "," {
+				p.next()
+				continue
+			}
+
+			break
+		}
+
+		if err := p.expect(tokenSymbol, ")"); err != nil {
+			return nil, err
+		}
+
+		switch function {
+		case "begins_with", "contains":
+			if len(args) != 2 {
+				return nil, fmt.Errorf("%s expects 2 arguments", function)
+			}
+		case "attribute_exists", "attribute_not_exists":
+			if len(args) != 1 {
+				return nil, fmt.Errorf("%s expects 1 argument", function)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported function %s", function)
+		}
+
+		return &node{op: function, children: args}, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenSymbol && strings.Contains("= <> < <= > >=", op.value) && op.value != "":
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		return &node{op: op.value, children: []*node{left, right}}, nil
+	case op.kind == tokenKeyword && op.value == "BETWEEN":
+		start, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(tokenKeyword, "AND"); err != nil {
+			return nil, err
+		}
+
+		end, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		return &node{op: "BETWEEN", children: []*node{left, start, end}}, nil
+	case op.kind == tokenKeyword && op.value == "IN":
+		if err = p.expect(tokenSymbol, "("); err != nil {
+			return nil, err
+		}
+
+		children := []*node{left}
+		for {
+			candidate, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, candidate)
+
+			if p.peek().value == "," {
+				p.next()
+				continue
+			}
+
+			break
+		}
+
+		return &node{op: "IN", children: children}, p.expect(tokenSymbol, ")")
+	}
+
+	return nil, fmt.Errorf("unexpected token %q", op.value)
+}
+
+// parseOperand interpreta um nome de atributo ou um placeholder de valor
+func (p *parser) parseOperand() (*node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenName:
+		return &node{operand: t.value}, nil
+	case tokenValue:
+		return &node{operand: t.value, isValue: true}, nil
+	}
+
+	return nil, fmt.Errorf("expected attribute or value, got %q", t.value)
+}
+
+// parseUpdate interpreta uma update expression com SET e REMOVE
+func parseUpdate(expression string) ([]updateAction, error) {
+	p, err := newParser(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []updateAction
+	clause := ""
+
+	for p.pos < len(p.tokens) {
+		t := p.peek()
+		if t.kind == tokenKeyword && (t.value == "SET" || t.value == "REMOVE" || t.value == "ADD" || t.value == "DELETE") {
+			clause = p.next().value
+			if clause == "ADD" || clause == "DELETE" {
+				return nil, fmt.Errorf("unsupported update clause %s", clause)
+			}
+		}
+
+		if clause == "" {
+			return nil, fmt.Errorf("invalid update expression %q", expression)
+		}
+
+		path := p.next()
+		if path.kind != tokenName {
+			return nil, fmt.Errorf("invalid update expression %q: expected attribute, got %q", expression, path.value)
+		}
+
+		action := updateAction{action: clause, path: path.value}
+
+		if clause == "SET" {
+			if err = p.expect(tokenSymbol, "="); err != nil {
+				return nil, fmt.Errorf("invalid update expression %q: %v", expression, err)
+			}
+
+			if action.value, err = p.parseSetValue(); err != nil {
+				return nil, fmt.Errorf("invalid update expression %q: %v", expression, err)
+			}
+		}
+
+		actions = append(actions, action)
+
+		if p.peek().value == "," {
+			p.next()
+		}
+	}
+
+	return actions, nil
+}
+
+// parseSetValue interpreta o valor de um SET: operando, if_not_exists ou
+// operações de + e -
+func (p *parser) parseSetValue() (*node, error) {
+	var left *node
+
+	if p.peek().kind == tokenName && strings.ToLower(p.peek().value) == "if_not_exists" {
+		p.next()
+
+		if err := p.expect(tokenSymbol, "("); err != nil {
+			return nil, err
+		}
+
+		path, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(tokenSymbol, ","); err != nil {
+			return nil, err
+		}
+
+		fallback, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		if err = p.expect(tokenSymbol, ")"); err != nil {
+			return nil, err
+		}
+
+		left = &node{op: "if_not_exists", children: []*node{path, fallback}}
+	} else {
+		operand, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		left = operand
+	}
+
+	if op := p.peek(); op.kind == tokenSymbol && (op.value == "+" || op.value == "-") {
+		p.next()
+
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+
+		return &node{op: op.value, children: []*node{left, right}}, nil
+	}
+
+	return left, nil
+}
+
+// evaluator resolve os placeholders de nomes e valores de uma expressão
+type evaluator struct {
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+// attributeName resolve um nome de atributo, substituindo #placeholders
+func (e evaluator) attributeName(name string) (string, error) {
+	if strings.HasPrefix(name, "#") {
+		resolved, ok := e.names[name]
+		if !ok {
+			return "", fmt.Errorf("attribute name placeholder %s is not defined", name)
+		}
+
+		return resolved, nil
+	}
+
+	return name, nil
+}
+
+// resolve retorna o valor de um operando para o item
+func (e evaluator) resolve(n *node, item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	if n.isValue {
+		value, ok := e.values[n.operand]
+		if !ok {
+			return nil, fmt.Errorf("value placeholder %s is not defined", n.operand)
+		}
+
+		return value, nil
+	}
+
+	name, err := e.attributeName(n.operand)
+	if err != nil {
+		return nil, err
+	}
+
+	return item[name], nil
+}
+
+// match avalia uma condition expression para o item
+func (e evaluator) match(n *node, item map[string]types.AttributeValue) (bool, error) {
+	switch n.op {
+	case "AND", "OR":
+		left, err := e.match(n.children[0], item)
+		if err != nil {
+			return false, err
+		}
+
+		right, err := e.match(n.children[1], item)
+		if err != nil {
+			return false, err
+		}
+
+		if n.op == "AND" {
+			return left && right, nil
+		}
+
+		return left || right, nil
+	case "NOT":
+		matched, err := e.match(n.children[0], item)
+		return !matched, err
+	case "attribute_exists", "attribute_not_exists":
+		value, err := e.resolve(n.children[0], item)
+		if err != nil {
+			return false, err
+		}
+
+		return (value != nil) == (n.op == "attribute_exists"), nil
+	}
+
+	operands := make([]types.AttributeValue, len(n.children))
+	for i, child := range n.children {
+		value, err := e.resolve(child, item)
+		if err != nil {
+			return false, err
+		}
+
+		operands[i] = value
+	}
+
+	if operands[0] == nil {
+		return n.op == "<>", nil
+	}
+
+	switch n.op {
+	case "=":
+		return equal(operands[0], operands[1]), nil
+	case "<>":
+		return !equal(operands[0], operands[1]), nil
+	case "<", "<=", ">", ">=":
+		cmp, ok := compare(operands[0], operands[1])
+		if !ok {
+			return false, nil
+		}
+
+		switch n.op {
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		}
+
+		return cmp >= 0, nil
+	case "BETWEEN":
+		low, okLow := compare(operands[0], operands[1])
+		high, okHigh := compare(operands[0], operands[2])
+		return okLow && okHigh && low >= 0 && high <= 0, nil
+	case "IN":
+		for _, candidate := range operands[1:] {
+			if equal(operands[0], candidate) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case "begins_with":
+		switch value := operands[0].(type) {
+		case *types.AttributeValueMemberS:
+			prefix, ok := operands[1].(*types.AttributeValueMemberS)
+			return ok && strings.HasPrefix(value.Value, prefix.Value), nil
+		case *types.AttributeValueMemberB:
+			prefix, ok := operands[1].(*types.AttributeValueMemberB)
+			return ok && strings.HasPrefix(string(value.Value), string(prefix.Value)), nil
+		}
+
+		return false, nil
+	case "contains":
+		return contains(operands[0], operands[1]), nil
+	}
+
+	return false, fmt.Errorf("unsupported operator %s", n.op)
+}
+
+// apply aplica as ações de uma update expression no item
+func (e evaluator) apply(actions []updateAction, item map[string]types.AttributeValue) error {
+	for _, action := range actions {
+		name, err := e.attributeName(action.path)
+		if err != nil {
+			return err
+		}
+
+		if action.action == "REMOVE" {
+			delete(item, name)
+			continue
+		}
+
+		value, err := e.setValue(action.value, item)
+		if err != nil {
+			return err
+		}
+
+		item[name] = value
+	}
+
+	return nil
+}
+
+// setValue calcula o valor de um SET
+func (e evaluator) setValue(n *node, item map[string]types.AttributeValue) (types.AttributeValue, error) {
+	switch n.op {
+	case "":
+		value, err := e.resolve(n, item)
+		if err == nil && value == nil {
+			err = fmt.Errorf("attribute %s does not exist", n.operand)
+		}
+
+		return value, err
+	case "if_not_exists":
+		current, err := e.resolve(n.children[0], item)
+		if err != nil || current != nil {
+			return current, err
+		}
+
+		return e.resolve(n.children[1], item)
+	case "+", "-":
+		left, err := e.setValue(n.children[0], item)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := e.setValue(n.children[1], item)
+		if err != nil {
+			return nil, err
+		}
+
+		leftN, okLeft := left.(*types.AttributeValueMemberN)
+		rightN, okRight := right.(*types.AttributeValueMemberN)
+		if !okLeft || !okRight {
+			return nil, fmt.Errorf("operator %s requires numbers", n.op)
+		}
+
+		a, _ := new(big.Float).SetString(leftN.Value)
+		b, _ := new(big.Float).SetString(rightN.Value)
+		if a == nil || b == nil {
+			return nil, fmt.Errorf("invalid number")
+		}
+
+		if n.op == "+" {
+			a.Add(a, b)
+		} else {
+			a.Sub(a, b)
+		}
+
+		return &types.AttributeValueMemberN{Value: a.Text('f', -1)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported update operator %s", n.op)
+}
+
+// references retorna os nomes de atributos referenciados pela expressão
+func (e evaluator) references(n *node) ([]string, error) {
+	if n.op == "" {
+		if n.isValue {
+			return nil, nil
+		}
+
+		name, err := e.attributeName(n.operand)
+		return []string{name}, err
+	}
+
+	var names []string
+	for _, child := range n.children {
+		childNames, err := e.references(child)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, childNames...)
+	}
+
+	return names, nil
+}
+
+// equal compara dois AttributeValue
+func equal(a, b types.AttributeValue) bool {
+	if cmp, ok := compare(a, b); ok {
+		return cmp == 0
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compare ordena dois valores escalares do mesmo tipo (S, N ou B)
+func compare(a, b types.AttributeValue) (int, bool) {
+	switch left := a.(type) {
+	case *types.AttributeValueMemberS:
+		right, ok := b.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(left.Value, right.Value), true
+	case *types.AttributeValueMemberN:
+		right, ok := b.(*types.AttributeValueMemberN)
+		if !ok {
+			return 0, false
+		}
+
+		x, okX := new(big.Float).SetString(left.Value)
+		y, okY := new(big.Float).SetString(right.Value)
+		if !okX || !okY {
+			return 0, false
+		}
+
+		return x.Cmp(y), true
+	case *types.AttributeValueMemberB:
+		right, ok := b.(*types.AttributeValueMemberB)
+		if !ok {
+			return 0, false
+		}
+
+		return strings.Compare(string(left.Value), string(right.Value)), true
+	}
+
+	return 0, false
+}
+
+// contains implementa a função contains para strings, sets e listas
+func contains(container, value types.AttributeValue) bool {
+	switch c := container.(type) {
+	case *types.AttributeValueMemberS:
+		v, ok := value.(*types.AttributeValueMemberS)
+		return ok && strings.Contains(c.Value, v.Value)
+	case *types.AttributeValueMemberSS:
+		v, ok := value.(*types.AttributeValueMemberS)
+		for _, item := range c.Value {
+			if ok && item == v.Value {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberNS:
+		for _, item := range c.Value {
+			if equal(&types.AttributeValueMemberN{Value: item}, value) {
+				return true
+			}
+		}
+	case *types.AttributeValueMemberL:
+		for _, item := range c.Value {
+			if equal(item, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,1196 @@
+package inmemory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// batchStatementLimit é o máximo de instruções de um BatchExecuteStatement
+	batchStatementLimit = 25
+	// transactStatementLimit é o máximo de instruções de um ExecuteTransaction
+	transactStatementLimit = 100
+
+	pqIdent   = "ident"
+	pqQuoted  = "quoted"
+	pqString  = "string"
+	pqNumber  = "number"
+	pqParam   = "param"
+	pqSymbol  = "symbol"
+	pqKeyword = "keyword"
+)
+
+// ErrDuplicateItem é retornado pelo INSERT de um item que já existe
+var ErrDuplicateItem = errors.New("duplicate primary key exists in table")
+
+type (
+	// statement é uma instrução PartiQL interpretada. Os nomes e valores
+	// viram placeholders das expressões do DynamoDB, avaliadas pelo mesmo
+	// evaluator das operações com expressões
+	statement struct {
+		kind       string
+		table      string
+		index      string
+		projection string
+		where      string
+		item       map[string]types.AttributeValue
+		update     string
+		returning  string
+		eval       evaluator
+	}
+
+	// statementParser interpreta o subconjunto de PartiQL suportado:
+	//
+	// 	SELECT * | a, b FROM "tabela"[."indice"] [WHERE condição]
+	// 	INSERT INTO "tabela" VALUE {'a': ?, ...}
+	// 	UPDATE "tabela" SET a = ? [SET ...] [REMOVE b] WHERE chave [RETURNING ...]
+	// 	DELETE FROM "tabela" WHERE chave [RETURNING ALL OLD *]
+	statementParser struct {
+		tokens []token
+		pos    int
+		params []types.AttributeValue
+		used   int
+		eval   evaluator
+	}
+
+	// equality é uma condição de igualdade entre um atributo e um valor
+	equality struct {
+		name  string
+		value string
+		attr  types.AttributeValue
+	}
+
+	// storeResolver retorna o store da tabela de uma instrução
+	storeResolver func(table string) (*store, error)
+
+	// batchResult é o resultado de uma instrução de um lote
+	batchResult struct {
+		table string
+		item  map[string]types.AttributeValue
+		err   error
+	}
+)
+
+var statementKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "INSERT": true, "INTO": true, "VALUE": true,
+	"UPDATE": true, "SET": true, "REMOVE": true, "DELETE": true, "RETURNING": true,
+	"ALL": true, "MODIFIED": true, "NEW": true, "OLD": true, "AND": true, "OR": true,
+	"NOT": true, "BETWEEN": true, "IN": true, "IS": true, "MISSING": true,
+	"NULL": true, "TRUE": true, "FALSE": true,
+}
+
+// tokenizeStatement separa uma instrução PartiQL em tokens
+func tokenizeStatement(query string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		rest := query[i:]
+
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '"' || c == '\'':
+			value, size, err := unquote(rest)
+			if err != nil {
+				return nil, err
+			}
+
+			kind := pqQuoted
+			if c == '\'' {
+				kind = pqString
+			}
+
+			tokens = append(tokens, token{kind, value})
+			i += size
+		case c == '?':
+			tokens = append(tokens, token{pqParam, "?"})
+			i++
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(query) && (strings.ContainsRune("0123456789.eE", rune(query[i])) ||
+				(strings.ContainsRune("+-", rune(query[i])) && strings.ContainsRune("eE", rune(query[i-1])))) {
+				i++
+			}
+
+			tokens = append(tokens, token{pqNumber, query[start:i]})
+		case strings.HasPrefix(rest, "<<") || strings.HasPrefix(rest, ">>") || strings.HasPrefix(rest, "<=") ||
+			strings.HasPrefix(rest, ">=") || strings.HasPrefix(rest, "<>") || strings.HasPrefix(rest, "!="):
+			tokens = append(tokens, token{pqSymbol, rest[:2]})
+			i += 2
+		case strings.ContainsRune("(),=<>[]{}:.*+-", rune(c)):
+			tokens = append(tokens, token{pqSymbol, string(c)})
+			i++
+		case c == '_' || unicode.IsLetter(rune(c)):
+			start := i
+			for i < len(query) && (query[i] == '_' || unicode.IsLetter(rune(query[i])) || unicode.IsDigit(rune(query[i]))) {
+				i++
+			}
+
+			word := query[start:i]
+			if statementKeywords[strings.ToUpper(word)] {
+				tokens = append(tokens, token{pqKeyword, strings.ToUpper(word)})
+			} else {
+				tokens = append(tokens, token{pqIdent, word})
+			}
+		default:
+			return nil, fmt.Errorf("invalid character %q in statement", c)
+		}
+	}
+
+	return tokens, nil
+}
+
+// unquote lê um texto entre aspas do início de s, onde as aspas repetidas
+// escapam a própria aspa. Retorna o texto e o tamanho consumido
+func unquote(s string) (string, int, error) {
+	quote := s[0]
+	var value strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			value.WriteByte(s[i])
+			continue
+		}
+
+		if i+1 < len(s) && s[i+1] == quote {
+			value.WriteByte(quote)
+			i++
+			continue
+		}
+
+		return value.String(), i + 1, nil
+	}
+
+	return "", 0, fmt.Errorf("unterminated %c in statement", quote)
+}
+
+// parseStatement interpreta uma instrução com os parâmetros posicionais
+func parseStatement(query string, params []types.AttributeValue) (*statement, error) {
+	tokens, err := tokenizeStatement(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	p := &statementParser{
+		tokens: tokens,
+		params: params,
+		eval:   evaluator{names: map[string]string{}, values: map[string]types.AttributeValue{}},
+	}
+
+	st := &statement{eval: p.eval}
+
+	switch {
+	case p.accept(pqKeyword, "SELECT"):
+		st.kind, err = "SELECT", p.parseSelect(st)
+	case p.accept(pqKeyword, "INSERT"):
+		st.kind, err = "INSERT", p.parseInsert(st)
+	case p.accept(pqKeyword, "UPDATE"):
+		st.kind, err = "UPDATE", p.parseUpdate(st)
+	case p.accept(pqKeyword, "DELETE"):
+		st.kind, err = "DELETE", p.parseDelete(st)
+	default:
+		err = errors.New("statement should start with SELECT, INSERT, UPDATE or DELETE")
+	}
+
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected token %q", p.peek().value)
+	}
+
+	if err == nil && p.used != len(params) {
+		err = fmt.Errorf("number of parameters in request and statement don't match: expected %d, got %d", p.used, len(params))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: statement %q: %v", ErrValidation, query, err)
+	}
+
+	return st, nil
+}
+
+func (p *statementParser) peek() token {
+	if p.pos >= len(p.tokens) {
+		return token{}
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *statementParser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept consome o próximo token quando ele é o esperado
+func (p *statementParser) accept(kind, value string) bool {
+	if t := p.peek(); t.kind == kind && t.value == value {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+func (p *statementParser) expect(kind, value string) error {
+	if !p.accept(kind, value) {
+		return fmt.Errorf("expected %q, got %q", value, p.peek().value)
+	}
+
+	return nil
+}
+
+// param consome o próximo parâmetro posicional
+func (p *statementParser) param() (types.AttributeValue, error) {
+	if p.used >= len(p.params) {
+		return nil, fmt.Errorf("number of parameters in request and statement don't match: got %d", len(p.params))
+	}
+
+	p.used++
+	return p.params[p.used-1], nil
+}
+
+// nameRef cria o placeholder de um nome de atributo
+func (p *statementParser) nameRef(name string) string {
+	ref := fmt.Sprintf("#n%d", len(p.eval.names))
+	p.eval.names[ref] = name
+
+	return ref
+}
+
+// valueRef cria o placeholder de um valor
+func (p *statementParser) valueRef(value types.AttributeValue) string {
+	ref := fmt.Sprintf(":v%d", len(p.eval.values))
+	p.eval.values[ref] = value
+
+	return ref
+}
+
+// identifier consome um nome de tabela, índice ou atributo
+func (p *statementParser) identifier() (string, error) {
+	t := p.next()
+	if t.kind != pqIdent && t.kind != pqQuoted {
+		return "", fmt.Errorf("expected identifier, got %q", t.value)
+	}
+
+	return t.value, nil
+}
+
+// parseTable consome o nome da tabela e, com "tabela"."indice", do índice
+func (p *statementParser) parseTable(st *statement, allowIndex bool) error {
+	var err error
+	if st.table, err = p.identifier(); err != nil {
+		return err
+	}
+
+	if !p.accept(pqSymbol, ".") {
+		return nil
+	}
+
+	if !allowIndex {
+		return errors.New("indexes are only supported on SELECT")
+	}
+
+	st.index, err = p.identifier()
+
+	return err
+}
+
+func (p *statementParser) parseSelect(st *statement) error {
+	if !p.accept(pqSymbol, "*") {
+		var projection []string
+
+		for {
+			name, err := p.identifier()
+			if err != nil {
+				return err
+			}
+
+			if p.peek().value == "." || p.peek().value == "[" {
+				return errors.New("nested paths are not supported")
+			}
+
+			projection = append(projection, p.nameRef(name))
+
+			if !p.accept(pqSymbol, ",") {
+				break
+			}
+		}
+
+		st.projection = strings.Join(projection, ", ")
+	}
+
+	if err := p.expect(pqKeyword, "FROM"); err != nil {
+		return err
+	}
+
+	if err := p.parseTable(st, true); err != nil {
+		return err
+	}
+
+	if !p.accept(pqKeyword, "WHERE") {
+		return nil
+	}
+
+	return p.parseWhere(st)
+}
+
+func (p *statementParser) parseInsert(st *statement) error {
+	if err := p.expect(pqKeyword, "INTO"); err != nil {
+		return err
+	}
+
+	if err := p.parseTable(st, false); err != nil {
+		return err
+	}
+
+	if err := p.expect(pqKeyword, "VALUE"); err != nil {
+		return err
+	}
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	item, ok := value.(*types.AttributeValueMemberM)
+	if !ok {
+		return errors.New("INSERT value should be a tuple")
+	}
+
+	st.item = item.Value
+
+	return nil
+}
+
+func (p *statementParser) parseUpdate(st *statement) error {
+	if err := p.parseTable(st, false); err != nil {
+		return err
+	}
+
+	var sets, removes []string
+
+	for {
+		switch {
+		case p.accept(pqKeyword, "SET"):
+			set, err := p.translate("SET", "REMOVE", "WHERE", "RETURNING")
+			if err != nil {
+				return err
+			}
+
+			sets = append(sets, set)
+			continue
+		case p.accept(pqKeyword, "REMOVE"):
+			remove, err := p.translate("SET", "REMOVE", "WHERE", "RETURNING")
+			if err != nil {
+				return err
+			}
+
+			removes = append(removes, remove)
+			continue
+		}
+
+		break
+	}
+
+	if len(sets) == 0 && len(removes) == 0 {
+		return errors.New("UPDATE should have a SET or REMOVE clause")
+	}
+
+	var clauses []string
+	if len(sets) > 0 {
+		clauses = append(clauses, "SET "+strings.Join(sets, ", "))
+	}
+
+	if len(removes) > 0 {
+		clauses = append(clauses, "REMOVE "+strings.Join(removes, ", "))
+	}
+
+	st.update = strings.Join(clauses, " ")
+	if _, err := parseUpdate(st.update); err != nil {
+		return err
+	}
+
+	if err := p.expect(pqKeyword, "WHERE"); err != nil {
+		return err
+	}
+
+	if err := p.parseWhere(st); err != nil {
+		return err
+	}
+
+	return p.parseReturning(st)
+}
+
+func (p *statementParser) parseDelete(st *statement) error {
+	if err := p.expect(pqKeyword, "FROM"); err != nil {
+		return err
+	}
+
+	if err := p.parseTable(st, false); err != nil {
+		return err
+	}
+
+	if err := p.expect(pqKeyword, "WHERE"); err != nil {
+		return err
+	}
+
+	if err := p.parseWhere(st); err != nil {
+		return err
+	}
+
+	return p.parseReturning(st)
+}
+
+// parseWhere traduz a condição do WHERE em uma condition expression
+func (p *statementParser) parseWhere(st *statement) error {
+	where, err := p.translate("RETURNING")
+	if err != nil {
+		return err
+	}
+
+	if _, err = parseCondition(where); err != nil {
+		return err
+	}
+
+	st.where = where
+
+	return nil
+}
+
+// parseReturning consome RETURNING (ALL | MODIFIED) (OLD | NEW) *
+func (p *statementParser) parseReturning(st *statement) error {
+	if !p.accept(pqKeyword, "RETURNING") {
+		return nil
+	}
+
+	scope := p.next()
+	image := p.next()
+
+	if scope.kind != pqKeyword || (scope.value != "ALL" && scope.value != "MODIFIED") ||
+		image.kind != pqKeyword || (image.value != "OLD" && image.value != "NEW") {
+		return errors.New("RETURNING should be ALL OLD, ALL NEW, MODIFIED OLD or MODIFIED NEW")
+	}
+
+	st.returning = scope.value + " " + image.value
+
+	return p.expect(pqSymbol, "*")
+}
+
+// translate converte os tokens até uma das palavras de stop, fora de
+// parênteses, na sintaxe das expressões do DynamoDB: nomes viram #n,
+// parâmetros e literais viram :v, [ ] vira ( ) e IS [NOT] MISSING vira
+// attribute_not_exists e attribute_exists
+func (p *statementParser) translate(stop ...string) (string, error) {
+	var out []string
+	depth := 0
+
+	for p.pos < len(p.tokens) {
+		t := p.peek()
+		if depth == 0 && t.kind == pqKeyword && hasKeyword(stop, t.value) {
+			break
+		}
+		p.next()
+
+		switch t.kind {
+		case pqIdent, pqQuoted:
+			if t.kind == pqIdent && p.peek().value == "(" {
+				out = append(out, strings.ToLower(t.value))
+				continue
+			}
+
+			if p.peek().value == "." || p.peek().value == "[" {
+				return "", errors.New("nested paths are not supported")
+			}
+
+			out = append(out, p.nameRef(t.value))
+		case pqParam:
+			value, err := p.param()
+			if err != nil {
+				return "", err
+			}
+
+			out = append(out, p.valueRef(value))
+		case pqString, pqNumber:
+			p.pos--
+
+			value, err := p.value()
+			if err != nil {
+				return "", err
+			}
+
+			out = append(out, p.valueRef(value))
+		case pqKeyword:
+			switch t.value {
+			case "AND", "OR", "NOT", "BETWEEN", "IN":
+				out = append(out, t.value)
+			case "TRUE", "FALSE", "NULL":
+				p.pos--
+
+				value, err := p.value()
+				if err != nil {
+					return "", err
+				}
+
+				out = append(out, p.valueRef(value))
+			case "IS":
+				if len(out) == 0 || !strings.HasPrefix(out[len(out)-1], "#") {
+					return "", errors.New("IS should follow an attribute")
+				}
+
+				function := "attribute_not_exists"
+				if p.accept(pqKeyword, "NOT") {
+					function = "attribute_exists"
+				}
+
+				if err := p.expect(pqKeyword, "MISSING"); err != nil {
+					return "", err
+				}
+
+				out[len(out)-1] = fmt.Sprintf("%s(%s)", function, out[len(out)-1])
+			default:
+				return "", fmt.Errorf("unexpected keyword %s", t.value)
+			}
+		case pqSymbol:
+			switch t.value {
+			case "(", "[":
+				depth++
+				out = append(out, "(")
+			case ")", "]":
+				depth--
+				out = append(out, ")")
+			case "!=":
+				out = append(out, "<>")
+			case "-":
+				if p.peek().kind == pqNumber && !isOperand(out) {
+					value := p.next().value
+					out = append(out, p.valueRef(&types.AttributeValueMemberN{Value: "-" + value}))
+					continue
+				}
+
+				out = append(out, t.value)
+			case ",", "=", "<>", "<", "<=", ">", ">=", "+":
+				out = append(out, t.value)
+			default:
+				return "", fmt.Errorf("unexpected token %q", t.value)
+			}
+		}
+	}
+
+	if len(out) == 0 {
+		return "", fmt.Errorf("expected expression, got %q", p.peek().value)
+	}
+
+	return strings.Join(out, " "), nil
+}
+
+// value consome um valor: parâmetro, texto, número, TRUE, FALSE, NULL,
+// lista [ ], tupla { } ou set << >>
+func (p *statementParser) value() (types.AttributeValue, error) {
+	t := p.next()
+
+	switch {
+	case t.kind == pqParam:
+		return p.param()
+	case t.kind == pqString:
+		return &types.AttributeValueMemberS{Value: t.value}, nil
+	case t.kind == pqNumber:
+		return &types.AttributeValueMemberN{Value: t.value}, nil
+	case t.kind == pqSymbol && t.value == "-" && p.peek().kind == pqNumber:
+		return &types.AttributeValueMemberN{Value: "-" + p.next().value}, nil
+	case t.kind == pqKeyword && (t.value == "TRUE" || t.value == "FALSE"):
+		return &types.AttributeValueMemberBOOL{Value: t.value == "TRUE"}, nil
+	case t.kind == pqKeyword && t.value == "NULL":
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case t.kind == pqSymbol && t.value == "[":
+		list, err := p.values("]")
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case t.kind == pqSymbol && t.value == "<<":
+		set, err := p.values(">>")
+		if err != nil {
+			return nil, err
+		}
+
+		return setOf(set)
+	case t.kind == pqSymbol && t.value == "{":
+		return p.tuple()
+	}
+
+	return nil, fmt.Errorf("expected value, got %q", t.value)
+}
+
+// values consome os valores separados por vírgula até o símbolo de fim
+func (p *statementParser) values(end string) ([]types.AttributeValue, error) {
+	list := []types.AttributeValue{}
+
+	for !p.accept(pqSymbol, end) {
+		if len(list) > 0 {
+			if err := p.expect(pqSymbol, ","); err != nil {
+				return nil, err
+			}
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, value)
+	}
+
+	return list, nil
+}
+
+// tuple consome os pares 'nome': valor até o }
+func (p *statementParser) tuple() (types.AttributeValue, error) {
+	members := map[string]types.AttributeValue{}
+
+	for !p.accept(pqSymbol, "}") {
+		if len(members) > 0 {
+			if err := p.expect(pqSymbol, ","); err != nil {
+				return nil, err
+			}
+		}
+
+		name := p.next()
+		if name.kind != pqString && name.kind != pqQuoted {
+			return nil, fmt.Errorf("expected attribute name, got %q", name.value)
+		}
+
+		if err := p.expect(pqSymbol, ":"); err != nil {
+			return nil, err
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		members[name.value] = value
+	}
+
+	return &types.AttributeValueMemberM{Value: members}, nil
+}
+
+// setOf converte os valores de << >> em SS, NS ou BS
+func setOf(values []types.AttributeValue) (types.AttributeValue, error) {
+	var ss, ns []string
+	var bs [][]byte
+
+	for _, value := range values {
+		switch v := value.(type) {
+		case *types.AttributeValueMemberS:
+			ss = append(ss, v.Value)
+		case *types.AttributeValueMemberN:
+			ns = append(ns, v.Value)
+		case *types.AttributeValueMemberB:
+			bs = append(bs, v.Value)
+		default:
+			return nil, errors.New("sets should have only strings, numbers or binaries")
+		}
+	}
+
+	switch {
+	case len(ss) == len(values) && len(ss) > 0:
+		return &types.AttributeValueMemberSS{Value: ss}, nil
+	case len(ns) == len(values) && len(ns) > 0:
+		return &types.AttributeValueMemberNS{Value: ns}, nil
+	case len(bs) == len(values) && len(bs) > 0:
+		return &types.AttributeValueMemberBS{Value: bs}, nil
+	}
+
+	return nil, errors.New("sets should not be empty or mix types")
+}
+
+func hasKeyword(keywords []string, keyword string) bool {
+	for _, k := range keywords {
+		if k == keyword {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOperand indica se o último token traduzido encerra um operando
+func isOperand(out []string) bool {
+	if len(out) == 0 {
+		return false
+	}
+
+	last := out[len(out)-1]
+
+	return strings.HasPrefix(last, "#") || strings.HasPrefix(last, ":") || last == ")"
+}
+
+// equalities retorna as igualdades entre atributo e valor do WHERE
+// ligadas por AND, pelo nome do atributo
+func (st *statement) equalities() map[string]equality {
+	equals := map[string]equality{}
+	if st.where == "" {
+		return equals
+	}
+
+	n, err := parseCondition(st.where)
+	if err != nil {
+		return equals
+	}
+
+	var collect func(n *node)
+	collect = func(n *node) {
+		switch n.op {
+		case "AND":
+			collect(n.children[0])
+			collect(n.children[1])
+		case "=":
+			name, value := n.children[0], n.children[1]
+			if name.isValue {
+				name, value = value, name
+			}
+
+			if name.isValue || !value.isValue {
+				return
+			}
+
+			attribute, _ := st.eval.attributeName(name.operand)
+			equals[attribute] = equality{name: name.operand, value: value.operand, attr: st.eval.values[value.operand]}
+		}
+	}
+	collect(n)
+
+	return equals
+}
+
+// key retorna a chave do item a partir das igualdades do WHERE, que
+// devem cobrir todos os atributos de chave da tabela
+func (st *statement) key(schema keySchema) (map[string]types.AttributeValue, error) {
+	equals := st.equalities()
+	key := map[string]types.AttributeValue{}
+
+	for _, name := range []string{schema.hash, schema.rangeName} {
+		if name == "" {
+			continue
+		}
+
+		equal, ok := equals[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: where clause does not contain a mandatory equality on all key attributes", ErrValidation)
+		}
+
+		key[name] = equal.attr
+	}
+
+	return key, nil
+}
+
+// withName retorna uma cópia do evaluator com mais um placeholder de nome
+func (e evaluator) withName(placeholder, name string) evaluator {
+	names := map[string]string{placeholder: name}
+	for k, v := range e.names {
+		names[k] = v
+	}
+
+	return evaluator{names: names, values: e.values}
+}
+
+// selectItems executa um SELECT. Com igualdade na hash key da tabela ou
+// do índice os itens vêm ordenados pela range key, como em uma Query, e
+// sem ela a tabela é lida inteira, como em um Scan
+func (s *store) selectItems(st *statement, p page) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+	s.mu.RLock()
+	schema, err := s.schema(st.index)
+	s.mu.RUnlock()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []map[string]types.AttributeValue
+	var lastEvaluatedKey map[string]types.AttributeValue
+
+	if equal, ok := st.equalities()[schema.hash]; ok {
+		items, lastEvaluatedKey, err = s.query(queryInput{
+			page:             p,
+			indexName:        st.index,
+			keyCondition:     fmt.Sprintf("%s = %s", equal.name, equal.value),
+			filter:           st.where,
+			names:            st.eval.names,
+			values:           st.eval.values,
+			scanIndexForward: true,
+		})
+	} else {
+		items, lastEvaluatedKey, err = s.scan(scanInput{
+			page:      p,
+			indexName: st.index,
+			filter:    st.where,
+			names:     st.eval.names,
+			values:    st.eval.values,
+		})
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, item := range items {
+		if items[i], err = projectItem(item, st.projection, st.eval.names); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return items, lastEvaluatedKey, nil
+}
+
+// getItem executa um SELECT pela chave completa, usado nos lotes e nas
+// transações. Retorna nil quando o item não existe ou não satisfaz o WHERE
+func (s *store) getItem(st *statement) (map[string]types.AttributeValue, error) {
+	if st.index != "" {
+		return nil, fmt.Errorf("%w: batch and transaction reads should not use indexes", ErrValidation)
+	}
+
+	key, err := st.key(s.keySchema)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.get(key)
+	if err != nil || item == nil {
+		return nil, err
+	}
+
+	if err = checkCondition(st.where, item, st.eval); errors.Is(err, ErrConditionalCheckFailed) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return projectItem(item, st.projection, st.eval.names)
+}
+
+// prepareStatement valida a escrita de um INSERT, UPDATE ou DELETE sem
+// aplicá-la. Deve ser chamado com o lock de escrita do store
+func (s *store) prepareStatement(st *statement) (write, error) {
+	switch st.kind {
+	case "INSERT":
+		w, err := s.preparePut(st.item, "attribute_not_exists(#key)", st.eval.withName("#key", s.hash))
+		if errors.Is(err, ErrConditionalCheckFailed) {
+			return write{}, ErrDuplicateItem
+		}
+
+		return w, err
+	case "UPDATE":
+		key, err := st.key(s.keySchema)
+		if err != nil {
+			return write{}, err
+		}
+
+		// O WHERE inclui a igualdade da chave, então o UPDATE de um
+		// item que não existe falha na condição
+		return s.prepareUpdate(key, nil, st.update, st.where, st.eval)
+	case "DELETE":
+		key, err := st.key(s.keySchema)
+		if err != nil {
+			return write{}, err
+		}
+
+		condition := fmt.Sprintf("attribute_not_exists(#key) OR (%s)", st.where)
+
+		return s.prepareDelete(key, condition, st.eval.withName("#key", s.hash))
+	}
+
+	return write{}, fmt.Errorf("%w: %s is not a write statement", ErrValidation, st.kind)
+}
+
+// executeWrite aplica um INSERT, UPDATE ou DELETE e retorna os itens do
+// RETURNING
+func (s *store) executeWrite(st *statement) ([]map[string]types.AttributeValue, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.prepareStatement(st)
+	if err != nil {
+		return nil, err
+	}
+	s.commit(w)
+
+	return st.returned(w), nil
+}
+
+// returned retorna a imagem do item pedida no RETURNING
+func (st *statement) returned(w write) []map[string]types.AttributeValue {
+	var item map[string]types.AttributeValue
+
+	switch st.returning {
+	case "ALL OLD":
+		item = copyItem(w.old)
+	case "ALL NEW":
+		item = copyItem(w.new)
+	case "MODIFIED OLD":
+		item = modified(w.old, w.new)
+	case "MODIFIED NEW":
+		item = modified(w.new, w.old)
+	}
+
+	if len(item) == 0 {
+		return []map[string]types.AttributeValue{}
+	}
+
+	return []map[string]types.AttributeValue{item}
+}
+
+// modified retorna os atributos de image que não existem ou são diferentes
+// em other
+func modified(image, other map[string]types.AttributeValue) map[string]types.AttributeValue {
+	changed := map[string]types.AttributeValue{}
+
+	for name, value := range image {
+		if current, ok := other[name]; !ok || !equal(current, value) {
+			changed[name] = value
+		}
+	}
+
+	return changed
+}
+
+// executeStatement executa uma instrução e retorna os itens e o token da
+// próxima página, vazio na última
+func executeStatement(query string, params []types.AttributeValue, limit int, nextToken string, resolve storeResolver) ([]map[string]types.AttributeValue, string, error) {
+	st, err := parseStatement(query, params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s, err := resolve(st.table)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if st.kind != "SELECT" {
+		items, err := s.executeWrite(st)
+		return items, "", err
+	}
+
+	p := page{limit: limit}
+	if nextToken != "" {
+		if p.exclusiveStartKey, err = decodeToken(nextToken); err != nil {
+			return nil, "", err
+		}
+	}
+
+	items, lastEvaluatedKey, err := s.selectItems(st, p)
+	if err != nil || lastEvaluatedKey == nil {
+		return items, "", err
+	}
+
+	token, err := encodeToken(lastEvaluatedKey)
+
+	return items, token, err
+}
+
+// executeBatch executa cada instrução de forma independente. Os erros de
+// cada instrução voltam no resultado
+func executeBatch(queries []string, params [][]types.AttributeValue, resolve storeResolver) ([]batchResult, error) {
+	if len(queries) == 0 || len(queries) > batchStatementLimit {
+		return nil, fmt.Errorf("%w: statements should have between 1 and %d items", ErrValidation, batchStatementLimit)
+	}
+
+	results := make([]batchResult, len(queries))
+
+	for i, query := range queries {
+		st, err := parseStatement(query, params[i])
+		if err != nil {
+			results[i].err = err
+			continue
+		}
+
+		results[i].table = st.table
+
+		s, err := resolve(st.table)
+		if err != nil {
+			results[i].err = err
+			continue
+		}
+
+		if st.kind == "SELECT" {
+			results[i].item, results[i].err = s.getItem(st)
+		} else {
+			_, results[i].err = s.executeWrite(st)
+		}
+	}
+
+	return results, nil
+}
+
+// executeTransaction executa instruções que são todas leituras ou todas
+// escritas. As escritas são aplicadas em todos os stores ou em nenhum
+func executeTransaction(queries []string, params [][]types.AttributeValue, resolve storeResolver) ([]map[string]types.AttributeValue, error) {
+	if len(queries) == 0 || len(queries) > transactStatementLimit {
+		return nil, fmt.Errorf("%w: transact statements should have between 1 and %d items", ErrValidation, transactStatementLimit)
+	}
+
+	statements := make([]*statement, len(queries))
+	stores := make([]*store, len(queries))
+	reads := 0
+
+	for i, query := range queries {
+		st, err := parseStatement(query, params[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if stores[i], err = resolve(st.table); err != nil {
+			return nil, err
+		}
+
+		if st.kind == "SELECT" {
+			reads++
+		}
+
+		statements[i] = st
+	}
+
+	if reads > 0 && reads < len(statements) {
+		return nil, fmt.Errorf("%w: transactions should have only reads or only writes", ErrValidation)
+	}
+
+	if reads > 0 {
+		items := make([]map[string]types.AttributeValue, len(statements))
+		for i, st := range statements {
+			item, err := stores[i].getItem(st)
+			if err != nil {
+				return nil, err
+			}
+
+			items[i] = item
+		}
+
+		return items, nil
+	}
+
+	// Bloqueia as tabelas sempre na mesma ordem para evitar deadlock
+	locked := map[string]*store{}
+	for i, st := range statements {
+		locked[st.table] = stores[i]
+	}
+
+	names := make([]string, 0, len(locked))
+	for name := range locked {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		locked[name].mu.Lock()
+		defer locked[name].mu.Unlock()
+	}
+
+	prepared := make([]write, len(statements))
+	reasons := make([]cancellationReason, len(statements))
+	touched := map[string]bool{}
+	cancelled := false
+
+	for i, st := range statements {
+		var err error
+		prepared[i], err = stores[i].prepareStatement(st)
+
+		reasons[i] = cancellationReason{Code: "None"}
+
+		switch {
+		case errors.Is(err, ErrConditionalCheckFailed):
+			reasons[i] = cancellationReason{Code: "ConditionalCheckFailed", Message: err.Error()}
+			cancelled = true
+			continue
+		case errors.Is(err, ErrDuplicateItem):
+			reasons[i] = cancellationReason{Code: "DuplicateItem", Message: err.Error()}
+			cancelled = true
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		id := st.table + "|" + prepared[i].id
+		if touched[id] {
+			return nil, fmt.Errorf("%w: transaction request cannot include multiple operations on one item", ErrValidation)
+		}
+		touched[id] = true
+	}
+
+	if cancelled {
+		return nil, transactionCanceled(reasons)
+	}
+
+	for i := range statements {
+		stores[i].commit(prepared[i])
+	}
+
+	return nil, nil
+}
+
+// transactionCanceled monta o TransactionCanceledException com os motivos
+// de cada item
+func transactionCanceled(reasons []cancellationReason) error {
+	codes := make([]string, len(reasons))
+	for i, reason := range reasons {
+		codes[i] = reason.Code
+	}
+
+	return &apiError{
+		code:    "TransactionCanceledException",
+		message: fmt.Sprintf("Transaction cancelled, please refer cancellation reasons for specific reasons [%s]", strings.Join(codes, ", ")),
+		reasons: reasons,
+	}
+}
+
+// batchErrorCode é o código de erro de uma instrução de um lote
+func batchErrorCode(err error) string {
+	var apiErr *apiError
+
+	switch {
+	case errors.Is(err, ErrConditionalCheckFailed):
+		return string(types.BatchStatementErrorCodeEnumConditionalCheckFailed)
+	case errors.Is(err, ErrDuplicateItem):
+		return string(types.BatchStatementErrorCodeEnumDuplicateItem)
+	case errors.Is(err, ErrValidation):
+		return string(types.BatchStatementErrorCodeEnumValidationError)
+	case errors.As(err, &apiErr) && apiErr.code == "ResourceNotFoundException":
+		return string(types.BatchStatementErrorCodeEnumResourceNotFound)
+	}
+
+	return string(types.BatchStatementErrorCodeEnumInternalServerError)
+}
+
+// encodeToken serializa a chave do último item avaliado no NextToken
+func encodeToken(key map[string]types.AttributeValue) (string, error) {
+	raw, err := json.Marshal(attributeValues(key))
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeToken lê a chave de um NextToken criado por encodeToken
+func decodeToken(token string) (map[string]types.AttributeValue, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid NextToken", ErrValidation)
+	}
+
+	var key attributeValues
+	if err = json.Unmarshal(raw, &key); err != nil {
+		return nil, fmt.Errorf("%w: invalid NextToken", ErrValidation)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,418 @@
+package inmemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+)
+
+// errorPrefix é o prefixo do __type dos erros do protocolo JSON do DynamoDB
+const errorPrefix = "com.amazonaws.dynamodb.v20120810#"
+
+type (
+	// attributeValues é um item no formato DynamoDB JSON do protocolo
+	attributeValues map[string]types.AttributeValue
+
+	// attributeList são os parâmetros posicionais de uma instrução PartiQL
+	attributeList []types.AttributeValue
+
+	// expressionAttributes são os placeholders de nomes e valores
+	// compartilhados pelas operações com expressões
+	expressionAttributes struct {
+		ExpressionAttributeNames  map[string]string
+		ExpressionAttributeValues attributeValues
+	}
+
+	keySchemaElement struct {
+		AttributeName string
+		KeyType       types.KeyType
+	}
+
+	attributeDefinition struct {
+		AttributeName string
+		AttributeType types.ScalarAttributeType
+	}
+
+	throughput struct {
+		ReadCapacityUnits  int64
+		WriteCapacityUnits int64
+	}
+
+	projection struct {
+		ProjectionType   string   `json:",omitempty"`
+		NonKeyAttributes []string `json:",omitempty"`
+	}
+
+	indexDescription struct {
+		IndexName             string
+		KeySchema             []keySchemaElement
+		Projection            *projection `json:",omitempty"`
+		ProvisionedThroughput *throughput `json:",omitempty"`
+		IndexStatus           string      `json:",omitempty"`
+		IndexArn              string      `json:",omitempty"`
+	}
+
+	billingModeSummary struct {
+		BillingMode string
+	}
+
+	tableClassSummary struct {
+		TableClass string
+	}
+
+	tableDescription struct {
+		TableName              string
+		TableArn               string
+		TableStatus            string
+		CreationDateTime       float64
+		ItemCount              int64
+		KeySchema              []keySchemaElement
+		AttributeDefinitions   []attributeDefinition
+		GlobalSecondaryIndexes []indexDescription  `json:",omitempty"`
+		LocalSecondaryIndexes  []indexDescription  `json:",omitempty"`
+		ProvisionedThroughput  *throughput         `json:",omitempty"`
+		BillingModeSummary     *billingModeSummary `json:",omitempty"`
+		TableClassSummary      *tableClassSummary  `json:",omitempty"`
+	}
+
+	/* Requisições */
+
+	createTableInput struct {
+		TableName              string
+		KeySchema              []keySchemaElement
+		AttributeDefinitions   []attributeDefinition
+		GlobalSecondaryIndexes []indexDescription
+		LocalSecondaryIndexes  []indexDescription
+		BillingMode            string
+		ProvisionedThroughput  *throughput
+		TableClass             string
+	}
+
+	tableNameInput struct {
+		TableName string
+	}
+
+	listTablesInput struct {
+		ExclusiveStartTableName string
+		Limit                   int
+	}
+
+	updateTableInput struct {
+		TableName                   string
+		AttributeDefinitions        []attributeDefinition
+		GlobalSecondaryIndexUpdates []struct {
+			Create *indexDescription
+			Delete *struct{ IndexName string }
+		}
+	}
+
+	getItemInput struct {
+		expressionAttributes
+		TableName            string
+		Key                  attributeValues
+		ProjectionExpression string
+	}
+
+	putItemInput struct {
+		expressionAttributes
+		TableName           string
+		Item                attributeValues
+		ConditionExpression string
+		ReturnValues        string
+	}
+
+	updateItemInput struct {
+		expressionAttributes
+		TableName           string
+		Key                 attributeValues
+		UpdateExpression    string
+		ConditionExpression string
+		ReturnValues        string
+	}
+
+	deleteItemInput struct {
+		expressionAttributes
+		TableName           string
+		Key                 attributeValues
+		ConditionExpression string
+		ReturnValues        string
+	}
+
+	queryRequest struct {
+		expressionAttributes
+		TableName              string
+		IndexName              string
+		KeyConditionExpression string
+		FilterExpression       string
+		ProjectionExpression   string
+		ScanIndexForward       *bool
+		Limit                  int
+		ExclusiveStartKey      attributeValues
+		Select                 string
+	}
+
+	scanRequest struct {
+		expressionAttributes
+		TableName            string
+		IndexName            string
+		FilterExpression     string
+		ProjectionExpression string
+		Limit                int
+		ExclusiveStartKey    attributeValues
+		Segment              int
+		TotalSegments        int
+		Select               string
+	}
+
+	batchWriteItemInput struct {
+		RequestItems map[string][]struct {
+			PutRequest    *struct{ Item attributeValues }
+			DeleteRequest *struct{ Key attributeValues }
+		}
+	}
+
+	transactOperation struct {
+		expressionAttributes
+		TableName           string
+		Key                 attributeValues
+		Item                attributeValues
+		UpdateExpression    string
+		ConditionExpression string
+	}
+
+	transactWriteItemsInput struct {
+		TransactItems []struct {
+			ConditionCheck *transactOperation
+			Put            *transactOperation
+			Update         *transactOperation
+			Delete         *transactOperation
+		}
+	}
+
+	statementRequest struct {
+		Statement      string
+		Parameters     attributeList
+		ConsistentRead bool
+	}
+
+	executeStatementInput struct {
+		statementRequest
+		Limit     int
+		NextToken string
+	}
+
+	batchExecuteStatementInput struct {
+		Statements []statementRequest
+	}
+
+	executeTransactionInput struct {
+		TransactStatements []statementRequest
+		ClientRequestToken string
+	}
+
+	/* Respostas */
+
+	tableDescriptionOutput struct {
+		TableDescription tableDescription
+	}
+
+	itemOutput struct {
+		Item attributeValues `json:",omitempty"`
+	}
+
+	attributesOutput struct {
+		Attributes attributeValues `json:",omitempty"`
+	}
+
+	itemsOutput struct {
+		Items            []attributeValues `json:",omitempty"`
+		Count            int
+		LastEvaluatedKey attributeValues `json:",omitempty"`
+	}
+
+	statementOutput struct {
+		Items     []attributeValues
+		NextToken string `json:",omitempty"`
+	}
+
+	batchStatementResponse struct {
+		TableName string               `json:",omitempty"`
+		Item      attributeValues      `json:",omitempty"`
+		Error     *batchStatementError `json:",omitempty"`
+	}
+
+	batchStatementError struct {
+		Code    string
+		Message string
+	}
+
+	transactionResponse struct {
+		Item attributeValues `json:",omitempty"`
+	}
+
+	cancellationReason struct {
+		Code    string
+		Message string `json:",omitempty"`
+	}
+
+	// apiError é um erro do protocolo, serializado com o __type que o SDK
+	// usa para montar o erro tipado. Ex: *types.ResourceNotFoundException
+	apiError struct {
+		code    string
+		message string
+		reasons []cancellationReason
+	}
+)
+
+func (a attributeValues) MarshalJSON() ([]byte, error) {
+	return json.Marshal(expressions.ItemToDynamoJSON(a))
+}
+
+func (a *attributeValues) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw == nil {
+		*a = nil
+		return nil
+	}
+
+	item := attributeValues{}
+	for name, value := range raw {
+		attr, err := expressions.DynamoJSONToAttributeValue(value)
+		if err != nil {
+			return fmt.Errorf("attribute %s: %v", name, err)
+		}
+
+		item[name] = attr
+	}
+
+	*a = item
+
+	return nil
+}
+
+func (a *attributeList) UnmarshalJSON(data []byte) error {
+	var raw []interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	list := make(attributeList, 0, len(raw))
+	for i, value := range raw {
+		attr, err := expressions.DynamoJSONToAttributeValue(value)
+		if err != nil {
+			return fmt.Errorf("parameter %d: %v", i, err)
+		}
+
+		list = append(list, attr)
+	}
+
+	*a = list
+
+	return nil
+}
+
+// statementParams retorna os parâmetros de cada instrução
+func statementParams(requests []statementRequest) ([]string, [][]types.AttributeValue) {
+	queries := make([]string, 0, len(requests))
+	params := make([][]types.AttributeValue, 0, len(requests))
+
+	for _, request := range requests {
+		queries = append(queries, request.Statement)
+		params = append(params, request.Parameters)
+	}
+
+	return queries, params
+}
+
+// evaluator cria o evaluator com os placeholders da requisição
+func (e expressionAttributes) evaluator() evaluator {
+	return evaluator{names: e.ExpressionAttributeNames, values: e.ExpressionAttributeValues}
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s: %s", e.code, e.message)
+}
+
+// MarshalJSON serializa o erro no formato do protocolo JSON do DynamoDB
+func (e *apiError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type                string               `json:"__type"`
+		Message             string               `json:"message"`
+		CancellationReasons []cancellationReason `json:",omitempty"`
+	}{errorPrefix + e.code, e.message, e.reasons})
+}
+
+// status é o status HTTP do erro
+func (e *apiError) status() int {
+	if e.code == "InternalServerError" {
+		return http.StatusInternalServerError
+	}
+
+	return http.StatusBadRequest
+}
+
+// toAPIError converte os erros do store nos erros do protocolo
+func toAPIError(err error) *apiError {
+	var e *apiError
+
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, ErrConditionalCheckFailed):
+		return &apiError{code: "ConditionalCheckFailedException", message: err.Error()}
+	case errors.Is(err, ErrDuplicateItem):
+		return &apiError{code: "DuplicateItemException", message: err.Error()}
+	case errors.Is(err, ErrValidation):
+		return &apiError{code: "ValidationException", message: strings.TrimPrefix(err.Error(), ErrValidation.Error()+": ")}
+	}
+
+	return &apiError{code: "InternalServerError", message: err.Error()}
+}
+
+// listOfItems converte os itens do store para a resposta
+func listOfItems(items []map[string]types.AttributeValue) []attributeValues {
+	list := make([]attributeValues, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+
+	return list
+}
+
+// projectItem mantém apenas os atributos de uma ProjectionExpression.
+// Caminhos aninhados projetam o atributo de primeiro nível inteiro
+func projectItem(item map[string]types.AttributeValue, expression string, names map[string]string) (map[string]types.AttributeValue, error) {
+	if expression == "" || item == nil {
+		return item, nil
+	}
+
+	eval := evaluator{names: names}
+	projected := map[string]types.AttributeValue{}
+
+	for _, path := range strings.Split(expression, ",") {
+		path = strings.TrimSpace(path)
+		if i := strings.IndexAny(path, ".["); i >= 0 {
+			path = path[:i]
+		}
+
+		name, err := eval.attributeName(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if value, ok := item[name]; ok {
+			projected[name] = value
+		}
+	}
+
+	return projected, nil
+}
@@ -0,0 +1,897 @@
+package inmemory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	// targetPrefix é o prefixo do header X-Amz-Target das operações
+	targetPrefix = "DynamoDB_20120810."
+	// transactWriteLimit é o máximo de itens de um TransactWriteItems
+	transactWriteLimit = 100
+)
+
+type (
+	// Server é um servidor HTTP em processo compatível com o subconjunto
+	// do protocolo JSON do DynamoDB usado por este pacote: CreateTable,
+	// DescribeTable, ListTables, UpdateTable, DeleteTable, GetItem,
+	// PutItem, UpdateItem, DeleteItem, Query, Scan, BatchWriteItem,
+	// TransactWriteItems e o subconjunto de PartiQL de ExecuteStatement,
+	// BatchExecuteStatement e ExecuteTransaction.
+	//
+	// Substitui o DynamoDB Local nos testes de integração. Use NewClient
+	// para um *dynamodb.Client apontando para o servidor
+	Server struct {
+		// URL é o endpoint do servidor. Ex: http://127.0.0.1:51234
+		URL string
+
+		mu     sync.RWMutex
+		tables map[string]*serverTable
+		server *httptest.Server
+	}
+
+	serverTable struct {
+		description tableDescription
+		store       *store
+	}
+
+	operation func(s *Server, body []byte) (interface{}, error)
+)
+
+// operations são as operações suportadas, pelo nome do X-Amz-Target
+var operations = map[string]operation{
+	"CreateTable":        (*Server).createTable,
+	"DescribeTable":      (*Server).describeTable,
+	"ListTables":         (*Server).listTables,
+	"UpdateTable":        (*Server).updateTable,
+	"DeleteTable":        (*Server).deleteTable,
+	"GetItem":            (*Server).getItem,
+	"PutItem":            (*Server).putItem,
+	"UpdateItem":         (*Server).updateItem,
+	"DeleteItem":         (*Server).deleteItem,
+	"Query":              (*Server).query,
+	"Scan":               (*Server).scan,
+	"BatchWriteItem":     (*Server).batchWriteItem,
+	"TransactWriteItems": (*Server).transactWriteItems,
+
+	"ExecuteStatement":      (*Server).executeStatement,
+	"BatchExecuteStatement": (*Server).batchExecuteStatement,
+	"ExecuteTransaction":    (*Server).executeTransaction,
+}
+
+// NewServer inicia um Server vazio em uma porta local. Chame Close ao
+// final do teste
+func NewServer() *Server {
+	s := &Server{tables: map[string]*serverTable{}}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close encerra o servidor
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// NewClient cria um *dynamodb.Client apontando para o servidor, com
+// credenciais estáticas e sem retentativas
+func (s *Server) NewClient() *dynamodb.Client {
+	return dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("local", "local", ""),
+		EndpointResolver: dynamodb.EndpointResolverFromURL(s.URL),
+		Retryer:          aws.NopRetryer{},
+	})
+}
+
+// ServeHTTP despacha a requisição para a operação do X-Amz-Target
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix)
+
+	op, ok := operations[name]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, &apiError{
+			code:    "UnknownOperationException",
+			message: fmt.Sprintf("operation %q is not supported", name),
+		})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &apiError{code: "SerializationException", message: err.Error()})
+		return
+	}
+
+	out, err := op(s, body)
+	if err != nil {
+		apiErr := toAPIError(err)
+		writeJSON(w, apiErr.status(), apiErr)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// decode lê o corpo da requisição
+func decode(body []byte, input interface{}) error {
+	if err := json.Unmarshal(body, input); err != nil {
+		return &apiError{code: "SerializationException", message: err.Error()}
+	}
+
+	return nil
+}
+
+// table retorna a tabela ou ResourceNotFoundException
+func (s *Server) table(name string) (*serverTable, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tb, ok := s.tables[name]
+	if !ok {
+		return nil, tableNotFound(name)
+	}
+
+	return tb, nil
+}
+
+// tableNotFound é o ResourceNotFoundException de uma tabela
+func tableNotFound(name string) error {
+	return &apiError{
+		code:    "ResourceNotFoundException",
+		message: fmt.Sprintf("Requested resource not found: Table: %s not found", name),
+	}
+}
+
+// store retorna o store da tabela, usado nas instruções PartiQL
+func (s *Server) store(name string) (*store, error) {
+	tb, err := s.table(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return tb.store, nil
+}
+
+// describe retorna a descrição da tabela com a contagem de itens atual
+func (t *serverTable) describe() tableDescription {
+	description := t.description
+	description.ItemCount = int64(t.store.len())
+
+	return description
+}
+
+/* Tabelas */
+
+func (s *Server) createTable(body []byte) (interface{}, error) {
+	var input createTableInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	if err := validateCreateTable(input); err != nil {
+		return nil, err
+	}
+
+	arn := fmt.Sprintf("arn:aws:dynamodb:us-east-1:000000000000:table/%s", input.TableName)
+	description := tableDescription{
+		TableName:            input.TableName,
+		TableArn:             arn,
+		TableStatus:          string(types.TableStatusActive),
+		CreationDateTime:     float64(time.Now().Unix()),
+		KeySchema:            input.KeySchema,
+		AttributeDefinitions: input.AttributeDefinitions,
+		BillingModeSummary:   &billingModeSummary{BillingMode: string(types.BillingModeProvisioned)},
+	}
+
+	if input.BillingMode != "" {
+		description.BillingModeSummary.BillingMode = input.BillingMode
+	}
+
+	if input.BillingMode != string(types.BillingModePayPerRequest) {
+		description.ProvisionedThroughput = input.ProvisionedThroughput
+	}
+
+	if input.TableClass != "" {
+		description.TableClassSummary = &tableClassSummary{TableClass: input.TableClass}
+	}
+
+	indexes := map[string]keySchema{}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		gsi.IndexStatus = string(types.IndexStatusActive)
+		gsi.IndexArn = arn + "/index/" + gsi.IndexName
+		description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, gsi)
+		indexes[gsi.IndexName] = protocolSchema(gsi.KeySchema)
+	}
+
+	for _, lsi := range input.LocalSecondaryIndexes {
+		lsi.IndexArn = arn + "/index/" + lsi.IndexName
+		description.LocalSecondaryIndexes = append(description.LocalSecondaryIndexes, lsi)
+		indexes[lsi.IndexName] = protocolSchema(lsi.KeySchema)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tables[input.TableName]; ok {
+		return nil, &apiError{
+			code:    "ResourceInUseException",
+			message: fmt.Sprintf("Table already exists: %s", input.TableName),
+		}
+	}
+
+	s.tables[input.TableName] = &serverTable{
+		description: description,
+		store:       newStore(protocolSchema(input.KeySchema), indexes),
+	}
+
+	return tableDescriptionOutput{TableDescription: description}, nil
+}
+
+// validateCreateTable aplica as validações do DynamoDB sobre as chaves e
+// as definições de atributos
+func validateCreateTable(input createTableInput) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &apiError{code: "ValidationException", message: fmt.Sprintf(format, args...)}
+	}
+
+	if input.TableName == "" {
+		return invalid("TableName is required")
+	}
+
+	if protocolSchema(input.KeySchema).hash == "" {
+		return invalid("KeySchema should have a HASH key")
+	}
+
+	if err := validateBilling(input); err != nil {
+		return err
+	}
+
+	defined := map[string]bool{}
+	for _, definition := range input.AttributeDefinitions {
+		defined[definition.AttributeName] = true
+	}
+
+	used := map[string]bool{}
+	schemas := [][]keySchemaElement{input.KeySchema}
+	for _, index := range append(input.GlobalSecondaryIndexes, input.LocalSecondaryIndexes...) {
+		schemas = append(schemas, index.KeySchema)
+	}
+
+	for _, schema := range schemas {
+		for _, element := range schema {
+			if !defined[element.AttributeName] {
+				return invalid("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s]", element.AttributeName)
+			}
+
+			used[element.AttributeName] = true
+		}
+	}
+
+	if len(used) != len(defined) {
+		return invalid("One or more parameter values were invalid: Number of attributes in KeySchema does not exactly match number of attributes defined in AttributeDefinitions")
+	}
+
+	return nil
+}
+
+// validateBilling verifica o ProvisionedThroughput da tabela e dos GSI de
+// acordo com o BillingMode, como o DynamoDB
+func validateBilling(input createTableInput) error {
+	invalid := func(format string, args ...interface{}) error {
+		return &apiError{code: "ValidationException", message: "One or more parameter values were invalid: " + fmt.Sprintf(format, args...)}
+	}
+
+	if input.BillingMode == string(types.BillingModePayPerRequest) {
+		if input.ProvisionedThroughput != nil {
+			return invalid("Neither ReadCapacityUnits nor WriteCapacityUnits can be specified when BillingMode is PAY_PER_REQUEST")
+		}
+
+		for _, gsi := range input.GlobalSecondaryIndexes {
+			if gsi.ProvisionedThroughput != nil {
+				return invalid("ProvisionedThroughput should not be specified for index: %s when BillingMode is PAY_PER_REQUEST", gsi.IndexName)
+			}
+		}
+
+		return nil
+	}
+
+	if input.ProvisionedThroughput == nil {
+		return invalid("ReadCapacityUnits and WriteCapacityUnits must both be specified when BillingMode is PROVISIONED")
+	}
+
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		if gsi.ProvisionedThroughput == nil {
+			return invalid("ProvisionedThroughput must be specified for index: %s", gsi.IndexName)
+		}
+	}
+
+	return nil
+}
+
+// protocolSchema extrai hash e range de um KeySchema do protocolo
+func protocolSchema(elements []keySchemaElement) keySchema {
+	var schema keySchema
+
+	for _, element := range elements {
+		switch element.KeyType {
+		case types.KeyTypeHash:
+			schema.hash = element.AttributeName
+		case types.KeyTypeRange:
+			schema.rangeName = element.AttributeName
+		}
+	}
+
+	return schema
+}
+
+func (s *Server) describeTable(body []byte) (interface{}, error) {
+	var input tableNameInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return struct{ Table tableDescription }{tb.describe()}, nil
+}
+
+func (s *Server) listTables(body []byte) (interface{}, error) {
+	var input listTablesInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := []string{}
+	for name := range s.tables {
+		if name > input.ExclusiveStartTableName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	out := struct {
+		TableNames             []string
+		LastEvaluatedTableName string `json:",omitempty"`
+	}{TableNames: names}
+
+	if input.Limit > 0 && len(names) > input.Limit {
+		out.TableNames = names[:input.Limit]
+		out.LastEvaluatedTableName = names[input.Limit-1]
+	}
+
+	return out, nil
+}
+
+// updateTable aplica apenas as alterações de Global Secondary Index. Os
+// índices criados ficam ativos na hora
+func (s *Server) updateTable(body []byte) (interface{}, error) {
+	var input updateTableInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	description := &tb.description
+	for _, definition := range input.AttributeDefinitions {
+		if !hasDefinition(description.AttributeDefinitions, definition.AttributeName) {
+			description.AttributeDefinitions = append(description.AttributeDefinitions, definition)
+		}
+	}
+
+	for _, update := range input.GlobalSecondaryIndexUpdates {
+		switch {
+		case update.Create != nil:
+			gsi := *update.Create
+			gsi.IndexStatus = string(types.IndexStatusActive)
+			gsi.IndexArn = description.TableArn + "/index/" + gsi.IndexName
+
+			description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, gsi)
+			tb.store.setIndex(gsi.IndexName, protocolSchema(gsi.KeySchema))
+		case update.Delete != nil:
+			var kept []indexDescription
+			for _, gsi := range description.GlobalSecondaryIndexes {
+				if gsi.IndexName != update.Delete.IndexName {
+					kept = append(kept, gsi)
+				}
+			}
+
+			if len(kept) == len(description.GlobalSecondaryIndexes) {
+				return nil, &apiError{
+					code:    "ResourceNotFoundException",
+					message: fmt.Sprintf("Requested resource not found: Index: %s not found", update.Delete.IndexName),
+				}
+			}
+
+			description.GlobalSecondaryIndexes = kept
+			tb.store.setIndex(update.Delete.IndexName, keySchema{})
+		}
+	}
+
+	return tableDescriptionOutput{TableDescription: tb.describe()}, nil
+}
+
+func hasDefinition(definitions []attributeDefinition, name string) bool {
+	for _, definition := range definitions {
+		if definition.AttributeName == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) deleteTable(body []byte) (interface{}, error) {
+	var input tableNameInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tables, input.TableName)
+
+	description := tb.describe()
+	description.TableStatus = string(types.TableStatusDeleting)
+
+	return tableDescriptionOutput{TableDescription: description}, nil
+}
+
+/* Itens */
+
+func (s *Server) getItem(body []byte) (interface{}, error) {
+	var input getItemInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := tb.store.get(input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err = projectItem(item, input.ProjectionExpression, input.ExpressionAttributeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return itemOutput{Item: item}, nil
+}
+
+func (s *Server) putItem(body []byte) (interface{}, error) {
+	var input putItemInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := tb.store.put(input.Item, input.ConditionExpression, input.evaluator())
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ReturnValues == string(types.ReturnValueAllOld) {
+		return attributesOutput{Attributes: old}, nil
+	}
+
+	return attributesOutput{}, nil
+}
+
+func (s *Server) updateItem(body []byte) (interface{}, error) {
+	var input updateItemInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	old, updated, err := tb.store.update(input.Key, nil, input.UpdateExpression, input.ConditionExpression, input.evaluator())
+	if err != nil {
+		return nil, err
+	}
+
+	switch types.ReturnValue(input.ReturnValues) {
+	case types.ReturnValueAllNew, types.ReturnValueUpdatedNew:
+		return attributesOutput{Attributes: updated}, nil
+	case types.ReturnValueAllOld, types.ReturnValueUpdatedOld:
+		return attributesOutput{Attributes: old}, nil
+	}
+
+	return attributesOutput{}, nil
+}
+
+func (s *Server) deleteItem(body []byte) (interface{}, error) {
+	var input deleteItemInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	old, err := tb.store.delete(input.Key, input.ConditionExpression, input.evaluator())
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ReturnValues == string(types.ReturnValueAllOld) {
+		return attributesOutput{Attributes: old}, nil
+	}
+
+	return attributesOutput{}, nil
+}
+
+/* Leituras */
+
+func (s *Server) query(body []byte) (interface{}, error) {
+	var input queryRequest
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	forward := input.ScanIndexForward == nil || *input.ScanIndexForward
+
+	items, lastEvaluatedKey, err := tb.store.query(queryInput{
+		page:             page{limit: input.Limit, exclusiveStartKey: input.ExclusiveStartKey},
+		indexName:        input.IndexName,
+		keyCondition:     input.KeyConditionExpression,
+		filter:           input.FilterExpression,
+		names:            input.ExpressionAttributeNames,
+		values:           input.ExpressionAttributeValues,
+		scanIndexForward: forward,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return itemsResponse(items, lastEvaluatedKey, input.Select, input.ProjectionExpression, input.ExpressionAttributeNames)
+}
+
+func (s *Server) scan(body []byte) (interface{}, error) {
+	var input scanRequest
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	tb, err := s.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	items, lastEvaluatedKey, err := tb.store.scan(scanInput{
+		page:          page{limit: input.Limit, exclusiveStartKey: input.ExclusiveStartKey},
+		indexName:     input.IndexName,
+		filter:        input.FilterExpression,
+		names:         input.ExpressionAttributeNames,
+		values:        input.ExpressionAttributeValues,
+		segment:       input.Segment,
+		totalSegments: input.TotalSegments,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return itemsResponse(items, lastEvaluatedKey, input.Select, input.ProjectionExpression, input.ExpressionAttributeNames)
+}
+
+// itemsResponse monta a resposta de Query e Scan
+func itemsResponse(items []map[string]types.AttributeValue, lastEvaluatedKey map[string]types.AttributeValue, selectMode, projectionExpression string, names map[string]string) (interface{}, error) {
+	out := itemsOutput{Count: len(items), LastEvaluatedKey: lastEvaluatedKey}
+
+	if selectMode == string(types.SelectCount) {
+		return out, nil
+	}
+
+	for i, item := range items {
+		projected, err := projectItem(item, projectionExpression, names)
+		if err != nil {
+			return nil, err
+		}
+
+		items[i] = projected
+	}
+	out.Items = listOfItems(items)
+
+	return out, nil
+}
+
+/* Escritas em lote */
+
+// batchWriteItem grava todos os itens. Nunca há UnprocessedItems
+func (s *Server) batchWriteItem(body []byte) (interface{}, error) {
+	var input batchWriteItemInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for tableName, requests := range input.RequestItems {
+		if _, err := s.table(tableName); err != nil {
+			return nil, err
+		}
+
+		total += len(requests)
+	}
+
+	if total > 25 {
+		return nil, &apiError{
+			code:    "ValidationException",
+			message: "Too many items requested for the BatchWriteItem call",
+		}
+	}
+
+	for tableName, requests := range input.RequestItems {
+		tb, _ := s.table(tableName)
+
+		for _, request := range requests {
+			var err error
+
+			switch {
+			case request.PutRequest != nil:
+				_, err = tb.store.put(request.PutRequest.Item, "", evaluator{})
+			case request.DeleteRequest != nil:
+				_, err = tb.store.delete(request.DeleteRequest.Key, "", evaluator{})
+			}
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return struct{ UnprocessedItems map[string]interface{} }{map[string]interface{}{}}, nil
+}
+
+// transactWriteItems aplica todas as escritas ou nenhuma. As tabelas
+// envolvidas ficam bloqueadas durante a transação
+func (s *Server) transactWriteItems(body []byte) (interface{}, error) {
+	var input transactWriteItemsInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > transactWriteLimit {
+		return nil, &apiError{
+			code:    "ValidationException",
+			message: fmt.Sprintf("TransactItems should have between 1 and %d items", transactWriteLimit),
+		}
+	}
+
+	type transactWrite struct {
+		kind  string
+		op    *transactOperation
+		store *store
+	}
+
+	var writes []transactWrite
+	stores := map[string]*store{}
+
+	for _, item := range input.TransactItems {
+		w := transactWrite{}
+
+		switch {
+		case item.ConditionCheck != nil:
+			w.kind, w.op = "ConditionCheck", item.ConditionCheck
+		case item.Put != nil:
+			w.kind, w.op = "Put", item.Put
+		case item.Update != nil:
+			w.kind, w.op = "Update", item.Update
+		case item.Delete != nil:
+			w.kind, w.op = "Delete", item.Delete
+		default:
+			return nil, &apiError{code: "ValidationException", message: "TransactItem should have exactly one operation"}
+		}
+
+		tb, err := s.table(w.op.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		w.store = tb.store
+		stores[w.op.TableName] = tb.store
+		writes = append(writes, w)
+	}
+
+	// Bloqueia as tabelas sempre na mesma ordem para evitar deadlock
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		stores[name].mu.Lock()
+		defer stores[name].mu.Unlock()
+	}
+
+	prepared := make([]write, len(writes))
+	reasons := make([]cancellationReason, len(writes))
+	touched := map[string]bool{}
+	cancelled := false
+
+	for i, w := range writes {
+		var err error
+		eval := w.op.evaluator()
+
+		switch w.kind {
+		case "ConditionCheck":
+			prepared[i], err = w.store.prepareCheck(w.op.Key, w.op.ConditionExpression, eval)
+		case "Put":
+			prepared[i], err = w.store.preparePut(w.op.Item, w.op.ConditionExpression, eval)
+		case "Update":
+			prepared[i], err = w.store.prepareUpdate(w.op.Key, nil, w.op.UpdateExpression, w.op.ConditionExpression, eval)
+		case "Delete":
+			prepared[i], err = w.store.prepareDelete(w.op.Key, w.op.ConditionExpression, eval)
+		}
+
+		reasons[i] = cancellationReason{Code: "None"}
+
+		switch {
+		case errors.Is(err, ErrConditionalCheckFailed):
+			reasons[i] = cancellationReason{Code: "ConditionalCheckFailed", Message: err.Error()}
+			cancelled = true
+			continue
+		case err != nil:
+			return nil, err
+		}
+
+		id := w.op.TableName + "|" + prepared[i].id
+		if touched[id] {
+			return nil, &apiError{
+				code:    "ValidationException",
+				message: "Transaction request cannot include multiple operations on one item",
+			}
+		}
+		touched[id] = true
+	}
+
+	if cancelled {
+		codes := make([]string, len(reasons))
+		for i, reason := range reasons {
+			codes[i] = reason.Code
+		}
+
+		return nil, &apiError{
+			code:    "TransactionCanceledException",
+			message: fmt.Sprintf("Transaction cancelled, please refer cancellation reasons for specific reasons [%s]", strings.Join(codes, ", ")),
+			reasons: reasons,
+		}
+	}
+
+	for i, w := range writes {
+		w.store.commit(prepared[i])
+	}
+
+	return struct{}{}, nil
+}
+
+/* PartiQL */
+
+func (s *Server) executeStatement(body []byte) (interface{}, error) {
+	var input executeStatementInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	items, nextToken, err := executeStatement(input.Statement, input.Parameters, input.Limit, input.NextToken, s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	return statementOutput{Items: listOfItems(items), NextToken: nextToken}, nil
+}
+
+// batchExecuteStatement executa cada instrução de forma independente. Os
+// erros de cada instrução voltam na resposta dela
+func (s *Server) batchExecuteStatement(body []byte) (interface{}, error) {
+	var input batchExecuteStatementInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	queries, params := statementParams(input.Statements)
+
+	results, err := executeBatch(queries, params, s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]batchStatementResponse, 0, len(results))
+	for _, result := range results {
+		response := batchStatementResponse{TableName: result.table, Item: result.item}
+		if result.err != nil {
+			response.Error = &batchStatementError{Code: batchErrorCode(result.err), Message: toAPIError(result.err).message}
+		}
+
+		responses = append(responses, response)
+	}
+
+	return struct{ Responses []batchStatementResponse }{responses}, nil
+}
+
+// executeTransaction executa todas as instruções ou nenhuma
+func (s *Server) executeTransaction(body []byte) (interface{}, error) {
+	var input executeTransactionInput
+	if err := decode(body, &input); err != nil {
+		return nil, err
+	}
+
+	queries, params := statementParams(input.TransactStatements)
+
+	items, err := executeTransaction(queries, params, s.store)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]transactionResponse, 0, len(items))
+	for _, item := range items {
+		responses = append(responses, transactionResponse{Item: item})
+	}
+
+	return struct {
+		Responses []transactionResponse `json:",omitempty"`
+	}{responses}, nil
+}
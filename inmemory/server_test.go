@@ -0,0 +1,181 @@
+package inmemory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+func newServerClient(t *testing.T) (*inmemory.Server, *drivers.DynamoClient) {
+	server := inmemory.NewServer()
+	t.Cleanup(server.Close)
+
+	client := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "lessons",
+		Environment: "testing",
+		Client:      server.NewClient(),
+		Table:       table.MustNewTable("lessons", lesson{}),
+	})
+
+	return server, client
+}
+
+func TestServer_DynamoClient(t *testing.T) {
+	_, d := newServerClient(t)
+
+	t.Run("should migrate, seed and query the table", func(t *testing.T) {
+		assert.Nil(t, d.Migrate())
+		assert.Nil(t, d.Migrate())
+
+		assert.Nil(t, d.Seed(
+			lesson{PK: "COURSE#1", SK: "LESSON#01", Owner: "jane", Position: 2, Title: "Intro"},
+			lesson{PK: "COURSE#1", SK: "LESSON#02", Owner: "john", Position: 1, Title: "Setup"},
+			lesson{PK: "COURSE#2", SK: "LESSON#01", Owner: "jane", Position: 1, Title: "Other"},
+		))
+
+		var result []lesson
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Between("LESSON#01", "LESSON#02"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Equal(t, []string{"Intro", "Setup"}, titles(result))
+
+		sql = d.NewExpressionBuilder().
+			SetIndex("OwnerIndex").
+			Where(expressions.NewKeyCondition("Owner", "jane"))
+
+		assert.Nil(t, d.Perform(drivers.QUERY, sql, &result))
+		assert.Len(t, result, 2)
+	})
+	t.Run("should update and get an item", func(t *testing.T) {
+		var updated, found lesson
+		sql := d.NewExpressionBuilder().
+			Where(expressions.NewKeyCondition("PK", "COURSE#1")).
+			AndWhere(expressions.NewSortKeyCondition("SK").Equal("LESSON#02"))
+
+		sql.Update(expressions.NewKeyCondition("Title", "Installation"))
+		assert.Nil(t, d.Perform(drivers.UPDATE, sql, &updated))
+		assert.Equal(t, "Installation", updated.Title)
+
+		assert.Nil(t, d.Perform(drivers.GET, sql, &found))
+		assert.Equal(t, updated, found)
+	})
+	t.Run("should truncate and drop the table", func(t *testing.T) {
+		deleted, err := d.Truncate()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), deleted)
+
+		assert.Nil(t, d.DropTable())
+
+		_, err = d.Client.DescribeTable(context.Background(), &dynamodb.DescribeTableInput{TableName: d.TableName})
+		var notFound *types.ResourceNotFoundException
+		assert.True(t, errors.As(err, &notFound))
+	})
+}
+
+func TestServer_Protocol(t *testing.T) {
+	_, d := newServerClient(t)
+	ctx := context.Background()
+	assert.Nil(t, d.CreateTable())
+
+	key := func(pk, sk string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: pk},
+			"SK": &types.AttributeValueMemberS{Value: sk},
+		}
+	}
+
+	t.Run("should refuse a failed condition expression", func(t *testing.T) {
+		item := key("COURSE#1", "LESSON#01")
+		item["Title"] = &types.AttributeValueMemberS{Value: "Intro"}
+
+		input := &dynamodb.PutItemInput{
+			TableName:           d.TableName,
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(PK)"),
+		}
+
+		_, err := d.Client.PutItem(ctx, input)
+		assert.Nil(t, err)
+
+		_, err = d.Client.PutItem(ctx, input)
+		var failed *types.ConditionalCheckFailedException
+		assert.True(t, errors.As(err, &failed))
+	})
+	t.Run("should cancel the whole transaction", func(t *testing.T) {
+		_, err := d.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{TableName: d.TableName, Item: key("COURSE#1", "LESSON#02")}},
+				{ConditionCheck: &types.ConditionCheck{
+					TableName:           d.TableName,
+					Key:                 key("COURSE#1", "LESSON#01"),
+					ConditionExpression: aws.String("Title = :title"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":title": &types.AttributeValueMemberS{Value: "Other"},
+					},
+				}},
+			},
+		})
+
+		var cancelled *types.TransactionCanceledException
+		assert.True(t, errors.As(err, &cancelled))
+		assert.Equal(t, "ConditionalCheckFailed", *cancelled.CancellationReasons[1].Code)
+
+		out, err := d.Client.GetItem(ctx, &dynamodb.GetItemInput{TableName: d.TableName, Key: key("COURSE#1", "LESSON#02")})
+		assert.Nil(t, err)
+		assert.Nil(t, out.Item)
+	})
+	t.Run("should paginate queries with Limit", func(t *testing.T) {
+		for _, sk := range []string{"LESSON#02", "LESSON#03", "LESSON#04"} {
+			_, err := d.Client.PutItem(ctx, &dynamodb.PutItemInput{TableName: d.TableName, Item: key("COURSE#1", sk)})
+			assert.Nil(t, err)
+		}
+
+		p := dynamodb.NewQueryPaginator(d.Client, &dynamodb.QueryInput{
+			TableName:              d.TableName,
+			KeyConditionExpression: aws.String("PK = :pk"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "COURSE#1"},
+			},
+			ScanIndexForward: aws.Bool(false),
+			Limit:            aws.Int32(3),
+		})
+
+		var keys []string
+		pages := 0
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			assert.Nil(t, err)
+
+			for _, item := range page.Items {
+				keys = append(keys, item["SK"].(*types.AttributeValueMemberS).Value)
+			}
+			pages++
+		}
+
+		assert.Equal(t, 2, pages)
+		assert.Equal(t, []string{"LESSON#04", "LESSON#03", "LESSON#02", "LESSON#01"}, keys)
+	})
+	t.Run("should list and describe tables", func(t *testing.T) {
+		tables, err := d.Client.ListTables(ctx, &dynamodb.ListTablesInput{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"lessons"}, tables.TableNames)
+
+		out, err := d.Client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: d.TableName})
+		assert.Nil(t, err)
+		assert.Equal(t, types.TableStatusActive, out.Table.TableStatus)
+		assert.Equal(t, int64(4), out.Table.ItemCount)
+		assert.Len(t, out.Table.GlobalSecondaryIndexes, 1)
+	})
+}
@@ -3,7 +3,6 @@
 package mocks
 
 import (
-	dynamodb "github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	domain "github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
 
 	mock "github.com/stretchr/testify/mock"
@@ -58,18 +57,40 @@ func (_m *Dynamo) Perform(action domain.Action, sql domain.SqlExpression, result
 	return r0
 }
 
-// Seed provides a mock function with given fields: items
-func (_m *Dynamo) Seed(items ...*dynamodb.PutItemInput) error {
-	_va := make([]interface{}, len(items))
-	for _i := range items {
-		_va[_i] = items[_i]
+// Prepare provides a mock function with given fields: query, args
+func (_m *Dynamo) Prepare(query string, args ...interface{}) (domain.SqlExpression, error) {
+	var _ca []interface{}
+	_ca = append(_ca, query)
+	_ca = append(_ca, args...)
+	ret := _m.Called(_ca...)
+
+	var r0 domain.SqlExpression
+	if rf, ok := ret.Get(0).(func(string, ...interface{}) domain.SqlExpression); ok {
+		r0 = rf(query, args...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.SqlExpression)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, ...interface{}) error); ok {
+		r1 = rf(query, args...)
+	} else {
+		r1 = ret.Error(1)
 	}
+
+	return r0, r1
+}
+
+// Seed provides a mock function with given fields: items
+func (_m *Dynamo) Seed(items ...interface{}) error {
 	var _ca []interface{}
-	_ca = append(_ca, _va...)
+	_ca = append(_ca, items...)
 	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(...*dynamodb.PutItemInput) error); ok {
+	if rf, ok := ret.Get(0).(func(...interface{}) error); ok {
 		r0 = rf(items...)
 	} else {
 		r0 = ret.Error(0)
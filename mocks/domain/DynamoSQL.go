@@ -12,6 +12,20 @@ type DynamoSQL struct {
 	mock.Mock
 }
 
+// BatchExecute provides a mock function with given fields: statements, target
+func (_m *DynamoSQL) BatchExecute(statements []domain.Statement, target interface{}) error {
+	ret := _m.Called(statements, target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]domain.Statement, interface{}) error); ok {
+		r0 = rf(statements, target)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: expression
 func (_m *DynamoSQL) Delete(expression domain.SqlExpression) error {
 	ret := _m.Called(expression)
@@ -26,6 +40,43 @@ func (_m *DynamoSQL) Delete(expression domain.SqlExpression) error {
 	return r0
 }
 
+// Execute provides a mock function with given fields: statement, target
+func (_m *DynamoSQL) Execute(statement domain.Statement, target interface{}) (*string, error) {
+	ret := _m.Called(statement, target)
+
+	var r0 *string
+	if rf, ok := ret.Get(0).(func(domain.Statement, interface{}) *string); ok {
+		r0 = rf(statement, target)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(domain.Statement, interface{}) error); ok {
+		r1 = rf(statement, target)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecuteTransaction provides a mock function with given fields: statements, target
+func (_m *DynamoSQL) ExecuteTransaction(statements []domain.Statement, target interface{}) error {
+	ret := _m.Called(statements, target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]domain.Statement, interface{}) error); ok {
+		r0 = rf(statements, target)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Get provides a mock function with given fields: expression, target
 func (_m *DynamoSQL) Get(expression domain.SqlExpression, target interface{}) error {
 	ret := _m.Called(expression, target)
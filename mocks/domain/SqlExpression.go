@@ -46,6 +46,54 @@ func (_m *SqlExpression) Build() map[string]types.AttributeValue {
 	return r0
 }
 
+// Filter provides a mock function with given fields: condition
+func (_m *SqlExpression) Filter(condition domain.WithCondition) domain.SqlExpression {
+	ret := _m.Called(condition)
+
+	var r0 domain.SqlExpression
+	if rf, ok := ret.Get(0).(func(domain.WithCondition) domain.SqlExpression); ok {
+		r0 = rf(condition)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(domain.SqlExpression)
+		}
+	}
+
+	return r0
+}
+
+// FilterExpression provides a mock function with given fields:
+func (_m *SqlExpression) FilterExpression() *string {
+	ret := _m.Called()
+
+	var r0 *string
+	if rf, ok := ret.Get(0).(func() *string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*string)
+		}
+	}
+
+	return r0
+}
+
+// Item provides a mock function with given fields:
+func (_m *SqlExpression) Item() interface{} {
+	ret := _m.Called()
+
+	var r0 interface{}
+	if rf, ok := ret.Get(0).(func() interface{}); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	return r0
+}
+
 // Key provides a mock function with given fields:
 func (_m *SqlExpression) Key() map[string]types.AttributeValue {
 	ret := _m.Called()
@@ -94,6 +142,20 @@ func (_m *SqlExpression) Names() map[string]types.AttributeValue {
 	return r0
 }
 
+// ResolveIndex provides a mock function with given fields:
+func (_m *SqlExpression) ResolveIndex() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SetIndex provides a mock function with given fields: indexName
 func (_m *SqlExpression) SetIndex(indexName string) domain.SqlExpression {
 	ret := _m.Called(indexName)
@@ -20,7 +20,7 @@ type Mocktable struct {
 	Owner        string `diinamo:"type:string;gsi:CourseOwnerIndex;keyPairs:PK=Owner"`
 	Title        string `diinamo:"type:string;gsi:CourseTitleIndex;keyPairs:Title=SK"`
 	ParentCourse string `diinamo:"type:string;gsi:CourseLessonsIndex;keyPairs:ParentCourse=SK"`
-	ParentModule string `diinamo:"type:string;lsi:ModuleLessonsIndex;keyPairs:ParentModule=SK"`
+	ParentModule string `diinamo:"type:string;lsi:ModuleLessonsIndex;keyPairs:PK=ParentModule"`
 }
 
 // AttributeDefinitions provides a mock function with given fields:
@@ -146,3 +146,17 @@ func (_m *Table) TableClass() types.TableClass {
 
 	return r0
 }
+
+// Validate provides a mock function with given fields:
+func (_m *Table) Validate() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -14,6 +14,27 @@ type WithCondition struct {
 	mock.Mock
 }
 
+// KeyCondition provides a mock function with given fields: placeholders
+func (_m *WithCondition) KeyCondition(placeholders domain.Placeholders) (string, error) {
+	ret := _m.Called(placeholders)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(domain.Placeholders) string); ok {
+		r0 = rf(placeholders)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(domain.Placeholders) error); ok {
+		r1 = rf(placeholders)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Name provides a mock function with given fields:
 func (_m *WithCondition) Name() string {
 	ret := _m.Called()
@@ -45,7 +66,7 @@ func (_m *WithCondition) SetName(name string) domain.WithCondition {
 }
 
 // Value provides a mock function with given fields:
-func (_m *WithCondition) Value() types.AttributeValue {
+func (_m *WithCondition) Value() (types.AttributeValue, error) {
 	ret := _m.Called()
 
 	var r0 types.AttributeValue
@@ -57,5 +78,12 @@ func (_m *WithCondition) Value() types.AttributeValue {
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
@@ -59,7 +59,7 @@ func (_m *WithSortKeyCondition) SetName(name string) domain.WithCondition {
 }
 
 // Value provides a mock function with given fields:
-func (_m *WithSortKeyCondition) Value() types.AttributeValue {
+func (_m *WithSortKeyCondition) Value() (types.AttributeValue, error) {
 	ret := _m.Called()
 
 	var r0 types.AttributeValue
@@ -71,5 +71,12 @@ func (_m *WithSortKeyCondition) Value() types.AttributeValue {
 		}
 	}
 
-	return r0
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
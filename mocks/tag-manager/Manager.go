@@ -15,6 +15,20 @@ type Manager struct {
 	mock.Mock
 }
 
+// GetAttributeName provides a mock function with given fields: key
+func (_m *Manager) GetAttributeName(key string) string {
+	ret := _m.Called(key)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // GetHash provides a mock function with given fields:
 func (_m *Manager) GetHash() string {
 	ret := _m.Called()
@@ -59,6 +73,22 @@ func (_m *Manager) GetRange() string {
 	return r0
 }
 
+// GetRules provides a mock function with given fields: key
+func (_m *Manager) GetRules(key string) []tagManager.Rule {
+	ret := _m.Called(key)
+
+	var r0 []tagManager.Rule
+	if rf, ok := ret.Get(0).(func(string) []tagManager.Rule); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]tagManager.Rule)
+		}
+	}
+
+	return r0
+}
+
 // GetType provides a mock function with given fields: key
 func (_m *Manager) GetType(key string) reflect.Kind {
 	ret := _m.Called(key)
@@ -6,6 +6,8 @@ import (
 	reflect "reflect"
 
 	mock "github.com/stretchr/testify/mock"
+
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
 )
 
 // TagGetters is an autogenerated mock type for the TagGetters type
@@ -13,6 +15,20 @@ type TagGetters struct {
 	mock.Mock
 }
 
+// GetAttributeName provides a mock function with given fields: key
+func (_m *TagGetters) GetAttributeName(key string) string {
+	ret := _m.Called(key)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // GetHash provides a mock function with given fields:
 func (_m *TagGetters) GetHash() string {
 	ret := _m.Called()
@@ -41,6 +57,22 @@ func (_m *TagGetters) GetRange() string {
 	return r0
 }
 
+// GetRules provides a mock function with given fields: key
+func (_m *TagGetters) GetRules(key string) []tagManager.Rule {
+	ret := _m.Called(key)
+
+	var r0 []tagManager.Rule
+	if rf, ok := ret.Get(0).(func(string) []tagManager.Rule); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]tagManager.Rule)
+		}
+	}
+
+	return r0
+}
+
 // GetType provides a mock function with given fields: key
 func (_m *TagGetters) GetType(key string) reflect.Kind {
 	ret := _m.Called(key)
@@ -20,6 +20,20 @@ func (_m *TagMapperInterface) ExtractFieldList() {
 	_m.Called()
 }
 
+// ExtractCapacity provides a mock function with given fields: tagsPair, field
+func (_m *TagMapperInterface) ExtractCapacity(tagsPair []string, field reflect.StructField) error {
+	ret := _m.Called(tagsPair, field)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, reflect.StructField) error); ok {
+		r0 = rf(tagsPair, field)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExtractGSI provides a mock function with given fields: tagsPair, field
 func (_m *TagMapperInterface) ExtractGSI(tagsPair []string, field reflect.StructField) error {
 	ret := _m.Called(tagsPair, field)
@@ -62,6 +76,20 @@ func (_m *TagMapperInterface) ExtractPK(tagsPair []string, field reflect.StructF
 	return r0
 }
 
+// ExtractRules provides a mock function with given fields: tagsPair, field
+func (_m *TagMapperInterface) ExtractRules(tagsPair []string, field reflect.StructField) error {
+	ret := _m.Called(tagsPair, field)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, reflect.StructField) error); ok {
+		r0 = rf(tagsPair, field)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExtractTypes provides a mock function with given fields: tagsPair, field
 func (_m *TagMapperInterface) ExtractTypes(tagsPair []string, field reflect.StructField) error {
 	ret := _m.Called(tagsPair, field)
@@ -76,6 +104,20 @@ func (_m *TagMapperInterface) ExtractTypes(tagsPair []string, field reflect.Stru
 	return r0
 }
 
+// GetAttributeName provides a mock function with given fields: key
+func (_m *TagMapperInterface) GetAttributeName(key string) string {
+	ret := _m.Called(key)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
 // GetHash provides a mock function with given fields:
 func (_m *TagMapperInterface) GetHash() string {
 	ret := _m.Called()
@@ -120,6 +162,22 @@ func (_m *TagMapperInterface) GetRange() string {
 	return r0
 }
 
+// GetRules provides a mock function with given fields: key
+func (_m *TagMapperInterface) GetRules(key string) []tagManager.Rule {
+	ret := _m.Called(key)
+
+	var r0 []tagManager.Rule
+	if rf, ok := ret.Get(0).(func(string) []tagManager.Rule); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]tagManager.Rule)
+		}
+	}
+
+	return r0
+}
+
 // GetType provides a mock function with given fields: key
 func (_m *TagMapperInterface) GetType(key string) reflect.Kind {
 	ret := _m.Called(key)
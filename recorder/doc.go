@@ -0,0 +1,13 @@
+/*
+Package recorder
+
+pacote criado para gravar e reproduzir as chamadas da API do DynamoDB nos
+testes. Uma execução com DYNAMO_RECORDER_MODE=record contra o DynamoDB
+Local grava os arquivos golden e as execuções seguintes reproduzem as
+gravações offline, falhando em qualquer mudança no formato das
+requisições:
+
+	rec := recorder.ForTest(t, "testdata/users.json")
+	client := dynamodb.NewFromConfig(cfg, rec.ClientOptions)
+*/
+package recorder
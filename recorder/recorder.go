@@ -0,0 +1,342 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// ModeEnv é a variável de ambiente lida por ModeFromEnv
+const ModeEnv = "DYNAMO_RECORDER_MODE"
+
+const (
+	// Record executa as requisições no endpoint configurado (ex: DynamoDB
+	// Local) e grava as interações no arquivo golden
+	Record = Mode("record")
+	// Replay responde com as interações gravadas, sem acessar a rede, e
+	// falha quando uma requisição não corresponde a nenhuma gravação
+	Replay = Mode("replay")
+)
+
+// ignoredFields são os campos gerados a cada execução pelo SDK e que não
+// fazem parte do formato da requisição
+var ignoredFields = []string{"ClientRequestToken"}
+
+// ErrMismatch é retornado no Replay quando a requisição não corresponde
+// a nenhuma interação gravada
+var ErrMismatch = errors.New("recorder: request does not match the recording")
+
+type (
+	// Mode é o modo de operação do Recorder
+	Mode string
+
+	// Interaction é uma requisição do SDK e a resposta recebida
+	Interaction struct {
+		Operation  string          `json:"operation"`
+		Request    json.RawMessage `json:"request"`
+		StatusCode int             `json:"statusCode"`
+		Response   json.RawMessage `json:"response"`
+	}
+
+	// Recorder é um aws.HTTPClient que grava ou reproduz as chamadas da
+	// API do DynamoDB em um arquivo golden.
+	//
+	// As requisições são comparadas pela operação (X-Amz-Target) e pelo
+	// corpo em JSON canônico, então mudanças nas KeyConditionExpression e
+	// UpdateExpression geradas fazem o Replay falhar
+	Recorder struct {
+		mode   Mode
+		path   string
+		client aws.HTTPClient
+
+		mu           sync.Mutex
+		interactions []Interaction
+		used         []bool
+		errs         []error
+	}
+)
+
+// ModeFromEnv lê o modo de DYNAMO_RECORDER_MODE. O padrão é Replay, para
+// que os testes rodem offline
+func ModeFromEnv() Mode {
+	if Mode(strings.ToLower(os.Getenv(ModeEnv))) == Record {
+		return Record
+	}
+
+	return Replay
+}
+
+// New inicializa um Recorder para o arquivo golden. No Replay o arquivo
+// é carregado e deve existir
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		mode:   mode,
+		path:   path,
+		client: http.DefaultClient,
+	}
+
+	switch mode {
+	case Record:
+		return r, nil
+	case Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("recorder: read recording: %v. Run the tests with %s=record to create it", err, ModeEnv)
+		}
+
+		if err = json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("recorder: parse recording %s: %v", path, err)
+		}
+
+		// O arquivo é gravado indentado para facilitar a revisão
+		for i, interaction := range r.interactions {
+			if r.interactions[i].Request, err = canonical(interaction.Request); err != nil {
+				return nil, fmt.Errorf("recorder: parse recording %s: %v", path, err)
+			}
+
+			if r.interactions[i].Response, err = canonical(interaction.Response); err != nil {
+				return nil, fmt.Errorf("recorder: parse recording %s: %v", path, err)
+			}
+		}
+
+		r.used = make([]bool, len(r.interactions))
+
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("recorder: unknown mode %q", mode)
+}
+
+// ForTest inicializa um Recorder com o modo de ModeFromEnv e registra em
+// t.Cleanup a gravação do arquivo (Record) ou a verificação de que todas
+// as interações foram reproduzidas (Replay)
+func ForTest(t testing.TB, path string) *Recorder {
+	t.Helper()
+
+	r, err := New(path, ModeFromEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := r.Finish(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return r
+}
+
+// Mode retorna o modo do Recorder
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// ClientOptions configura um *dynamodb.Client para usar o Recorder. As
+// retentativas são desligadas para que a gravação seja determinística.
+// Ex: dynamodb.NewFromConfig(cfg, recorder.ClientOptions)
+func (r *Recorder) ClientOptions(o *dynamodb.Options) {
+	if o.HTTPClient != nil {
+		r.client = o.HTTPClient
+	}
+
+	o.HTTPClient = r
+	o.Retryer = aws.NopRetryer{}
+}
+
+// Do implementa aws.HTTPClient
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	operation := strings.TrimPrefix(req.Header.Get("X-Amz-Target"), "DynamoDB_20120810.")
+
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := canonical(body)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %s request: %v", operation, err)
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, operation, request)
+	}
+
+	return r.record(req, operation, request)
+}
+
+// record executa a requisição e grava a interação
+func (r *Recorder) record(req *http.Request, operation string, request json.RawMessage) (*http.Response, error) {
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(data))
+
+	response, err := canonical(data)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %s response: %v", operation, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, Interaction{
+		Operation:  operation,
+		Request:    request,
+		StatusCode: res.StatusCode,
+		Response:   response,
+	})
+
+	return res, nil
+}
+
+// replay responde com a primeira interação ainda não usada que
+// corresponde à requisição. Requisições concorrentes, como as do Scan
+// paralelo do Truncate, podem chegar em qualquer ordem
+func (r *Recorder) replay(req *http.Request, operation string, request json.RawMessage) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	candidate := -1
+	for i, interaction := range r.interactions {
+		if r.used[i] || interaction.Operation != operation {
+			continue
+		}
+
+		if bytes.Equal(interaction.Request, request) {
+			r.used[i] = true
+
+			return &http.Response{
+				StatusCode: interaction.StatusCode,
+				Status:     http.StatusText(interaction.StatusCode),
+				Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.0"}},
+				Body:       io.NopCloser(bytes.NewReader(interaction.Response)),
+				Request:    req,
+			}, nil
+		}
+
+		if candidate < 0 {
+			candidate = i
+		}
+	}
+
+	detail := fmt.Sprintf("%s\n\tgot:      %s", operation, request)
+	if candidate >= 0 {
+		detail += fmt.Sprintf("\n\texpected: %s", r.interactions[candidate].Request)
+	}
+
+	err := fmt.Errorf("%w: %s", ErrMismatch, detail)
+
+	r.errs = append(r.errs, err)
+
+	return nil, err
+}
+
+// Finish grava o arquivo golden no Record. No Replay retorna o primeiro
+// erro de correspondência ou a primeira interação gravada que não foi
+// reproduzida
+func (r *Recorder) Finish() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == Record {
+		return r.save()
+	}
+
+	if len(r.errs) > 0 {
+		return r.errs[0]
+	}
+
+	for i, used := range r.used {
+		if !used {
+			return fmt.Errorf("recorder: recorded %s request was not replayed: %s", r.interactions[i].Operation, r.interactions[i].Request)
+		}
+	}
+
+	return nil
+}
+
+// save grava as interações no arquivo golden
+func (r *Recorder) save() error {
+	interactions := r.interactions
+	if interactions == nil {
+		interactions = []Interaction{}
+	}
+
+	data, err := json.MarshalIndent(interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("recorder: encode recording: %v", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("recorder: create recording dir: %v", err)
+	}
+
+	if err = os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("recorder: write recording: %v", err)
+	}
+
+	return nil
+}
+
+// readBody lê o corpo da requisição e o repõe para o envio
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recorder: read request body: %v", err)
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, nil
+}
+
+// canonical serializa um corpo JSON com as chaves ordenadas e sem os
+// campos gerados a cada execução
+func canonical(body []byte) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return json.RawMessage("{}"), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var content interface{}
+	if err := decoder.Decode(&content); err != nil {
+		return nil, err
+	}
+
+	if object, ok := content.(map[string]interface{}); ok {
+		for _, field := range ignoredFields {
+			delete(object, field)
+		}
+	}
+
+	return json.Marshal(content)
+}
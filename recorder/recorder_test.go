@@ -0,0 +1,105 @@
+package recorder_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/recorder"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	PK    string `diinamo:"type:string;hash"`
+	SK    string `diinamo:"type:string;range"`
+	Email string
+}
+
+func newClient(endpoint string, rec *recorder.Recorder) *drivers.DynamoClient {
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("local", "local", ""),
+		EndpointResolver: dynamodb.EndpointResolverFromURL(endpoint),
+	}, rec.ClientOptions)
+
+	return drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   "users",
+		Environment: "testing",
+		Client:      client,
+		Table:       table.MustNewTable("users", user{}),
+	})
+}
+
+func queryUsers(d *drivers.DynamoClient, prefix string) ([]user, error) {
+	var result []user
+	sql := d.NewExpressionBuilder().
+		Where(expressions.NewKeyCondition("PK", "ORG#1")).
+		AndWhere(expressions.NewSortKeyCondition("SK").StarsWith(prefix))
+
+	err := d.Perform(drivers.QUERY, sql, &result)
+
+	return result, err
+}
+
+func TestRecorder(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "users.json")
+
+	t.Run("should record the interactions", func(t *testing.T) {
+		server := inmemory.NewServer()
+		defer server.Close()
+
+		rec, err := recorder.New(golden, recorder.Record)
+		assert.Nil(t, err)
+
+		d := newClient(server.URL, rec)
+		assert.Nil(t, d.CreateTable())
+		assert.Nil(t, d.Seed(user{PK: "ORG#1", SK: "USER#1", Email: "jane@example.com"}))
+
+		result, err := queryUsers(d, "USER#")
+		assert.Nil(t, err)
+		assert.Len(t, result, 1)
+
+		assert.Nil(t, rec.Finish())
+	})
+	t.Run("should replay the interactions offline", func(t *testing.T) {
+		rec, err := recorder.New(golden, recorder.Replay)
+		assert.Nil(t, err)
+
+		d := newClient("http://127.0.0.1:1", rec)
+		assert.Nil(t, d.CreateTable())
+		assert.Nil(t, d.Seed(user{PK: "ORG#1", SK: "USER#1", Email: "jane@example.com"}))
+
+		result, err := queryUsers(d, "USER#")
+		assert.Nil(t, err)
+		assert.Equal(t, []user{{PK: "ORG#1", SK: "USER#1", Email: "jane@example.com"}}, result)
+
+		assert.Nil(t, rec.Finish())
+	})
+	t.Run("should fail when the request shape changes", func(t *testing.T) {
+		rec, err := recorder.New(golden, recorder.Replay)
+		assert.Nil(t, err)
+
+		d := newClient("http://127.0.0.1:1", rec)
+		assert.Nil(t, d.CreateTable())
+		assert.Nil(t, d.Seed(user{PK: "ORG#1", SK: "USER#1", Email: "jane@example.com"}))
+
+		_, err = queryUsers(d, "MEMBER#")
+		assert.Contains(t, err.Error(), recorder.ErrMismatch.Error())
+		assert.Contains(t, err.Error(), `"USER#"`)
+
+		err = rec.Finish()
+		assert.True(t, errors.Is(err, recorder.ErrMismatch))
+	})
+	t.Run("should require the recording in replay mode", func(t *testing.T) {
+		_, err := recorder.New(filepath.Join(t.TempDir(), "missing.json"), recorder.Replay)
+		assert.Contains(t, err.Error(), "DYNAMO_RECORDER_MODE=record")
+	})
+}
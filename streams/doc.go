@@ -0,0 +1,7 @@
+/*
+Package streams
+
+pacote criado para consumir DynamoDB Streams em lambda functions,
+decodificando as imagens nas estruturas com a tag diinamo
+*/
+package streams
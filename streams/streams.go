@@ -0,0 +1,332 @@
+package streams
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+)
+
+// Tipos de evento de um DynamoDB Stream
+const (
+	INSERT = "INSERT"
+	MODIFY = "MODIFY"
+	REMOVE = "REMOVE"
+)
+
+type (
+	// Matcher decide se um registro do stream pertence a uma entidade.
+	// Recebe a imagem do item (NewImage, OldImage ou Keys, o que existir)
+	Matcher func(image map[string]types.AttributeValue) bool
+
+	// Callbacks são os handlers de uma entidade. Entity é um valor da
+	// struct com as tags diinamo, a mesma usada em table.NewTable, e os
+	// callbacks recebem ponteiros para essa struct. Ex: image.(*User)
+	Callbacks struct {
+		Entity interface{}
+
+		OnInsert func(ctx context.Context, newImage interface{}) error
+		OnModify func(ctx context.Context, oldImage, newImage interface{}) error
+		OnRemove func(ctx context.Context, oldImage interface{}) error
+	}
+
+	// Handler despacha os registros de um DynamoDB Stream para os
+	// Callbacks da entidade correspondente
+	Handler struct {
+		routes []route
+		logger.Log
+	}
+
+	route struct {
+		match      Matcher
+		entityType reflect.Type
+		callbacks  Callbacks
+	}
+)
+
+// NewHandler inicializa um Handler sem entidades registradas
+func NewHandler() *Handler {
+	return &Handler{Log: logger.NewLogger()}
+}
+
+// Register registra os Callbacks de uma entidade. Os registros são
+// despachados para o primeiro Matcher que aceitar a imagem do item. Um
+// Matcher nil aceita todos os registros, útil em tabelas de uma entidade.
+//
+// Retorna um erro quando Entity não é uma struct ou um ponteiro de struct
+func (h *Handler) Register(match Matcher, callbacks Callbacks) (*Handler, error) {
+	if match == nil {
+		match = MatchAll
+	}
+
+	entityType := reflect.TypeOf(callbacks.Entity)
+	for entityType != nil && entityType.Kind() == reflect.Ptr {
+		entityType = entityType.Elem()
+	}
+
+	if entityType == nil || entityType.Kind() != reflect.Struct {
+		return h, fmt.Errorf("register: callbacks entity should be a struct, got %T", callbacks.Entity)
+	}
+
+	h.routes = append(h.routes, route{
+		match:      match,
+		entityType: entityType,
+		callbacks:  callbacks,
+	})
+
+	return h, nil
+}
+
+// MustRegister é o Register que entra em pânico com uma entidade
+// inválida. Serve para montar o Handler em variáveis de pacote
+func (h *Handler) MustRegister(match Matcher, callbacks Callbacks) *Handler {
+	handler, err := h.Register(match, callbacks)
+	if err != nil {
+		panic(err)
+	}
+
+	return handler
+}
+
+// Handle processa um DynamoDBEvent e pode ser usado diretamente como
+// handler da Lambda. Ao primeiro erro o processamento é interrompido e o
+// registro é reportado em batchItemFailures, para que a Lambda tente
+// novamente a partir dele (ReportBatchItemFailures)
+func (h *Handler) Handle(ctx context.Context, event events.DynamoDBEvent) (events.DynamoDBEventResponse, error) {
+	response := events.DynamoDBEventResponse{
+		BatchItemFailures: []events.DynamoDBBatchItemFailure{},
+	}
+
+	for _, record := range event.Records {
+		if err := h.HandleRecord(ctx, record); err != nil {
+			h.Error("failed on record %s: %v\n", record.EventID, err)
+
+			response.BatchItemFailures = append(response.BatchItemFailures, events.DynamoDBBatchItemFailure{
+				ItemIdentifier: record.Change.SequenceNumber,
+			})
+
+			break
+		}
+	}
+
+	return response, nil
+}
+
+// HandleRecord decodifica as imagens de um registro e executa o callback
+// da entidade correspondente. Registros sem entidade são ignorados
+func (h *Handler) HandleRecord(ctx context.Context, record events.DynamoDBEventRecord) error {
+	keys, err := ToAttributeValues(record.Change.Keys)
+	if err != nil {
+		return err
+	}
+
+	newImage, err := ToAttributeValues(record.Change.NewImage)
+	if err != nil {
+		return err
+	}
+
+	oldImage, err := ToAttributeValues(record.Change.OldImage)
+	if err != nil {
+		return err
+	}
+
+	image := keys
+	if len(newImage) > 0 {
+		image = newImage
+	} else if len(oldImage) > 0 {
+		image = oldImage
+	}
+
+	for _, r := range h.routes {
+		if !r.match(image) {
+			continue
+		}
+
+		return r.dispatch(ctx, record.EventName, oldImage, newImage)
+	}
+
+	h.Debug("no entity registered for record %s\n", record.EventID)
+
+	return nil
+}
+
+// dispatch executa o callback do tipo de evento
+func (r route) dispatch(ctx context.Context, eventName string, oldImage, newImage map[string]types.AttributeValue) error {
+	switch eventName {
+	case INSERT:
+		if r.callbacks.OnInsert == nil {
+			return nil
+		}
+
+		entity, err := r.decode(newImage)
+		if err != nil {
+			return err
+		}
+
+		return r.callbacks.OnInsert(ctx, entity)
+	case MODIFY:
+		if r.callbacks.OnModify == nil {
+			return nil
+		}
+
+		oldEntity, err := r.decode(oldImage)
+		if err != nil {
+			return err
+		}
+
+		newEntity, err := r.decode(newImage)
+		if err != nil {
+			return err
+		}
+
+		return r.callbacks.OnModify(ctx, oldEntity, newEntity)
+	case REMOVE:
+		if r.callbacks.OnRemove == nil {
+			return nil
+		}
+
+		entity, err := r.decode(oldImage)
+		if err != nil {
+			return err
+		}
+
+		return r.callbacks.OnRemove(ctx, entity)
+	}
+
+	return fmt.Errorf("unknown event name %q", eventName)
+}
+
+// decode cria um ponteiro da entidade e decodifica a imagem nele. Imagens
+// ausentes (ex: stream configurado como KEYS_ONLY) geram nil
+func (r route) decode(image map[string]types.AttributeValue) (interface{}, error) {
+	if len(image) == 0 {
+		return nil, nil
+	}
+
+	entity := reflect.New(r.entityType).Interface()
+	if err := expressions.UnmarshalMap(image, entity); err != nil {
+		return nil, fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return entity, nil
+}
+
+// Decode decodifica uma imagem de um registro do stream em uma struct
+// com as tags diinamo. target deve ser um ponteiro
+func Decode(image map[string]events.DynamoDBAttributeValue, target interface{}) error {
+	attributes, err := ToAttributeValues(image)
+	if err != nil {
+		return err
+	}
+
+	if err = expressions.UnmarshalMap(attributes, target); err != nil {
+		return fmt.Errorf("UnmarshalMap: %v", err)
+	}
+
+	return nil
+}
+
+// ToAttributeValues converte uma imagem do stream em um item do SDK
+func ToAttributeValues(image map[string]events.DynamoDBAttributeValue) (map[string]types.AttributeValue, error) {
+	attributes := make(map[string]types.AttributeValue, len(image))
+
+	for name, value := range image {
+		attr, err := ToAttributeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %v", name, err)
+		}
+
+		attributes[name] = attr
+	}
+
+	return attributes, nil
+}
+
+// ToAttributeValue converte um events.DynamoDBAttributeValue em um
+// types.AttributeValue do SDK
+func ToAttributeValue(value events.DynamoDBAttributeValue) (types.AttributeValue, error) {
+	switch value.DataType() {
+	case events.DataTypeString:
+		return &types.AttributeValueMemberS{Value: value.String()}, nil
+	case events.DataTypeNumber:
+		return &types.AttributeValueMemberN{Value: value.Number()}, nil
+	case events.DataTypeBinary:
+		return &types.AttributeValueMemberB{Value: value.Binary()}, nil
+	case events.DataTypeBoolean:
+		return &types.AttributeValueMemberBOOL{Value: value.Boolean()}, nil
+	case events.DataTypeNull:
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	case events.DataTypeStringSet:
+		return &types.AttributeValueMemberSS{Value: value.StringSet()}, nil
+	case events.DataTypeNumberSet:
+		return &types.AttributeValueMemberNS{Value: value.NumberSet()}, nil
+	case events.DataTypeBinarySet:
+		return &types.AttributeValueMemberBS{Value: value.BinarySet()}, nil
+	case events.DataTypeList:
+		var list []types.AttributeValue
+		for _, item := range value.List() {
+			attr, err := ToAttributeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, attr)
+		}
+		return &types.AttributeValueMemberL{Value: list}, nil
+	case events.DataTypeMap:
+		members, err := ToAttributeValues(value.Map())
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: members}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported data type %v", value.DataType())
+}
+
+// LoadEvent lê um DynamoDBEvent de um arquivo JSON. Útil para testar os
+// handlers com fixtures locais
+func LoadEvent(path string) (events.DynamoDBEvent, error) {
+	var event events.DynamoDBEvent
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return event, fmt.Errorf("read event: %v", err)
+	}
+
+	if err = json.Unmarshal(data, &event); err != nil {
+		return event, fmt.Errorf("parse event: %v", err)
+	}
+
+	return event, nil
+}
+
+/* Matchers */
+
+// MatchAll aceita todos os itens
+func MatchAll(image map[string]types.AttributeValue) bool {
+	return true
+}
+
+// KeyPrefix aceita itens cujo atributo string começa com o prefixo.
+// Ex: KeyPrefix("PK", "USER#")
+func KeyPrefix(attribute, prefix string) Matcher {
+	return func(image map[string]types.AttributeValue) bool {
+		value, ok := image[attribute].(*types.AttributeValueMemberS)
+		return ok && len(value.Value) >= len(prefix) && value.Value[:len(prefix)] == prefix
+	}
+}
+
+// AttributeEquals aceita itens cujo atributo string é igual ao valor.
+// Ex: AttributeEquals("EntityType", "User")
+func AttributeEquals(attribute, expected string) Matcher {
+	return func(image map[string]types.AttributeValue) bool {
+		value, ok := image[attribute].(*types.AttributeValueMemberS)
+		return ok && value.Value == expected
+	}
+}
@@ -0,0 +1,123 @@
+package streams_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/streams"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	PK    string `diinamo:"type:string;hash"`
+	SK    string `diinamo:"type:string;range"`
+	Email string
+	Age   int
+}
+
+func TestHandler_Handle(t *testing.T) {
+	event, err := streams.LoadEvent("testdata/users-event.json")
+	if err != nil {
+		t.Fatalf("failed to load event: %v", err)
+	}
+
+	t.Run("should dispatch records to entity callbacks", func(t *testing.T) {
+		var inserted, removed *user
+		var modified [2]*user
+
+		h := streams.NewHandler().MustRegister(streams.KeyPrefix("PK", "USER#"), streams.Callbacks{
+			Entity: user{},
+			OnInsert: func(ctx context.Context, newImage interface{}) error {
+				inserted = newImage.(*user)
+				return nil
+			},
+			OnModify: func(ctx context.Context, oldImage, newImage interface{}) error {
+				modified = [2]*user{oldImage.(*user), newImage.(*user)}
+				return nil
+			},
+			OnRemove: func(ctx context.Context, oldImage interface{}) error {
+				removed = oldImage.(*user)
+				return nil
+			},
+		})
+
+		response, err := h.Handle(context.Background(), event)
+		assert.Nil(t, err)
+		assert.Empty(t, response.BatchItemFailures)
+
+		assert.Equal(t, &user{PK: "USER#1", SK: "PROFILE", Email: "jane@example.com", Age: 31}, inserted)
+		assert.Equal(t, 31, modified[0].Age)
+		assert.Equal(t, "jane.doe@example.com", modified[1].Email)
+		assert.Equal(t, 32, removed.Age)
+	})
+	t.Run("should report the failed record in batchItemFailures", func(t *testing.T) {
+		calls := 0
+
+		h := streams.NewHandler().MustRegister(streams.KeyPrefix("PK", "USER#"), streams.Callbacks{
+			Entity: user{},
+			OnInsert: func(ctx context.Context, newImage interface{}) error {
+				calls++
+				return nil
+			},
+			OnModify: func(ctx context.Context, oldImage, newImage interface{}) error {
+				return errors.New("downstream unavailable")
+			},
+			OnRemove: func(ctx context.Context, oldImage interface{}) error {
+				calls++
+				return nil
+			},
+		})
+
+		response, err := h.Handle(context.Background(), event)
+		assert.Nil(t, err)
+		assert.Len(t, response.BatchItemFailures, 1)
+		assert.Equal(t, "200", response.BatchItemFailures[0].ItemIdentifier)
+		assert.Equal(t, 1, calls)
+	})
+	t.Run("should refuse an entity that is not a struct", func(t *testing.T) {
+		_, err := streams.NewHandler().Register(nil, streams.Callbacks{Entity: "user"})
+		assert.EqualError(t, err, "register: callbacks entity should be a struct, got string")
+
+		_, err = streams.NewHandler().Register(nil, streams.Callbacks{})
+		assert.EqualError(t, err, "register: callbacks entity should be a struct, got <nil>")
+
+		assert.Panics(t, func() {
+			streams.NewHandler().MustRegister(nil, streams.Callbacks{Entity: 1})
+		})
+	})
+	t.Run("should dispatch every record without matcher", func(t *testing.T) {
+		calls := 0
+		count := func(ctx context.Context, image interface{}) error {
+			calls++
+			return nil
+		}
+
+		h := streams.NewHandler().MustRegister(nil, streams.Callbacks{
+			Entity:   user{},
+			OnInsert: count,
+			OnModify: func(ctx context.Context, oldImage, newImage interface{}) error {
+				return count(ctx, newImage)
+			},
+			OnRemove: count,
+		})
+
+		response, err := h.Handle(context.Background(), event)
+		assert.Nil(t, err)
+		assert.Empty(t, response.BatchItemFailures)
+		assert.Equal(t, len(event.Records), calls)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("should decode a stream image into a tagged struct", func(t *testing.T) {
+		event, err := streams.LoadEvent("testdata/users-event.json")
+		assert.Nil(t, err)
+
+		var u user
+		err = streams.Decode(event.Records[0].Change.NewImage, &u)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "jane@example.com", u.Email)
+	})
+}
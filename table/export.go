@@ -0,0 +1,337 @@
+package table
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormat é o formato de saída de um template de CloudFormation
+type ExportFormat string
+
+const (
+	// YAML exporta o template de CloudFormation / SAM em YAML
+	YAML = ExportFormat("yaml")
+	// JSON exporta o template de CloudFormation / SAM em JSON
+	JSON = ExportFormat("json")
+)
+
+type (
+	// CloudFormationTemplate é a estrutura mínima de um template de
+	// CloudFormation / SAM contendo os recursos exportados
+	CloudFormationTemplate struct {
+		AWSTemplateFormatVersion string                            `json:"AWSTemplateFormatVersion" yaml:"AWSTemplateFormatVersion"`
+		Resources                map[string]CloudFormationResource `json:"Resources" yaml:"Resources"`
+	}
+
+	// CloudFormationResource é um recurso AWS::DynamoDB::Table
+	CloudFormationResource struct {
+		Type       string                  `json:"Type" yaml:"Type"`
+		Properties CloudFormationTableSpec `json:"Properties" yaml:"Properties"`
+	}
+
+	// CloudFormationTableSpec são as propriedades de um AWS::DynamoDB::Table
+	CloudFormationTableSpec struct {
+		TableName               string                      `json:"TableName" yaml:"TableName"`
+		AttributeDefinitions    []cfnAttributeDefinition    `json:"AttributeDefinitions" yaml:"AttributeDefinitions"`
+		KeySchema               []cfnKeySchema              `json:"KeySchema" yaml:"KeySchema"`
+		BillingMode             string                      `json:"BillingMode,omitempty" yaml:"BillingMode,omitempty"`
+		ProvisionedThroughput   *cfnProvisionedThroughput   `json:"ProvisionedThroughput,omitempty" yaml:"ProvisionedThroughput,omitempty"`
+		TableClass              string                      `json:"TableClass,omitempty" yaml:"TableClass,omitempty"`
+		GlobalSecondaryIndexes  []cfnGlobalSecondaryIndex   `json:"GlobalSecondaryIndexes,omitempty" yaml:"GlobalSecondaryIndexes,omitempty"`
+		LocalSecondaryIndexes   []cfnLocalSecondaryIndex    `json:"LocalSecondaryIndexes,omitempty" yaml:"LocalSecondaryIndexes,omitempty"`
+		TimeToLiveSpecification *cfnTimeToLiveSpecification `json:"TimeToLiveSpecification,omitempty" yaml:"TimeToLiveSpecification,omitempty"`
+	}
+
+	cfnAttributeDefinition struct {
+		AttributeName string `json:"AttributeName" yaml:"AttributeName"`
+		AttributeType string `json:"AttributeType" yaml:"AttributeType"`
+	}
+
+	cfnKeySchema struct {
+		AttributeName string `json:"AttributeName" yaml:"AttributeName"`
+		KeyType       string `json:"KeyType" yaml:"KeyType"`
+	}
+
+	cfnProvisionedThroughput struct {
+		ReadCapacityUnits  int64 `json:"ReadCapacityUnits" yaml:"ReadCapacityUnits"`
+		WriteCapacityUnits int64 `json:"WriteCapacityUnits" yaml:"WriteCapacityUnits"`
+	}
+
+	cfnProjection struct {
+		ProjectionType   string   `json:"ProjectionType" yaml:"ProjectionType"`
+		NonKeyAttributes []string `json:"NonKeyAttributes,omitempty" yaml:"NonKeyAttributes,omitempty"`
+	}
+
+	cfnGlobalSecondaryIndex struct {
+		IndexName             string                    `json:"IndexName" yaml:"IndexName"`
+		KeySchema             []cfnKeySchema            `json:"KeySchema" yaml:"KeySchema"`
+		Projection            cfnProjection             `json:"Projection" yaml:"Projection"`
+		ProvisionedThroughput *cfnProvisionedThroughput `json:"ProvisionedThroughput,omitempty" yaml:"ProvisionedThroughput,omitempty"`
+	}
+
+	cfnLocalSecondaryIndex struct {
+		IndexName  string         `json:"IndexName" yaml:"IndexName"`
+		KeySchema  []cfnKeySchema `json:"KeySchema" yaml:"KeySchema"`
+		Projection cfnProjection  `json:"Projection" yaml:"Projection"`
+	}
+
+	cfnTimeToLiveSpecification struct {
+		AttributeName string `json:"AttributeName" yaml:"AttributeName"`
+		Enabled       bool   `json:"Enabled" yaml:"Enabled"`
+	}
+)
+
+// CloudFormationResource monta o recurso AWS::DynamoDB::Table a partir
+// das definições extraídas das tags diinamo
+func (t *Table) CloudFormationResource() CloudFormationResource {
+	spec := CloudFormationTableSpec{
+		TableName:             t.TableName,
+		KeySchema:             toCfnKeySchema(t.KeySchema()),
+		BillingMode:           string(t.Billing()),
+		ProvisionedThroughput: toCfnThroughput(t.ProvisionedThroughput()),
+		TableClass:            string(t.TableClass()),
+	}
+
+	for _, attr := range t.AttributeDefinitions() {
+		spec.AttributeDefinitions = append(spec.AttributeDefinitions, cfnAttributeDefinition{
+			AttributeName: *attr.AttributeName,
+			AttributeType: string(attr.AttributeType),
+		})
+	}
+
+	for _, gsi := range t.GetGSI() {
+		spec.GlobalSecondaryIndexes = append(spec.GlobalSecondaryIndexes, cfnGlobalSecondaryIndex{
+			IndexName:             *gsi.IndexName,
+			KeySchema:             toCfnKeySchema(gsi.KeySchema),
+			Projection:            toCfnProjection(gsi.Projection),
+			ProvisionedThroughput: toCfnThroughput(gsi.ProvisionedThroughput),
+		})
+	}
+
+	for _, lsi := range t.GetLSI() {
+		spec.LocalSecondaryIndexes = append(spec.LocalSecondaryIndexes, cfnLocalSecondaryIndex{
+			IndexName:  *lsi.IndexName,
+			KeySchema:  toCfnKeySchema(lsi.KeySchema),
+			Projection: toCfnProjection(lsi.Projection),
+		})
+	}
+
+	if ttlAttribute := t.TimeToLiveAttribute(); ttlAttribute != "" {
+		spec.TimeToLiveSpecification = &cfnTimeToLiveSpecification{
+			AttributeName: ttlAttribute,
+			Enabled:       true,
+		}
+	}
+
+	return CloudFormationResource{
+		Type:       "AWS::DynamoDB::Table",
+		Properties: spec,
+	}
+}
+
+// ExportCloudFormation gera um template de CloudFormation / SAM com a
+// tabela registrada sob o logicalID informado, no formato YAML ou JSON.
+// A tabela é verificada por Validate antes da exportação
+func (t *Table) ExportCloudFormation(logicalID string, format ExportFormat) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	template := CloudFormationTemplate{
+		AWSTemplateFormatVersion: "2010-09-09",
+		Resources: map[string]CloudFormationResource{
+			logicalID: t.CloudFormationResource(),
+		},
+	}
+
+	switch format {
+	case YAML:
+		out := &bytes.Buffer{}
+		encoder := yaml.NewEncoder(out)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(template); err != nil {
+			return nil, err
+		}
+
+		return out.Bytes(), encoder.Close()
+	case JSON:
+		return json.MarshalIndent(template, "", "  ")
+	}
+
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// ExportTerraform gera o bloco HCL de um recurso aws_dynamodb_table
+// com o nome de recurso informado. A tabela é verificada por Validate
+// antes da exportação
+func (t *Table) ExportTerraform(resourceName string) (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	hcl := &strings.Builder{}
+
+	fmt.Fprintf(hcl, "resource \"aws_dynamodb_table\" %q {\n", resourceName)
+
+	attributes := []hclAttribute{
+		{"name", fmt.Sprintf("%q", t.TableName)},
+		{"billing_mode", fmt.Sprintf("%q", string(t.Billing()))},
+		{"table_class", fmt.Sprintf("%q", string(t.TableClass()))},
+	}
+	attributes = append(attributes, terraformThroughput(t.ProvisionedThroughput())...)
+	attributes = append(attributes, terraformKeys(t.KeySchema())...)
+	writeHCLAttributes(hcl, "  ", attributes)
+
+	for _, attr := range t.AttributeDefinitions() {
+		writeHCLBlock(hcl, "attribute", []hclAttribute{
+			{"name", fmt.Sprintf("%q", *attr.AttributeName)},
+			{"type", fmt.Sprintf("%q", string(attr.AttributeType))},
+		})
+	}
+
+	for _, gsi := range t.GetGSI() {
+		indexAttributes := []hclAttribute{{"name", fmt.Sprintf("%q", *gsi.IndexName)}}
+		indexAttributes = append(indexAttributes, terraformKeys(gsi.KeySchema)...)
+		indexAttributes = append(indexAttributes, terraformProjection(gsi.Projection)...)
+		indexAttributes = append(indexAttributes, terraformThroughput(gsi.ProvisionedThroughput)...)
+
+		writeHCLBlock(hcl, "global_secondary_index", indexAttributes)
+	}
+
+	for _, lsi := range t.GetLSI() {
+		indexAttributes := []hclAttribute{{"name", fmt.Sprintf("%q", *lsi.IndexName)}}
+		for _, key := range terraformKeys(lsi.KeySchema) {
+			// O hash de um LSI é sempre o hash da tabela
+			if key.name == "range_key" {
+				indexAttributes = append(indexAttributes, key)
+			}
+		}
+		indexAttributes = append(indexAttributes, terraformProjection(lsi.Projection)...)
+
+		writeHCLBlock(hcl, "local_secondary_index", indexAttributes)
+	}
+
+	if ttlAttribute := t.TimeToLiveAttribute(); ttlAttribute != "" {
+		writeHCLBlock(hcl, "ttl", []hclAttribute{
+			{"attribute_name", fmt.Sprintf("%q", ttlAttribute)},
+			{"enabled", "true"},
+		})
+	}
+
+	hcl.WriteString("}\n")
+
+	return hcl.String(), nil
+}
+
+// hclAttribute é um par nome = valor de um bloco HCL
+type hclAttribute struct {
+	name  string
+	value string
+}
+
+// writeHCLBlock escreve um bloco aninhado dentro do recurso
+func writeHCLBlock(hcl *strings.Builder, name string, attributes []hclAttribute) {
+	fmt.Fprintf(hcl, "\n  %s {\n", name)
+	writeHCLAttributes(hcl, "    ", attributes)
+	hcl.WriteString("  }\n")
+}
+
+// writeHCLAttributes escreve os atributos alinhados da mesma forma
+// que o terraform fmt
+func writeHCLAttributes(hcl *strings.Builder, indent string, attributes []hclAttribute) {
+	width := 0
+	for _, attr := range attributes {
+		if len(attr.name) > width {
+			width = len(attr.name)
+		}
+	}
+
+	for _, attr := range attributes {
+		fmt.Fprintf(hcl, "%s%-*s = %s\n", indent, width, attr.name, attr.value)
+	}
+}
+
+func terraformKeys(keySchema []types.KeySchemaElement) []hclAttribute {
+	var attributes []hclAttribute
+
+	for _, key := range keySchema {
+		switch key.KeyType {
+		case types.KeyTypeHash:
+			attributes = append(attributes, hclAttribute{"hash_key", fmt.Sprintf("%q", *key.AttributeName)})
+		case types.KeyTypeRange:
+			attributes = append(attributes, hclAttribute{"range_key", fmt.Sprintf("%q", *key.AttributeName)})
+		}
+	}
+
+	return attributes
+}
+
+func terraformThroughput(throughput *types.ProvisionedThroughput) []hclAttribute {
+	cfnThroughput := toCfnThroughput(throughput)
+	if cfnThroughput == nil {
+		return nil
+	}
+
+	return []hclAttribute{
+		{"read_capacity", fmt.Sprintf("%d", cfnThroughput.ReadCapacityUnits)},
+		{"write_capacity", fmt.Sprintf("%d", cfnThroughput.WriteCapacityUnits)},
+	}
+}
+
+func terraformProjection(projection *types.Projection) []hclAttribute {
+	cfnProjection := toCfnProjection(projection)
+
+	attributes := []hclAttribute{{"projection_type", fmt.Sprintf("%q", cfnProjection.ProjectionType)}}
+
+	if len(cfnProjection.NonKeyAttributes) > 0 {
+		quoted := make([]string, len(cfnProjection.NonKeyAttributes))
+		for i, attr := range cfnProjection.NonKeyAttributes {
+			quoted[i] = fmt.Sprintf("%q", attr)
+		}
+
+		attributes = append(attributes, hclAttribute{"non_key_attributes", "[" + strings.Join(quoted, ", ") + "]"})
+	}
+
+	return attributes
+}
+
+func toCfnKeySchema(keySchema []types.KeySchemaElement) []cfnKeySchema {
+	var keys []cfnKeySchema
+
+	for _, key := range keySchema {
+		keys = append(keys, cfnKeySchema{
+			AttributeName: *key.AttributeName,
+			KeyType:       string(key.KeyType),
+		})
+	}
+
+	return keys
+}
+
+func toCfnThroughput(throughput *types.ProvisionedThroughput) *cfnProvisionedThroughput {
+	if throughput == nil || throughput.ReadCapacityUnits == nil || throughput.WriteCapacityUnits == nil {
+		return nil
+	}
+
+	return &cfnProvisionedThroughput{
+		ReadCapacityUnits:  *throughput.ReadCapacityUnits,
+		WriteCapacityUnits: *throughput.WriteCapacityUnits,
+	}
+}
+
+func toCfnProjection(projection *types.Projection) cfnProjection {
+	if projection == nil || projection.ProjectionType == "" {
+		return cfnProjection{ProjectionType: string(types.ProjectionTypeAll)}
+	}
+
+	return cfnProjection{
+		ProjectionType:   string(projection.ProjectionType),
+		NonKeyAttributes: projection.NonKeyAttributes,
+	}
+}
@@ -0,0 +1,93 @@
+package table_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+type exportEntity struct {
+	PK        string `diinamo:"type:string;hash"`
+	SK        string `diinamo:"type:string;range"`
+	Owner     string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK;projection:include(ExpiresAt)"`
+	CreatedAt int64  `diinamo:"type:number;lsi:CreatedAtIndex;keyPairs:PK=CreatedAt;projection:keys"`
+	ExpiresAt int64  `diinamo:"type:number;ttl"`
+}
+
+func TestTable_ExportCloudFormation(t *testing.T) {
+	tb := table.MustNewTable("courses", exportEntity{})
+
+	t.Run("should export yaml template", func(t *testing.T) {
+		out, err := tb.ExportCloudFormation("CoursesTable", table.YAML)
+		assert.Nil(t, err)
+
+		var template table.CloudFormationTemplate
+		assert.Nil(t, yaml.Unmarshal(out, &template))
+
+		resource := template.Resources["CoursesTable"]
+		assert.Equal(t, "AWS::DynamoDB::Table", resource.Type)
+		assert.Equal(t, "courses", resource.Properties.TableName)
+		assert.Len(t, resource.Properties.AttributeDefinitions, 4)
+		assert.Len(t, resource.Properties.GlobalSecondaryIndexes, 1)
+		assert.Len(t, resource.Properties.LocalSecondaryIndexes, 1)
+		assert.Equal(t, []string{"ExpiresAt"}, resource.Properties.GlobalSecondaryIndexes[0].Projection.NonKeyAttributes)
+		assert.Equal(t, "KEYS_ONLY", resource.Properties.LocalSecondaryIndexes[0].Projection.ProjectionType)
+		assert.Equal(t, "ExpiresAt", resource.Properties.TimeToLiveSpecification.AttributeName)
+	})
+	t.Run("should export json template", func(t *testing.T) {
+		out, err := tb.ExportCloudFormation("CoursesTable", table.JSON)
+		assert.Nil(t, err)
+
+		var template table.CloudFormationTemplate
+		assert.Nil(t, json.Unmarshal(out, &template))
+		assert.Equal(t, "PROVISIONED", template.Resources["CoursesTable"].Properties.BillingMode)
+	})
+	t.Run("should fail with unknown format", func(t *testing.T) {
+		_, err := tb.ExportCloudFormation("CoursesTable", table.ExportFormat("toml"))
+		assert.EqualError(t, err, "unsupported export format: toml")
+	})
+}
+
+func TestTable_ExportTerraform(t *testing.T) {
+	t.Run("should export aws_dynamodb_table resource", func(t *testing.T) {
+		tb := table.MustNewTable("courses", exportEntity{})
+
+		hcl, err := tb.ExportTerraform("courses")
+		assert.Nil(t, err)
+
+		assert.True(t, strings.HasPrefix(hcl, `resource "aws_dynamodb_table" "courses" {`))
+		assert.Contains(t, hcl, `hash_key       = "PK"`)
+		assert.Contains(t, hcl, `range_key      = "SK"`)
+		assert.Contains(t, hcl, "global_secondary_index {")
+		assert.Contains(t, hcl, "local_secondary_index {")
+		assert.Contains(t, hcl, `attribute_name = "ExpiresAt"`)
+		assert.Equal(t, 4, strings.Count(hcl, "attribute {"))
+	})
+}
+
+func TestTable_ExportInvalid(t *testing.T) {
+	type untyped struct {
+		PK string `diinamo:"hash"`
+	}
+
+	tb := table.MustNewTable("untyped", untyped{})
+
+	t.Run("should refuse to export a key without type", func(t *testing.T) {
+		_, err := tb.ExportCloudFormation("UntypedTable", table.YAML)
+		assert.EqualError(t, err, "table untyped: invalid schema: key PK of the table has no type tag")
+
+		_, err = tb.ExportTerraform("untyped")
+		assert.EqualError(t, err, "table untyped: invalid schema: key PK of the table has no type tag")
+	})
+	t.Run("should describe the attributes without exiting", func(t *testing.T) {
+		definitions := tb.AttributeDefinitions()
+
+		assert.Len(t, definitions, 1)
+		assert.Equal(t, "PK", *definitions[0].AttributeName)
+		assert.Empty(t, definitions[0].AttributeType)
+	})
+}
@@ -0,0 +1,284 @@
+package table
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+	"gopkg.in/yaml.v3"
+)
+
+// cfnTableType é o tipo de recurso de tabelas do DynamoDB no CloudFormation
+const cfnTableType = "AWS::DynamoDB::Table"
+
+// ImportCloudFormationFile lê um template de CloudFormation / SAM (YAML ou
+// JSON) do disco e monta a Table do recurso logicalID.
+//
+// Veja ImportCloudFormation
+func ImportCloudFormationFile(path, logicalID string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read template: %v", err)
+	}
+
+	return ImportCloudFormation(data, logicalID)
+}
+
+// ImportCloudFormation monta uma Table a partir de um recurso
+// AWS::DynamoDB::Table de um template de CloudFormation / SAM, sem a
+// necessidade de uma entidade com as tags diinamo.
+//
+// Se logicalID for vazio o template deve ter apenas uma tabela. Quando o
+// TableName do recurso não for um valor literal (ex: !Sub) o logicalID é
+// usado como nome da tabela.
+func ImportCloudFormation(data []byte, logicalID string) (*Table, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("parse template: %v", err)
+	}
+
+	template, ok := cfnValue(&document).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("template should be an object")
+	}
+
+	resources, _ := template["Resources"].(map[string]interface{})
+
+	var resource map[string]interface{}
+	for id, raw := range resources {
+		candidate, _ := raw.(map[string]interface{})
+		if candidate["Type"] != cfnTableType || (logicalID != "" && id != logicalID) {
+			continue
+		}
+
+		if resource != nil {
+			return nil, errors.New("template has more than one table, inform the logical id")
+		}
+
+		resource = candidate
+		logicalID = id
+	}
+
+	if resource == nil {
+		return nil, fmt.Errorf("%s resource %q not found in template", cfnTableType, logicalID)
+	}
+
+	properties, _ := resource["Properties"].(map[string]interface{})
+
+	return tableFromCfnProperties(logicalID, properties)
+}
+
+// tableFromCfnProperties converte as Properties de um AWS::DynamoDB::Table
+// no TagsModel que seria gerado pelas tags diinamo
+func tableFromCfnProperties(logicalID string, properties map[string]interface{}) (*Table, error) {
+	model := &tagManager.TagsModel{
+		GSI:     []tagManager.GlobalSecIndex{},
+		LSI:     []tagManager.LocalSecIndex{},
+		Types:   map[string]reflect.Kind{},
+		Scalars: map[string]string{},
+	}
+
+	for _, raw := range cfnList(properties["AttributeDefinitions"]) {
+		attr := cfnMap(raw)
+		name := cfnString(attr["AttributeName"])
+		scalar := cfnString(attr["AttributeType"])
+
+		switch scalar {
+		case string(types.ScalarAttributeTypeS):
+			model.Types[name] = reflect.String
+		case string(types.ScalarAttributeTypeN):
+			model.Types[name] = reflect.Int64
+		case string(types.ScalarAttributeTypeB):
+			model.Types[name] = reflect.Slice
+		default:
+			return nil, fmt.Errorf("invalid attribute type for %q", name)
+		}
+
+		model.Scalars[name] = scalar
+	}
+
+	model.Hash, model.Range = cfnKeys(properties["KeySchema"])
+	if model.Hash == "" {
+		return nil, errors.New("table key schema has no HASH key")
+	}
+
+	for _, raw := range cfnList(properties["GlobalSecondaryIndexes"]) {
+		index := cfnMap(raw)
+		gsi := tagManager.GlobalSecIndex{
+			IndexName:             cfnString(index["IndexName"]),
+			Projection:            cfnIndexProjection(index["Projection"]),
+			ProvisionedThroughput: cfnThroughput(index["ProvisionedThroughput"]),
+		}
+		gsi.Hash, gsi.Range = cfnKeys(index["KeySchema"])
+
+		model.GSI = append(model.GSI, gsi)
+	}
+
+	for _, raw := range cfnList(properties["LocalSecondaryIndexes"]) {
+		index := cfnMap(raw)
+		lsi := tagManager.LocalSecIndex{
+			IndexName:             cfnString(index["IndexName"]),
+			Projection:            cfnIndexProjection(index["Projection"]),
+			ProvisionedThroughput: tagManager.ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+		}
+		lsi.Hash, lsi.Range = cfnKeys(index["KeySchema"])
+
+		model.LSI = append(model.LSI, lsi)
+	}
+
+	if ttlSpec := cfnMap(properties["TimeToLiveSpecification"]); cfnBool(ttlSpec["Enabled"]) {
+		model.TTL = cfnString(ttlSpec["AttributeName"])
+	}
+
+	tableName := cfnString(properties["TableName"])
+	if tableName == "" {
+		tableName = logicalID
+	}
+
+	throughput := cfnThroughput(properties["ProvisionedThroughput"])
+
+	t := &Table{
+		TableName:       tableName,
+		BillingMode:     types.BillingModeProvisioned,
+		TableClassMode:  drivers.STANDARD,
+		ReadThroughput:  int32(throughput.ReadCapacity),
+		WriteThroughput: int32(throughput.WriteCapacity),
+	}
+
+	if billing := cfnString(properties["BillingMode"]); billing != "" {
+		t.BillingMode = types.BillingMode(billing)
+	}
+
+	if cfnString(properties["TableClass"]) == string(types.TableClassStandardInfrequentAccess) {
+		t.TableClassMode = drivers.INFREQUENT_ACCESS
+	}
+
+	logg := logger.NewLogger()
+	t.Metadata = &tagManager.TagManager{
+		Log: logg,
+		TagMapper: &tagManager.TagMapper{
+			TagsModel: model,
+			Log:       logg,
+		},
+	}
+
+	return t, nil
+}
+
+// cfnValue converte um nó YAML em valores Go. As funções intrínsecas na
+// forma curta (ex: !Ref Name) viram a forma longa ({"Ref": "Name"})
+func cfnValue(node *yaml.Node) interface{} {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+
+		return cfnValue(node.Content[0])
+	}
+
+	var value interface{}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		mapping := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			mapping[node.Content[i].Value] = cfnValue(node.Content[i+1])
+		}
+		value = mapping
+	case yaml.SequenceNode:
+		var list []interface{}
+		for _, item := range node.Content {
+			list = append(list, cfnValue(item))
+		}
+		value = list
+	case yaml.AliasNode:
+		return cfnValue(node.Alias)
+	default:
+		value = node.Value
+	}
+
+	if strings.HasPrefix(node.Tag, "!") && !strings.HasPrefix(node.Tag, "!!") {
+		function := strings.TrimPrefix(node.Tag, "!")
+		if function != "Ref" && function != "Condition" {
+			function = "Fn::" + function
+		}
+
+		return map[string]interface{}{function: value}
+	}
+
+	return value
+}
+
+func cfnMap(value interface{}) map[string]interface{} {
+	mapping, _ := value.(map[string]interface{})
+	return mapping
+}
+
+func cfnList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+// cfnString retorna o valor literal de uma string, ou vazio caso o valor
+// seja uma função intrínseca
+func cfnString(value interface{}) string {
+	str, _ := value.(string)
+	return str
+}
+
+func cfnBool(value interface{}) bool {
+	enabled, _ := strconv.ParseBool(cfnString(value))
+	return enabled
+}
+
+// cfnKeys retorna os atributos HASH e RANGE de um KeySchema
+func cfnKeys(value interface{}) (hashKey, rangeKey string) {
+	for _, raw := range cfnList(value) {
+		key := cfnMap(raw)
+
+		switch cfnString(key["KeyType"]) {
+		case string(types.KeyTypeHash):
+			hashKey = cfnString(key["AttributeName"])
+		case string(types.KeyTypeRange):
+			rangeKey = cfnString(key["AttributeName"])
+		}
+	}
+
+	return hashKey, rangeKey
+}
+
+// cfnIndexProjection lê a Projection de um índice
+func cfnIndexProjection(value interface{}) tagManager.Projection {
+	spec := cfnMap(value)
+	projection := tagManager.Projection{Type: cfnString(spec["ProjectionType"])}
+
+	for _, attribute := range cfnList(spec["NonKeyAttributes"]) {
+		projection.NonKeyAttributes = append(projection.NonKeyAttributes, cfnString(attribute))
+	}
+
+	return projection
+}
+
+// cfnThroughput lê um ProvisionedThroughput. Valores ausentes ou que não
+// sejam literais usam o padrão de 1 Read Capacity e 1 Write Capacity
+func cfnThroughput(value interface{}) tagManager.ProvisionedThroughput {
+	throughput := tagManager.ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1}
+	spec := cfnMap(value)
+
+	if read, err := strconv.ParseUint(cfnString(spec["ReadCapacityUnits"]), 10, 32); err == nil {
+		throughput.ReadCapacity = uint(read)
+	}
+
+	if write, err := strconv.ParseUint(cfnString(spec["WriteCapacityUnits"]), 10, 32); err == nil {
+		throughput.WriteCapacity = uint(write)
+	}
+
+	return throughput
+}
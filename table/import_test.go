@@ -0,0 +1,79 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/stretchr/testify/assert"
+)
+
+const samTemplate = `
+AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Parameters:
+  Stage:
+    Type: String
+Resources:
+  UsersFunction:
+    Type: AWS::Serverless::Function
+  UsersTable:
+    Type: AWS::DynamoDB::Table
+    Properties:
+      TableName: !Sub "${Stage}-users"
+      BillingMode: PAY_PER_REQUEST
+      TableClass: STANDARD_INFREQUENT_ACCESS
+      AttributeDefinitions:
+        - AttributeName: PK
+          AttributeType: S
+        - AttributeName: SK
+          AttributeType: S
+        - AttributeName: Email
+          AttributeType: S
+      KeySchema:
+        - AttributeName: PK
+          KeyType: HASH
+        - AttributeName: SK
+          KeyType: RANGE
+      GlobalSecondaryIndexes:
+        - IndexName: EmailIndex
+          KeySchema:
+            - AttributeName: Email
+              KeyType: HASH
+          Projection:
+            ProjectionType: ALL
+      TimeToLiveSpecification:
+        AttributeName: ExpiresAt
+        Enabled: true
+`
+
+func TestImportCloudFormation(t *testing.T) {
+	t.Run("should import table from sam template", func(t *testing.T) {
+		tb, err := table.ImportCloudFormation([]byte(samTemplate), "")
+		assert.Nil(t, err)
+
+		assert.Equal(t, "UsersTable", tb.TableName)
+		assert.Equal(t, "PK", tb.GetMetadata().GetHash())
+		assert.Equal(t, "SK", tb.GetMetadata().GetRange())
+		assert.Equal(t, types.BillingModePayPerRequest, tb.Billing())
+		assert.Equal(t, types.TableClassStandardInfrequentAccess, tb.TableClass())
+		assert.Equal(t, "ExpiresAt", tb.TimeToLiveAttribute())
+		assert.Len(t, tb.GetGSI(), 1)
+		assert.Equal(t, "EmailIndex", *tb.GetGSI()[0].IndexName)
+		assert.Len(t, tb.AttributeDefinitions(), 3)
+	})
+	t.Run("should reproduce an exported table", func(t *testing.T) {
+		exported := table.MustNewTable("courses", exportEntity{})
+		template, err := exported.ExportCloudFormation("CoursesTable", table.JSON)
+		assert.Nil(t, err)
+
+		imported, err := table.ImportCloudFormation(template, "CoursesTable")
+		assert.Nil(t, err)
+
+		assert.Equal(t, exported.CloudFormationResource(), imported.CloudFormationResource())
+	})
+	t.Run("should fail when resource is not found", func(t *testing.T) {
+		_, err := table.ImportCloudFormation([]byte(samTemplate), "OrdersTable")
+		assert.EqualError(t, err, `AWS::DynamoDB::Table resource "OrdersTable" not found in template`)
+	})
+}
@@ -1,7 +1,8 @@
 package table
 
 import (
-	"log"
+	"errors"
+	"fmt"
 	"reflect"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -24,12 +25,44 @@ type (
 
 		Metadata tagManager.Manager
 	}
+
+	// Option é uma configuração opcional de NewTable. As options têm
+	// prioridade sobre as tags billing, rcu e wcu da entidade
+	Option func(t *Table)
 )
 
-// NewTable é um construtor e inicializador para uma estrutura Table
-func NewTable(tbName string, tableStruct interface{}) *Table {
-	if reflect.TypeOf(tableStruct).Kind() != reflect.Struct {
-		log.Fatalf("table struct should be a struct")
+// WithPayPerRequest define a cobrança sob demanda (PAY_PER_REQUEST). A
+// tabela e os índices são criados sem ProvisionedThroughput
+func WithPayPerRequest() Option {
+	return func(t *Table) {
+		t.BillingMode = types.BillingModePayPerRequest
+	}
+}
+
+// WithThroughput define a cobrança PROVISIONED com a capacidade de leitura
+// e de escrita da tabela
+func WithThroughput(read, write int32) Option {
+	return func(t *Table) {
+		t.BillingMode = types.BillingModeProvisioned
+		t.ReadThroughput = read
+		t.WriteThroughput = write
+	}
+}
+
+// NewTable é um construtor e inicializador para uma estrutura Table.
+// A cobrança e a capacidade vêm das tags billing, rcu e wcu do campo
+// hash, ou das options. Ex:
+//
+// 	NewTable("users", User{}, WithPayPerRequest())
+// 	NewTable("users", User{}, WithThroughput(5, 10))
+//
+// Sem elas a tabela é PROVISIONED com 1 Read Capacity e 1 Write Capacity.
+//
+// Uma tag diinamo inválida volta como *tagManager.TagError, com a
+// estrutura, o campo e o trecho da tag
+func NewTable(tbName string, tableStruct interface{}, options ...Option) (*Table, error) {
+	if tableStruct == nil || reflect.TypeOf(tableStruct).Kind() != reflect.Struct {
+		return nil, fmt.Errorf("table %s: entity should be a struct, got %T", tbName, tableStruct)
 	}
 
 	t := &Table{
@@ -41,9 +74,37 @@ func NewTable(tbName string, tableStruct interface{}) *Table {
 	}
 
 	t.Metadata = tagManager.NewTagManager().SetEntity(tableStruct)
-	err := t.Metadata.MapTags()
+	if err := t.Metadata.MapTags(); err != nil {
+		return nil, fmt.Errorf("table %s: %w", tbName, err)
+	}
+
+	model := t.Metadata.GetMapper().GetModel()
+	if model.Billing != "" {
+		t.BillingMode = types.BillingMode(model.Billing)
+	}
+
+	if model.Throughput.ReadCapacity > 0 {
+		t.ReadThroughput = int32(model.Throughput.ReadCapacity)
+	}
+
+	if model.Throughput.WriteCapacity > 0 {
+		t.WriteThroughput = int32(model.Throughput.WriteCapacity)
+	}
+
+	for _, option := range options {
+		option(t)
+	}
+
+	return t, nil
+}
+
+// MustNewTable é o NewTable que entra em pânico com uma entidade
+// inválida. Serve para testes e variáveis de pacote. Em uma Lambda,
+// prefira NewTable e trate o erro
+func MustNewTable(tbName string, tableStruct interface{}, options ...Option) *Table {
+	t, err := NewTable(tbName, tableStruct, options...)
 	if err != nil {
-		log.Fatalf("error on map tags")
+		panic(err)
 	}
 
 	return t
@@ -57,54 +118,60 @@ func (t *Table) GetMetadata() tagManager.Manager {
 // AttributeDefinitions é o método que retorna a definição de atributos para a PK.
 // A PK é composta de Hash e Range keys
 func (t *Table) AttributeDefinitions() []types.AttributeDefinition {
-	attrDefinitions := []types.AttributeDefinition{
-		t.getAttrDefinition(t.Metadata.GetHash()),
-		t.getAttrDefinition(t.Metadata.GetRange()),
+	var attrDefinitions []types.AttributeDefinition
+
+	for _, key := range t.KeySchema() {
+		attrDefinitions = t.appendAttrDefinition(attrDefinitions, *key.AttributeName)
 	}
 
 	// Adiciona os atributos de Global Secondary Index às definições
 	// de atributos da Tabela
 	for _, gsi := range t.GetGSI() {
 		for _, key := range gsi.KeySchema {
-			issetField := false
-
-			for _, attr := range attrDefinitions {
-				if *attr.AttributeName == *key.AttributeName && !issetField {
-					issetField = true
-				}
-			}
+			attrDefinitions = t.appendAttrDefinition(attrDefinitions, *key.AttributeName)
+		}
+	}
 
-			// Se o atributo não estiver na lista de atributos, adiciona
-			if !issetField {
-				attrDefinitions = append(attrDefinitions, t.getAttrDefinition(*key.AttributeName))
-			}
+	// Adiciona os atributos de Local Secondary Index às definições
+	// de atributos da Tabela
+	for _, lsi := range t.GetLSI() {
+		for _, key := range lsi.KeySchema {
+			attrDefinitions = t.appendAttrDefinition(attrDefinitions, *key.AttributeName)
 		}
 	}
 
 	return attrDefinitions
 }
 
+// appendAttrDefinition adiciona a definição de atributo de key caso
+// ela ainda não esteja na lista de atributos
+func (t *Table) appendAttrDefinition(attrDefinitions []types.AttributeDefinition, key string) []types.AttributeDefinition {
+	for _, attr := range attrDefinitions {
+		if *attr.AttributeName == key {
+			return attrDefinitions
+		}
+	}
+
+	return append(attrDefinitions, t.getAttrDefinition(key))
+}
+
 // KeySchema é o método que retorna o schema de chaves que compõe a PK
 func (t *Table) KeySchema() []types.KeySchemaElement {
 	return t.getKeySchema()
 }
 
 // getAttrDefinition é um método que retorna o types.AttributeDefinition de uma
-// chave específica contida na entity de Metadata
+// chave específica contida na entity de Metadata. O tipo segue o que o
+// codec grava para o campo, veja tagManager.ScalarType. Uma chave sem tag
+// type fica sem tipo, o erro é reportado por Validate
 func (t *Table) getAttrDefinition(key string) types.AttributeDefinition {
-	mapHashType := t.Metadata.GetType(key)
-
-	if mapHashType == reflect.Invalid {
-		log.Fatalf("invalid key attr definition")
-	}
-
-	hashType := types.ScalarAttributeTypeS
-	switch mapHashType {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64:
+	var hashType types.ScalarAttributeType
+	switch t.Metadata.GetMapper().GetModel().ScalarType(key) {
+	case tagManager.ScalarString:
+		hashType = types.ScalarAttributeTypeS
+	case tagManager.ScalarNumber:
 		hashType = types.ScalarAttributeTypeN
-	case reflect.Map, reflect.Slice, reflect.Array:
+	case tagManager.ScalarBinary:
 		hashType = types.ScalarAttributeTypeB
 	}
 
@@ -142,22 +209,63 @@ func (t *Table) Billing() types.BillingMode {
 }
 
 // ProvisionedThroughput é o método que retorna o ponteiro de configuração
-// do Throughput da tabela.
+// do Throughput da tabela, ou nil em tabelas PAY_PER_REQUEST.
 //
 // Os padrões de provisionamento são 1 Read Capacity e 1 Write Capacity
 func (t *Table) ProvisionedThroughput() *types.ProvisionedThroughput {
+	if t.BillingMode == types.BillingModePayPerRequest {
+		return nil
+	}
+
 	return &types.ProvisionedThroughput{
 		ReadCapacityUnits:  aws.Int64(int64(t.ReadThroughput)),
 		WriteCapacityUnits: aws.Int64(int64(t.WriteThroughput)),
 	}
 }
 
+// Validate verifica o schema da tabela contra as regras do DynamoDB antes
+// de qualquer chamada à AWS. Além das regras de tagManager.TagsModel.Validate,
+// uma tabela PROVISIONED precisa de ao menos 1 Read e 1 Write Capacity
+func (t *Table) Validate() error {
+	var violations []string
+
+	if err := t.Metadata.GetMapper().GetModel().Validate(); err != nil {
+		var schemaErr *tagManager.SchemaError
+		if !errors.As(err, &schemaErr) {
+			return fmt.Errorf("table %s: %w", t.TableName, err)
+		}
+
+		violations = append(violations, schemaErr.Violations...)
+	}
+
+	if t.BillingMode != types.BillingModePayPerRequest && (t.ReadThroughput < 1 || t.WriteThroughput < 1) {
+		violations = append(violations, fmt.Sprintf("a provisioned table requires at least 1 read and 1 write capacity, got %d and %d", t.ReadThroughput, t.WriteThroughput))
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("table %s: %w", t.TableName, &tagManager.SchemaError{Violations: violations})
+	}
+
+	return nil
+}
+
 // TableClass é o método que retorna o TableClass da tabela
 func (t *Table) TableClass() types.TableClass {
+	if t.TableClassMode == drivers.INFREQUENT_ACCESS {
+		return types.TableClassStandardInfrequentAccess
+	}
+
 	return types.TableClass(t.TableClassMode)
 }
 
-// GetGSI é o método que monta e retorna os GlobalSecondaryIndex
+// TimeToLiveAttribute é o método que retorna o atributo marcado
+// com a tag ttl, ou vazio caso a tabela não tenha TTL
+func (t *Table) TimeToLiveAttribute() string {
+	return t.GetMetadata().GetMapper().GetModel().TTL
+}
+
+// GetGSI é o método que monta e retorna os GlobalSecondaryIndex. Em
+// tabelas PAY_PER_REQUEST os índices não têm ProvisionedThroughput
 func (t *Table) GetGSI() []types.GlobalSecondaryIndex {
 	var GSIs []types.GlobalSecondaryIndex
 
@@ -176,13 +284,14 @@ func (t *Table) GetGSI() []types.GlobalSecondaryIndex {
 					KeyType:       types.KeyTypeHash,
 				},
 			},
-			ProvisionedThroughput: &types.ProvisionedThroughput{
+			Projection: indexProjection(gsi.Projection),
+		}
+
+		if t.BillingMode != types.BillingModePayPerRequest {
+			parseGSI.ProvisionedThroughput = &types.ProvisionedThroughput{
 				ReadCapacityUnits:  aws.Int64(int64(gsi.ProvisionedThroughput.ReadCapacity)),
 				WriteCapacityUnits: aws.Int64(int64(gsi.ProvisionedThroughput.WriteCapacity)),
-			},
-			Projection: &types.Projection{
-				ProjectionType: types.ProjectionType("ALL"),
-			},
+			}
 		}
 
 		if gsi.Range != "" {
@@ -213,9 +322,7 @@ func (t *Table) GetLSI() []types.LocalSecondaryIndex {
 					KeyType:       types.KeyTypeHash,
 				},
 			},
-			Projection: &types.Projection{
-				ProjectionType: types.ProjectionType("ALL"),
-			},
+			Projection: indexProjection(lsi.Projection),
 		}
 
 		if lsi.Range != "" {
@@ -231,6 +338,19 @@ func (t *Table) GetLSI() []types.LocalSecondaryIndex {
 	return LSIs
 }
 
+// indexProjection monta a Projection do índice a partir da tag
+// projection. Sem a tag, todos os atributos são projetados
+func indexProjection(projection tagManager.Projection) *types.Projection {
+	if projection.Type == "" {
+		return &types.Projection{ProjectionType: types.ProjectionTypeAll}
+	}
+
+	return &types.Projection{
+		ProjectionType:   types.ProjectionType(projection.Type),
+		NonKeyAttributes: projection.NonKeyAttributes,
+	}
+}
+
 func GenTable() *dynamodb.CreateTableInput {
 	return &dynamodb.CreateTableInput{
 		AttributeDefinitions: []types.AttributeDefinition{},
@@ -0,0 +1,115 @@
+package table_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+	"github.com/stretchr/testify/assert"
+)
+
+type capacityEntity struct {
+	PK    string `diinamo:"type:string;hash;rcu:5;wcu:10"`
+	SK    string `diinamo:"type:string;range"`
+	Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=SK;rcu:2;wcu:3"`
+}
+
+type onDemandEntity struct {
+	PK    string `diinamo:"type:string;hash;billing:on-demand"`
+	Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=PK"`
+}
+
+func TestNewTable_Capacity(t *testing.T) {
+	t.Run("should use the capacity of the tags", func(t *testing.T) {
+		tb := table.MustNewTable("courses", capacityEntity{})
+
+		assert.Equal(t, types.BillingModeProvisioned, tb.Billing())
+		assert.Equal(t, int64(5), *tb.ProvisionedThroughput().ReadCapacityUnits)
+		assert.Equal(t, int64(10), *tb.ProvisionedThroughput().WriteCapacityUnits)
+		assert.Equal(t, int64(2), *tb.GetGSI()[0].ProvisionedThroughput.ReadCapacityUnits)
+		assert.Equal(t, int64(3), *tb.GetGSI()[0].ProvisionedThroughput.WriteCapacityUnits)
+	})
+	t.Run("should omit the throughput of on-demand tables", func(t *testing.T) {
+		tb := table.MustNewTable("courses", onDemandEntity{})
+
+		assert.Equal(t, types.BillingModePayPerRequest, tb.Billing())
+		assert.Nil(t, tb.ProvisionedThroughput())
+		assert.Nil(t, tb.GetGSI()[0].ProvisionedThroughput)
+	})
+	t.Run("should prefer the options over the tags", func(t *testing.T) {
+		tb := table.MustNewTable("courses", capacityEntity{}, table.WithPayPerRequest())
+		assert.Nil(t, tb.ProvisionedThroughput())
+		assert.Nil(t, tb.GetGSI()[0].ProvisionedThroughput)
+
+		tb = table.MustNewTable("courses", onDemandEntity{}, table.WithThroughput(7, 8))
+		assert.Equal(t, types.BillingModeProvisioned, tb.Billing())
+		assert.Equal(t, int64(7), *tb.ProvisionedThroughput().ReadCapacityUnits)
+		assert.Equal(t, int64(1), *tb.GetGSI()[0].ProvisionedThroughput.ReadCapacityUnits)
+	})
+}
+
+func TestNewTable_Errors(t *testing.T) {
+	t.Run("should return the error of an invalid tag", func(t *testing.T) {
+		type invalid struct {
+			PK string `diinamo:"type:string;hash"`
+			SK string `diinamo:"type:string;gsi:SortIndex;keyPairs:SK="`
+		}
+
+		tb, err := table.NewTable("courses", invalid{})
+
+		var tagErr *tagManager.TagError
+		assert.Nil(t, tb)
+		assert.ErrorAs(t, err, &tagErr)
+		assert.EqualError(t, err, `table courses: diinamo tag of invalid.SK: invalid keyPairs "SK=", expected HASH=RANGE (token "keyPairs:SK=")`)
+	})
+	t.Run("should refuse an entity that is not a struct", func(t *testing.T) {
+		_, err := table.NewTable("courses", "course")
+		assert.EqualError(t, err, "table courses: entity should be a struct, got string")
+
+		_, err = table.NewTable("courses", nil)
+		assert.EqualError(t, err, "table courses: entity should be a struct, got <nil>")
+	})
+	t.Run("should panic in MustNewTable", func(t *testing.T) {
+		assert.Panics(t, func() { table.MustNewTable("courses", 1) })
+	})
+}
+
+func TestTable_IndexRoles(t *testing.T) {
+	type item struct {
+		PK     string `diinamo:"type:string;hash"`
+		SK     string `diinamo:"type:string;range;gsi:GSI1=range"`
+		GSI1PK string `diinamo:"type:string;gsi:GSI1=hash,GSI2=range"`
+		GSI2PK string `diinamo:"type:string;gsi:GSI2=hash"`
+	}
+
+	tb := table.MustNewTable("items", item{})
+
+	assert.Len(t, tb.GetGSI(), 2)
+	assert.Equal(t, "GSI1PK", *tb.GetGSI()[0].KeySchema[0].AttributeName)
+	assert.Equal(t, "SK", *tb.GetGSI()[0].KeySchema[1].AttributeName)
+	assert.Equal(t, "GSI2PK", *tb.GetGSI()[1].KeySchema[0].AttributeName)
+	assert.Equal(t, "GSI1PK", *tb.GetGSI()[1].KeySchema[1].AttributeName)
+	assert.Len(t, tb.AttributeDefinitions(), 4)
+}
+
+func TestTable_Validate(t *testing.T) {
+	t.Run("should accept a valid table", func(t *testing.T) {
+		assert.Nil(t, table.MustNewTable("courses", capacityEntity{}).Validate())
+		assert.Nil(t, table.MustNewTable("courses", onDemandEntity{}).Validate())
+	})
+	t.Run("should return the violations of the schema and of the capacity", func(t *testing.T) {
+		type invalid struct {
+			PK    string `diinamo:"type:string;hash"`
+			Owner string `diinamo:"gsi:OwnerIndex;keyPairs:Owner"`
+			Date  string `diinamo:"type:string;lsi:DateIndex;keyPairs:Owner=Date"`
+		}
+
+		err := table.MustNewTable("courses", invalid{}, table.WithThroughput(0, 1)).Validate()
+
+		var schemaErr *tagManager.SchemaError
+		assert.ErrorAs(t, err, &schemaErr)
+		assert.EqualError(t, err, "table courses: invalid schema: lsi DateIndex has the hash key Owner, expected the table hash key PK; "+
+			"key Owner of gsi OwnerIndex has no type tag; a provisioned table requires at least 1 read and 1 write capacity, got 0 and 1")
+	})
+}
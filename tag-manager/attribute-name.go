@@ -0,0 +1,47 @@
+package tagManager
+
+import (
+	"reflect"
+	"strings"
+)
+
+// AttributeName retorna o nome do atributo no DynamoDB de um campo. A
+// ordem de prioridade é a opção name da tag diinamo, o nome da tag
+// dynamodbav e, por último, o nome do campo Go. Ex:
+//
+// 	PK string `diinamo:"type:string;hash;name:pk"` // pk
+// 	SK string `dynamodbav:"sk"`                     // sk
+// 	Title string                                   // Title
+func AttributeName(field reflect.StructField) string {
+	if tagName := FieldOptions(field).Value(name); tagName != "" {
+		return tagName
+	}
+
+	if avName := dynamodbavName(field); avName != "" && avName != "-" {
+		return avName
+	}
+
+	return field.Name
+}
+
+// Ignored indica se o campo está fora do item, com dynamodbav:"-"
+func Ignored(field reflect.StructField) bool {
+	return dynamodbavName(field) == "-"
+}
+
+func dynamodbavName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("dynamodbav"), ",")[0]
+}
+
+// attributeName traduz o nome de um campo da estrutura para o nome do
+// atributo. Nomes que não são campos, como nomes de atributo já
+// traduzidos, são devolvidos sem alteração
+func (t *TagMapper) attributeName(key string) string {
+	for _, field := range t.FieldList {
+		if field.Name == key {
+			return AttributeName(field)
+		}
+	}
+
+	return key
+}
@@ -0,0 +1,55 @@
+package tagManager
+
+import (
+	"reflect"
+	"sync"
+)
+
+type (
+	// TagOptions são as opções da tag diinamo de um campo verificadas por
+	// ParseTag, indexadas pela chave. As flags têm o valor vazio
+	TagOptions map[string]string
+)
+
+var (
+	// optionsCache guarda as opções já verificadas de cada tag diinamo
+	optionsCache sync.Map
+)
+
+// FieldOptions devolve as opções da tag diinamo do campo. As tags são
+// verificadas uma vez por ParseTag e guardadas em cache. Uma tag
+// inválida não tem opções, o erro é devolvido por TagsLoop
+func FieldOptions(field reflect.StructField) TagOptions {
+	inlineTags, ok := field.Tag.Lookup("diinamo")
+	if !ok {
+		return nil
+	}
+
+	if cached, ok := optionsCache.Load(inlineTags); ok {
+		return cached.(TagOptions)
+	}
+
+	options := TagOptions{}
+	if tagsPair, _, err := ParseTag(inlineTags); err == nil {
+		for _, option := range tagsPair {
+			key, value, _ := splitOption(option)
+			options[key] = value
+		}
+	}
+
+	optionsCache.Store(inlineTags, options)
+
+	return options
+}
+
+// Value devolve o valor de uma opção key:value, ou "" quando a opção não
+// foi declarada
+func (o TagOptions) Value(key string) string {
+	return o[key]
+}
+
+// Has indica se a opção, flag ou key:value, foi declarada
+func (o TagOptions) Has(option string) bool {
+	_, ok := o[option]
+	return ok
+}
@@ -0,0 +1,247 @@
+package tagManager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A tag diinamo segue a gramática:
+//
+// 	tag    = option { ";" option }
+// 	option = flag | key ":" value
+// 	flag   = "hash" | "range" | "ttl" | "required"
+// 	key    = "type" | "name" | "gsi" | "lsi" | "keyPairs" | "projection" |
+// 	         "rcu" | "wcu" | "billing" | "min" | "max" | "enum" | "pattern"
+//
+// O valor vai até o próximo ";" e pode conter ":", como em pattern. Os
+// valores aceitos são:
+//
+// 	type       string, number ou binary
+// 	gsi e lsi  NAME, com keyPairs, ou papéis NAME=hash,OTHER=range
+// 	keyPairs   HASH=RANGE, ou apenas HASH em um gsi
+// 	projection keys, all ou include(A,B)
+// 	billing    on-demand ou provisioned
+// 	rcu e wcu  inteiros positivos
+//
+// Com papéis, um campo participa de vários índices e cada índice reúne as
+// chaves declaradas em campos diferentes. Ex:
+//
+// 	GSI1PK string `diinamo:"type:string;gsi:GSI1=hash,GSI2=range"`
+// 	GSI2PK string `diinamo:"type:string;gsi:GSI2=hash;projection:keys"`
+//
+// O papel em um lsi é sempre range, já que o hash é o da tabela. A
+// projection, o rcu e o wcu valem para os índices em que o campo é o hash
+// e, nos lsi, o range
+//
+// Uma opção declarada duas vezes é um erro. Opções desconhecidas geram um
+// aviso e são ignoradas
+
+type (
+	// TagError é o erro de uma tag diinamo inválida, com a estrutura, o
+	// campo e, quando existe, o trecho (Token) da tag que causou o erro
+	TagError struct {
+		Struct string
+		Field  string
+		Token  string
+		Err    error
+	}
+
+	// indexRole é o papel, hash ou range, de um campo em um índice
+	indexRole struct {
+		index string
+		key   string
+	}
+)
+
+var (
+	flagOptions  = map[string]bool{hash: true, _range: true, ttl: true, Required: true}
+	valueOptions = map[string]bool{
+		_type: true, name: true, gsi: true, lsi: true, keyPairs: true, projection: true,
+		rcu: true, wcu: true, billing: true, Min: true, Max: true, Enum: true, Pattern: true,
+	}
+	attributeTypes = map[string]bool{"string": true, "number": true, "binary": true}
+)
+
+func (e *TagError) Error() string {
+	message := fmt.Sprintf("diinamo tag of %s.%s: %v", e.Struct, e.Field, e.Err)
+	if e.Token != "" {
+		message += fmt.Sprintf(" (token %q)", e.Token)
+	}
+
+	return message
+}
+
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// ParseTag verifica a tag diinamo de um campo segundo a gramática do
+// pacote e devolve as opções, no formato key:value recebido pelos
+// TagHandler, e as opções desconhecidas. O erro é um *TagError sem
+// Struct e Field, que são preenchidos por TagsLoop
+func ParseTag(inlineTags string) (options []string, unknown []string, err error) {
+	declared := map[string]string{}
+
+	for _, token := range strings.Split(inlineTags, ";") {
+		option := strings.TrimSpace(token)
+		key, value, hasValue := splitOption(option)
+
+		switch {
+		case option == "":
+			return nil, nil, &TagError{Token: token, Err: errors.New("empty option")}
+		case flagOptions[key] && hasValue:
+			return nil, nil, &TagError{Token: option, Err: fmt.Errorf("%s does not accept a value", key)}
+		case valueOptions[key] && value == "":
+			return nil, nil, &TagError{Token: option, Err: fmt.Errorf("%s requires a value", key)}
+		case !flagOptions[key] && !valueOptions[key]:
+			unknown = append(unknown, option)
+			continue
+		}
+
+		if _, ok := declared[key]; ok {
+			return nil, nil, &TagError{Token: option, Err: fmt.Errorf("%s is declared twice", key)}
+		}
+
+		if err := checkOptionValue(key, value); err != nil {
+			return nil, nil, &TagError{Token: option, Err: err}
+		}
+
+		declared[key] = value
+		options = append(options, option)
+	}
+
+	if err := checkIndexOptions(declared); err != nil {
+		return nil, nil, err
+	}
+
+	return options, unknown, nil
+}
+
+// checkOptionValue verifica os valores com formato fixo. Os valores das
+// regras de validação, da projection e da capacidade são verificados
+// pelos TagHandler
+func checkOptionValue(key, value string) error {
+	switch key {
+	case _type:
+		if !attributeTypes[value] {
+			return fmt.Errorf("invalid type %q, expected string, number or binary", value)
+		}
+	case gsi, lsi:
+		if isIndexRoles(value) {
+			_, err := parseIndexRoles(key, value)
+			return err
+		}
+	case keyPairs:
+		hashKey, rangeKey, hasRange := splitKeyPairs(value)
+		if hashKey == "" || (hasRange && rangeKey == "") || strings.Count(value, "=") > 1 {
+			return fmt.Errorf("invalid keyPairs %q, expected HASH=RANGE", value)
+		}
+	}
+
+	return nil
+}
+
+// checkIndexOptions verifica as opções que dependem de um gsi ou lsi
+func checkIndexOptions(declared map[string]string) error {
+	gsiValue, isGSI := declared[gsi]
+	lsiValue, isLSI := declared[lsi]
+	pairs, hasKeyPairs := declared[keyPairs]
+
+	gsiRoles := isGSI && isIndexRoles(gsiValue)
+	lsiRoles := isLSI && isIndexRoles(lsiValue)
+	withRoles := gsiRoles || lsiRoles
+	withKeyPairs := (isGSI && !gsiRoles) || (isLSI && !lsiRoles)
+
+	switch {
+	case withKeyPairs && withRoles:
+		return &TagError{Err: errors.New("an index with keyPairs cannot be combined with index roles")}
+	case withKeyPairs && isGSI && isLSI:
+		return &TagError{Err: errors.New("a field with keyPairs cannot declare both a gsi and a lsi")}
+	case withKeyPairs && !hasKeyPairs:
+		return &TagError{Err: errors.New("an index requires keyPairs or index roles")}
+	case hasKeyPairs && withRoles:
+		return &TagError{Token: keyPairs + ":" + pairs, Err: errors.New("keyPairs cannot be combined with index roles")}
+	case hasKeyPairs && !withKeyPairs:
+		return &TagError{Token: keyPairs + ":" + pairs, Err: errors.New("keyPairs requires a gsi or a lsi")}
+	}
+
+	if _, _, hasRange := splitKeyPairs(pairs); isLSI && !lsiRoles && !hasRange {
+		return &TagError{Token: keyPairs + ":" + pairs, Err: errors.New("a lsi requires a range key, expected HASH=RANGE")}
+	}
+
+	ownsGSI := isGSI && (!gsiRoles || hasHashRole(gsiValue))
+
+	if projectionValue, ok := declared[projection]; ok && !ownsGSI && !isLSI {
+		return &TagError{Token: projection + ":" + projectionValue, Err: errors.New("projection requires a gsi or a lsi where the field is the hash key")}
+	}
+
+	for _, capacity := range []string{rcu, wcu} {
+		if value, ok := declared[capacity]; ok && gsiRoles && !ownsGSI {
+			return &TagError{Token: capacity + ":" + value, Err: fmt.Errorf("%s requires a gsi where the field is the hash key", capacity)}
+		}
+	}
+
+	return nil
+}
+
+// isIndexRoles indica se o valor de gsi ou lsi é uma lista de papéis
+func isIndexRoles(value string) bool {
+	return strings.Contains(value, "=")
+}
+
+// hasHashRole indica se a lista de papéis tem algum papel hash
+func hasHashRole(value string) bool {
+	roles, _ := parseIndexRoles(gsi, value)
+	for _, role := range roles {
+		if role.key == hash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseIndexRoles lê os papéis NAME=hash,OTHER=range de um gsi ou lsi
+func parseIndexRoles(kind, value string) ([]indexRole, error) {
+	var roles []indexRole
+	seen := map[string]bool{}
+
+	for _, membership := range strings.Split(value, ",") {
+		index, key, _ := splitKeyPairs(strings.TrimSpace(membership))
+
+		switch {
+		case index == "" || (key != hash && key != _range):
+			return nil, fmt.Errorf("invalid index role %q, expected NAME=hash or NAME=range", membership)
+		case seen[index]:
+			return nil, fmt.Errorf("%s %s is declared twice", kind, index)
+		case kind == lsi && key == hash:
+			return nil, fmt.Errorf("the hash key of lsi %s is the hash key of the table, expected %s=range", index, index)
+		}
+
+		seen[index] = true
+		roles = append(roles, indexRole{index: index, key: key})
+	}
+
+	return roles, nil
+}
+
+// splitOption separa a chave e o valor de uma opção key:value
+func splitOption(option string) (key, value string, hasValue bool) {
+	keyValue := strings.SplitN(option, ":", 2)
+	if len(keyValue) == 1 {
+		return keyValue[0], "", false
+	}
+
+	return keyValue[0], keyValue[1], true
+}
+
+// splitKeyPairs separa o HASH e o RANGE de keyPairs:HASH=RANGE
+func splitKeyPairs(value string) (hashKey, rangeKey string, hasRange bool) {
+	pairs := strings.SplitN(value, "=", 2)
+	if len(pairs) == 1 {
+		return pairs[0], "", false
+	}
+
+	return pairs[0], pairs[1], true
+}
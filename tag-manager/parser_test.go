@@ -0,0 +1,203 @@
+package tagManager_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTag(t *testing.T) {
+	t.Run("should return the options and the unknown options", func(t *testing.T) {
+		options, unknown, err := tagManager.ParseTag("type:string; gsi:OwnerIndex;keyPairs:Owner=SK;pattern:^[a-z]+:[0-9]+$;sparse")
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"type:string", "gsi:OwnerIndex", "keyPairs:Owner=SK", "pattern:^[a-z]+:[0-9]+$"}, options)
+		assert.Equal(t, []string{"sparse"}, unknown)
+	})
+	t.Run("should accept a gsi without range key", func(t *testing.T) {
+		_, _, err := tagManager.ParseTag("type:string;gsi:OwnerIndex;keyPairs:Owner")
+		assert.Nil(t, err)
+	})
+	t.Run("should fail with the offending token", func(t *testing.T) {
+		cases := []struct {
+			tag   string
+			err   string
+			token string
+		}{
+			{"type:string;;hash", "empty option", ""},
+			{"hash:yes", "hash does not accept a value", "hash:yes"},
+			{"type", "type requires a value", "type"},
+			{"name:", "name requires a value", "name:"},
+			{"type:text", `invalid type "text", expected string, number or binary`, "type:text"},
+			{"type:string;type:number", "type is declared twice", "type:number"},
+			{"gsi:OwnerIndex;keyPairs:=SK", `invalid keyPairs "=SK", expected HASH=RANGE`, "keyPairs:=SK"},
+			{"gsi:OwnerIndex;keyPairs:A=B=C", `invalid keyPairs "A=B=C", expected HASH=RANGE`, "keyPairs:A=B=C"},
+			{"gsi:OwnerIndex", "an index requires keyPairs or index roles", ""},
+			{"keyPairs:PK=SK", "keyPairs requires a gsi or a lsi", "keyPairs:PK=SK"},
+			{"lsi:DateIndex;keyPairs:PK", "a lsi requires a range key, expected HASH=RANGE", "keyPairs:PK"},
+			{"gsi:A;lsi:B;keyPairs:PK=SK", "a field with keyPairs cannot declare both a gsi and a lsi", ""},
+			{"projection:keys", "projection requires a gsi or a lsi where the field is the hash key", "projection:keys"},
+			{"gsi:GSI1=hash,GSI2", `invalid index role "GSI2", expected NAME=hash or NAME=range`, "gsi:GSI1=hash,GSI2"},
+			{"gsi:GSI1=hash,GSI1=range", "gsi GSI1 is declared twice", "gsi:GSI1=hash,GSI1=range"},
+			{"lsi:DateIndex=hash", "the hash key of lsi DateIndex is the hash key of the table, expected DateIndex=range", "lsi:DateIndex=hash"},
+			{"gsi:GSI1=hash;keyPairs:PK=SK", "keyPairs cannot be combined with index roles", "keyPairs:PK=SK"},
+			{"gsi:GSI1=hash;lsi:DateIndex;keyPairs:PK=SK", "an index with keyPairs cannot be combined with index roles", ""},
+			{"gsi:GSI1=range;projection:keys", "projection requires a gsi or a lsi where the field is the hash key", "projection:keys"},
+			{"gsi:GSI1=range;rcu:5", "rcu requires a gsi where the field is the hash key", "rcu:5"},
+		}
+
+		for _, c := range cases {
+			_, _, err := tagManager.ParseTag(c.tag)
+
+			var tagErr *tagManager.TagError
+			if assert.ErrorAs(t, err, &tagErr, c.tag) {
+				assert.EqualError(t, tagErr.Err, c.err, c.tag)
+				assert.Equal(t, c.token, tagErr.Token, c.tag)
+			}
+		}
+	})
+}
+
+func TestFieldOptions(t *testing.T) {
+	type item struct {
+		PK        string `diinamo:"type:string; hash; name:pk"`
+		ExpiresAt int64  `diinamo:"type:number;ttl"`
+		Invalid   string `diinamo:"type:text;name:invalid"`
+		Title     string
+	}
+
+	entity := reflect.TypeOf(item{})
+	field := func(name string) reflect.StructField {
+		f, _ := entity.FieldByName(name)
+		return f
+	}
+
+	t.Run("should share the options parsed by ParseTag", func(t *testing.T) {
+		options := tagManager.FieldOptions(field("PK"))
+
+		assert.Equal(t, tagManager.TagOptions{"type": "string", "hash": "", "name": "pk"}, options)
+		assert.True(t, options.Has("hash"))
+		assert.False(t, options.Has("ttl"))
+		assert.Equal(t, "pk", options.Value("name"))
+		assert.Equal(t, "pk", tagManager.AttributeName(field("PK")))
+		assert.True(t, tagManager.FieldOptions(field("ExpiresAt")).Has("ttl"))
+	})
+	t.Run("should have no options without a valid tag", func(t *testing.T) {
+		assert.Empty(t, tagManager.FieldOptions(field("Invalid")))
+		assert.Equal(t, "Invalid", tagManager.AttributeName(field("Invalid")))
+		assert.Nil(t, tagManager.FieldOptions(field("Title")))
+	})
+}
+
+func TestTagMapper_TagError(t *testing.T) {
+	type course struct {
+		PK    string `diinamo:"type:string;hash"`
+		Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner"`
+		Level string `diinamo:"type:string;lsi:LevelIndex;keyPairs:PK"`
+	}
+
+	t.Run("should report the struct, the field and the token", func(t *testing.T) {
+		tm := &tagManager.TagMapper{
+			PropertyTypes: reflect.TypeOf(course{}),
+			Log:           logger.NewLogger(),
+		}
+
+		err := tm.RunMap()
+
+		var tagErr *tagManager.TagError
+		assert.ErrorAs(t, err, &tagErr)
+		assert.Equal(t, "course", tagErr.Struct)
+		assert.Equal(t, "Level", tagErr.Field)
+		assert.EqualError(t, err, `diinamo tag of course.Level: a lsi requires a range key, expected HASH=RANGE (token "keyPairs:PK")`)
+	})
+	t.Run("should wrap the errors of the handlers", func(t *testing.T) {
+		type capacity struct {
+			PK string `diinamo:"type:string;hash;rcu:many"`
+		}
+
+		tm := &tagManager.TagMapper{
+			PropertyTypes: reflect.TypeOf(capacity{}),
+			Log:           logger.NewLogger(),
+		}
+
+		err := tm.RunMap()
+
+		var tagErr *tagManager.TagError
+		assert.ErrorAs(t, err, &tagErr)
+		assert.EqualError(t, errors.Unwrap(err), `rcu of field PK requires a positive number, got "many"`)
+	})
+}
+
+func TestTagMapper_IndexRoles(t *testing.T) {
+	type item struct {
+		PK     string `diinamo:"type:string;hash"`
+		SK     string `diinamo:"type:string;range;gsi:GSI1=range"`
+		GSI1PK string `diinamo:"type:string;gsi:GSI1=hash,GSI2=range;projection:keys;rcu:3"`
+		GSI2PK string `diinamo:"type:string;gsi:GSI2=hash;name:gsi2pk"`
+		Date   string `diinamo:"type:string;lsi:DateIndex=range;projection:include(GSI2PK)"`
+	}
+
+	newMapper := func(entity interface{}) *tagManager.TagMapper {
+		return &tagManager.TagMapper{PropertyTypes: reflect.TypeOf(entity), Log: logger.NewLogger()}
+	}
+
+	t.Run("should group the index roles by index name", func(t *testing.T) {
+		tm := newMapper(item{})
+		assert.Nil(t, tm.RunMap())
+
+		assert.Equal(t, []tagManager.GlobalSecIndex{
+			{
+				IndexName:             "GSI1",
+				Hash:                  "GSI1PK",
+				Range:                 "SK",
+				Projection:            tagManager.Projection{Type: tagManager.ProjectionKeysOnly},
+				ProvisionedThroughput: tagManager.ProvisionedThroughput{ReadCapacity: 3, WriteCapacity: 1},
+			},
+			{
+				IndexName:             "GSI2",
+				Hash:                  "gsi2pk",
+				Range:                 "GSI1PK",
+				ProvisionedThroughput: tagManager.ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+			},
+		}, tm.GSI)
+		assert.Equal(t, []tagManager.LocalSecIndex{{
+			IndexName:             "DateIndex",
+			Hash:                  "PK",
+			Range:                 "Date",
+			Projection:            tagManager.Projection{Type: tagManager.ProjectionInclude, NonKeyAttributes: []string{"gsi2pk"}},
+			ProvisionedThroughput: tagManager.ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+		}}, tm.LSI)
+	})
+	t.Run("should fail when an index has two keys with the same role", func(t *testing.T) {
+		type twoHashes struct {
+			PK string `diinamo:"type:string;hash;gsi:GSI1=hash"`
+			SK string `diinamo:"type:string;range;gsi:GSI1=hash"`
+		}
+
+		err := newMapper(twoHashes{}).RunMap()
+		assert.EqualError(t, err, "diinamo tag of twoHashes.SK: gsi GSI1 has two hash keys: PK and SK")
+	})
+	t.Run("should fail when a gsi has no hash key", func(t *testing.T) {
+		type noHash struct {
+			PK string `diinamo:"type:string;hash"`
+			SK string `diinamo:"type:string;range;gsi:GSI1=range"`
+		}
+
+		err := newMapper(noHash{}).RunMap()
+		assert.EqualError(t, err, "diinamo tags of noHash: gsi GSI1 has no hash key, expected a field with GSI1=hash")
+	})
+	t.Run("should fail when a role conflicts with keyPairs", func(t *testing.T) {
+		type twice struct {
+			PK    string `diinamo:"type:string;hash"`
+			Owner string `diinamo:"type:string;gsi:OwnerIndex;keyPairs:Owner=PK"`
+			Title string `diinamo:"type:string;gsi:OwnerIndex=hash"`
+		}
+
+		err := newMapper(twice{}).RunMap()
+		assert.EqualError(t, err, "diinamo tag of twice.Title: gsi OwnerIndex has two hash keys: Owner and Title")
+	})
+}
@@ -0,0 +1,86 @@
+package tagManager
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Tipos escalares aceitos pelo DynamoDB nas chaves
+const (
+	ScalarString = "S"
+	ScalarNumber = "N"
+	ScalarBinary = "B"
+)
+
+type (
+	// marshaler é o domain.Marshaler, que não pode ser importado aqui
+	// porque o pacote domain depende deste
+	marshaler interface {
+		MarshalDiinamo() (types.AttributeValue, error)
+	}
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*marshaler)(nil)).Elem()
+	declaredTypes = map[string]string{"string": ScalarString, "number": ScalarNumber, "binary": ScalarBinary}
+)
+
+// ScalarType resolve o tipo escalar que o codec de expressions grava para
+// o campo. Os ponteiros valem pelo tipo apontado, time.Time é S, ou N com
+// ttl ou dynamodbav:",unixtime", e os tipos que implementam
+// domain.Marshaler valem pela tag type. Retorna "" quando o valor não é
+// gravado como S, N ou B, como em []string (SS), maps e structs
+func ScalarType(field reflect.StructField) string {
+	fieldType := field.Type
+	if fieldType.Implements(marshalerType) || reflect.PtrTo(fieldType).Implements(marshalerType) {
+		return declaredTypes[FieldOptions(field).Value(_type)]
+	}
+
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return ScalarString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return ScalarNumber
+	case reflect.Slice, reflect.Array:
+		if fieldType.Elem().Kind() == reflect.Uint8 {
+			return ScalarBinary
+		}
+	case reflect.Struct:
+		if fieldType == timeType {
+			if FieldOptions(field).Has(ttl) || hasUnixTime(field) {
+				return ScalarNumber
+			}
+
+			return ScalarString
+		}
+	}
+
+	return ""
+}
+
+// ScalarType devolve o tipo escalar de um atributo com a tag type, ou ""
+// quando o atributo não tem a tag ou não é gravado como S, N ou B
+func (m *TagsModel) ScalarType(attribute string) string {
+	return m.Scalars[attribute]
+}
+
+// hasUnixTime indica se o campo tem a opção dynamodbav:",unixtime"
+func hasUnixTime(field reflect.StructField) bool {
+	for _, option := range strings.Split(field.Tag.Get("dynamodbav"), ",")[1:] {
+		if option == "unixtime" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -27,6 +27,8 @@ type (
 		GetHash() string
 		GetRange() string
 		GetType(key string) reflect.Kind
+		GetRules(key string) []Rule
+		GetAttributeName(key string) string
 	}
 )
 
@@ -75,6 +77,17 @@ func (t *TagManager) GetType(key string) reflect.Kind {
 	return t.TagMapper.GetType(key)
 }
 
+// GetRules recupera as regras de validação de uma key específica
+func (t *TagManager) GetRules(key string) []Rule {
+	return t.TagMapper.GetRules(key)
+}
+
+// GetAttributeName traduz o nome de um campo da estrutura para o nome do
+// atributo no DynamoDB
+func (t *TagManager) GetAttributeName(key string) string {
+	return t.TagMapper.GetAttributeName(key)
+}
+
 // GetMapper é um método para recuperar o TagMapper
 // dentro de TagManager
 func (t *TagManager) GetMapper() TagMapperInterface {
@@ -93,7 +106,8 @@ type ExampleEntity struct {
 	Owner        string `diinamo:"type:string;gsi:CourseOwnerIndex;keyPairs:PK=Owner"`
 	Title        string `diinamo:"type:string;gsi:CourseTitleIndex;keyPairs:Title=SK"`
 	ParentCourse string `diinamo:"type:string;gsi:CourseLessonsIndex;keyPairs:ParentCourse=SK"`
-	ParentModule string `diinamo:"type:string;lsi:ModuleLessonsIndex;keyPairs:ParentModule=SK"`
+	ParentModule string `diinamo:"type:string;lsi:ModuleLessonsIndex;keyPairs:PK=ParentModule"`
+	Status       string `diinamo:"type:string;required;enum:DRAFT|PUBLISHED"`
 }
 
 // This é um método apenas de teste para ExampleEntity
@@ -1,8 +1,10 @@
 package tagManager
 
 import (
-	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,34 +23,75 @@ type (
 		WriteCapacity uint
 	}
 
+	// Projection são os atributos copiados para um índice secundário.
+	// Type é ALL, KEYS_ONLY ou INCLUDE, e NonKeyAttributes são os
+	// atributos além das chaves no INCLUDE. Vazio equivale a ALL
+	Projection struct {
+		Type             string
+		NonKeyAttributes []string
+	}
+
 	// GlobalSecIndex é o formato de um Global Secondary Index (GSI).
-	// Essa estrutura mantém o IndexName, e o par de chaves Hash e Range
+	// Essa estrutura mantém o IndexName, o par de chaves Hash e Range e
+	// a Projection
 	GlobalSecIndex struct {
-		IndexName string
-		Hash      string
-		Range     string
+		IndexName  string
+		Hash       string
+		Range      string
+		Projection Projection
 		ProvisionedThroughput
 	}
 
 	// LocalSecIndex é o formato de um Local Secondary Index (LSI).
-	// Essa estrutura mantém o IndexName, e o par de chaves Hash e Range
+	// Essa estrutura mantém o IndexName, o par de chaves Hash e Range e
+	// a Projection
 	LocalSecIndex struct {
-		IndexName string
-		Hash      string
-		Range     string
+		IndexName  string
+		Hash       string
+		Range      string
+		Projection Projection
 		ProvisionedThroughput
 	}
 
+	// Rule é uma regra de validação declarada na tag diinamo. Param é o
+	// valor após os dois pontos. Ex: min:1 vira {Name:min Param:1}
+	Rule struct {
+		Name  string
+		Param string
+	}
+
 	// TagsModel é uma estrutura de gerenciamento de tags.
 	// Responsável por manter as Hash, RangeKeys, GSI, Types, etc.
+	// Os GSI e LSI são agrupados pelo nome do índice.
+	//
+	// Billing e Throughput são o modo de cobrança e a capacidade da
+	// tabela declarados no campo hash, vazios quando não declarados.
+	// Scalars são os tipos S, N ou B que o codec grava para os atributos
+	// com a tag type, vazio quando o atributo não é gravado como escalar
 	TagsModel struct {
 		Hash  string
 		Range string
+		TTL   string
+
+		Billing    string
+		Throughput ProvisionedThroughput
 
 		GSI []GlobalSecIndex
 		LSI []LocalSecIndex
 
-		Types map[string]reflect.Kind
+		Types   map[string]reflect.Kind
+		Scalars map[string]string
+		Rules   map[string][]Rule
+	}
+
+	// indexOptions são as opções de índice da tag de um campo
+	indexOptions struct {
+		name       string
+		hash       string
+		rangeKey   string
+		roles      []indexRole
+		projection Projection
+		throughput ProvisionedThroughput
 	}
 
 	// TagMapper é uma estrutura para gerenciar os dados das tags
@@ -72,7 +115,9 @@ type (
 		ExtractPK(tagsPair []string, field reflect.StructField) error
 		ExtractGSI(tagsPair []string, field reflect.StructField) error
 		ExtractLSI(tagsPair []string, field reflect.StructField) error
+		ExtractCapacity(tagsPair []string, field reflect.StructField) error
 		ExtractTypes(tagsPair []string, field reflect.StructField) error
+		ExtractRules(tagsPair []string, field reflect.StructField) error
 
 		GetModel() *TagsModel
 
@@ -82,17 +127,51 @@ type (
 
 // Tags
 const (
-	hash     = "hash"
-	_range   = "range"
-	gsi      = "gsi"
-	keyPairs = "keyPairs"
-	lsi      = "lsi"
-	_type    = "type"
+	hash       = "hash"
+	_range     = "range"
+	gsi        = "gsi"
+	keyPairs   = "keyPairs"
+	lsi        = "lsi"
+	_type      = "type"
+	ttl        = "ttl"
+	name       = "name"
+	projection = "projection"
+	rcu        = "rcu"
+	wcu        = "wcu"
+	billing    = "billing"
+)
+
+// Modos de cobrança da tag billing, com os valores do DynamoDB
+const (
+	BillingProvisioned   = "PROVISIONED"
+	BillingPayPerRequest = "PAY_PER_REQUEST"
+)
+
+// Tipos de Projection dos índices secundários
+const (
+	ProjectionAll      = "ALL"
+	ProjectionKeysOnly = "KEYS_ONLY"
+	ProjectionInclude  = "INCLUDE"
+
+	// MaxProjectedAttributes é o limite do DynamoDB de NonKeyAttributes
+	// somados entre todos os índices secundários da tabela
+	MaxProjectedAttributes = 100
+)
+
+// Regras de validação
+const (
+	Required = "required"
+	Min      = "min"
+	Max      = "max"
+	Enum     = "enum"
+	Pattern  = "pattern"
 )
 
 // ExtractFieldList extrai os metadados de PropertyTypes de TagMapper
-// para a estrutura.
+// para a estrutura. Uma nova chamada substitui a lista anterior
 func (t *TagMapper) ExtractFieldList() {
+	t.FieldNames, t.FieldList = nil, nil
+
 	for i := 0; i < t.PropertyTypes.NumField(); i++ {
 		field := t.PropertyTypes.Field(i)
 		t.FieldNames = append(t.FieldNames, field.Name)
@@ -111,31 +190,43 @@ func (t *TagMapper) RunMap() error {
 		t.ExtractPK,
 		t.ExtractGSI,
 		t.ExtractLSI,
+		t.ExtractCapacity,
 		t.ExtractTypes,
+		t.ExtractRules,
 	); err != nil {
 		return err
 	}
 
+	if err := t.checkIndexes(); err != nil {
+		return err
+	}
+
 	t.Debug("extraction complete. %v spent\n", time.Since(started))
 
 	return nil
 }
 
 // TagsLoop é o método que faz a iteração nos campos da Struct recebida
-// em TagManager.SetEntity do TagManager
+// em TagManager.SetEntity do TagManager. A tag de cada campo é verificada
+// por ParseTag antes dos TagHandler, e os erros voltam como *TagError
+// com a estrutura e o campo. Opções desconhecidas geram um aviso
 func (t *TagMapper) TagsLoop(cases ...TagHandler) error {
 	for _, field := range t.FieldList {
 		if inlineTags, ok := field.Tag.Lookup("diinamo"); ok {
 			// inlineTags se parece com:
 			// 	type:string;hash
-			tagsPair := strings.Split(inlineTags, ";")
-
-			if len(cases) > 0 {
-				for _, useCase := range cases {
-					err := useCase(tagsPair, field)
-					if err != nil {
-						return err
-					}
+			tagsPair, unknown, err := ParseTag(inlineTags)
+			if err != nil {
+				return t.tagError(field, err)
+			}
+
+			for _, option := range unknown {
+				t.Warn("unknown diinamo option %q in %s.%s is ignored\n", option, t.structName(), field.Name)
+			}
+
+			for _, useCase := range cases {
+				if err := useCase(tagsPair, field); err != nil {
+					return t.tagError(field, err)
 				}
 			}
 		}
@@ -144,8 +235,30 @@ func (t *TagMapper) TagsLoop(cases ...TagHandler) error {
 	return nil
 }
 
+// tagError completa o *TagError de ParseTag, ou envolve o erro de um
+// TagHandler, com a estrutura e o campo
+func (t *TagMapper) tagError(field reflect.StructField, err error) error {
+	tagErr, ok := err.(*TagError)
+	if !ok {
+		tagErr = &TagError{Err: err}
+	}
+
+	tagErr.Struct = t.structName()
+	tagErr.Field = field.Name
+
+	return tagErr
+}
+
+func (t *TagMapper) structName() string {
+	if t.PropertyTypes == nil {
+		return ""
+	}
+
+	return t.PropertyTypes.Name()
+}
+
 // ExtractPK é um método de extração e definição dos pares de Chave:
-// Hash e Range
+// Hash e Range. Também define o atributo de Time To Live (TTL) da tabela
 func (t *TagMapper) ExtractPK(tagsPair []string, field reflect.StructField) error {
 	for _, tag := range tagsPair {
 		if t.TagsModel == nil {
@@ -154,9 +267,11 @@ func (t *TagMapper) ExtractPK(tagsPair []string, field reflect.StructField) erro
 
 		switch tag {
 		case hash:
-			t.TagsModel.Hash = field.Name
+			t.TagsModel.Hash = AttributeName(field)
 		case _range:
-			t.TagsModel.Range = field.Name
+			t.TagsModel.Range = AttributeName(field)
+		case ttl:
+			t.TagsModel.TTL = AttributeName(field)
 		}
 	}
 
@@ -164,81 +279,354 @@ func (t *TagMapper) ExtractPK(tagsPair []string, field reflect.StructField) erro
 }
 
 // ExtractGSI é um método de extração e definição dos pares de Chave
-// dos índices secundários: Hash, Range e IndexName
+// dos índices secundários: Hash, Range, IndexName, Projection e a
+// capacidade das tags rcu e wcu, que por padrão é 1 e 1.
+//
+// Os índices declarados por papéis são agrupados pelo nome, então o hash
+// e o range de um índice podem vir de campos diferentes
 func (t *TagMapper) ExtractGSI(tagsPair []string, field reflect.StructField) error {
-	gsIndex := &GlobalSecIndex{
-		ProvisionedThroughput: ProvisionedThroughput{
-			ReadCapacity:  1,
-			WriteCapacity: 1,
-		},
+	if t.TagsModel.GSI == nil {
+		t.TagsModel.GSI = []GlobalSecIndex{}
 	}
 
 	// tagsPair se parece com:
 	// 	[gsi:TheNameOfIndex keyPairs:PK=SK]
-	// 	[gsi:TheNameOfAnotherIndex keyPairs:SK=PK]
-	for _, tag := range tagsPair {
-		tagKeyValue := strings.Split(tag, ":")
+	// 	[gsi:TheNameOfAnotherIndex keyPairs:SK=PK projection:include(A,B)]
+	// 	[gsi:TheNameOfIndex keyPairs:PK=SK rcu:5 wcu:10]
+	// 	[gsi:TheNameOfIndex=hash,TheNameOfAnotherIndex=range]
+	options, err := t.indexOptions(gsi, tagsPair, field)
+	if err != nil {
+		return err
+	}
+
+	if options.name != "" && options.hash != "" {
+		if t.globalIndex(options.name) != nil {
+			return fmt.Errorf("gsi %s is declared twice", options.name)
+		}
+
+		t.TagsModel.GSI = append(t.TagsModel.GSI, GlobalSecIndex{
+			IndexName:             options.name,
+			Hash:                  options.hash,
+			Range:                 options.rangeKey,
+			Projection:            options.projection,
+			ProvisionedThroughput: options.throughput,
+		})
+	}
+
+	for _, role := range options.roles {
+		gsIndex := t.globalIndex(role.index)
+		if gsIndex == nil {
+			t.TagsModel.GSI = append(t.TagsModel.GSI, GlobalSecIndex{
+				IndexName:             role.index,
+				ProvisionedThroughput: ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+			})
+			gsIndex = &t.TagsModel.GSI[len(t.TagsModel.GSI)-1]
+		}
+
+		if role.key == _range {
+			if err := setIndexKey(gsi, role, &gsIndex.Range, AttributeName(field)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err := setIndexKey(gsi, role, &gsIndex.Hash, AttributeName(field)); err != nil {
+			return err
+		}
+
+		gsIndex.Projection = options.projection
+		gsIndex.ProvisionedThroughput = options.throughput
+	}
+
+	return t.checkProjectedAttributes()
+}
+
+// ExtractLSI é um método de extração de definição dos pares de Chave
+// dos índices secundários locais: Hash, Range, IndexName e Projection.
+// Um lsi declarado por papel recebe o hash da tabela no final do RunMap
+func (t *TagMapper) ExtractLSI(tagsPair []string, field reflect.StructField) error {
+	if t.TagsModel.LSI == nil {
+		t.TagsModel.LSI = []LocalSecIndex{}
+	}
+
+	options, err := t.indexOptions(lsi, tagsPair, field)
+	if err != nil {
+		return err
+	}
+
+	if options.name != "" && options.hash != "" {
+		if t.localIndex(options.name) != nil {
+			return fmt.Errorf("lsi %s is declared twice", options.name)
+		}
+
+		t.TagsModel.LSI = append(t.TagsModel.LSI, LocalSecIndex{
+			IndexName:             options.name,
+			Hash:                  options.hash,
+			Range:                 options.rangeKey,
+			Projection:            options.projection,
+			ProvisionedThroughput: ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+		})
+	}
+
+	for _, role := range options.roles {
+		lsIndex := t.localIndex(role.index)
+		if lsIndex == nil {
+			t.TagsModel.LSI = append(t.TagsModel.LSI, LocalSecIndex{
+				IndexName:             role.index,
+				ProvisionedThroughput: ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+			})
+			lsIndex = &t.TagsModel.LSI[len(t.TagsModel.LSI)-1]
+		}
 
-		if t.TagsModel.GSI == nil {
-			t.TagsModel.GSI = []GlobalSecIndex{}
+		if err := setIndexKey(lsi, role, &lsIndex.Range, AttributeName(field)); err != nil {
+			return err
 		}
 
-		switch tagKeyValue[0] {
-		case gsi:
-			gsIndex.IndexName = tagKeyValue[1]
+		lsIndex.Projection = options.projection
+	}
+
+	return t.checkProjectedAttributes()
+}
+
+// indexOptions lê as opções de índice (kind gsi ou lsi) da tag de um
+// campo: o nome e o keyPairs, ou os papéis, a projection e a capacidade
+func (t *TagMapper) indexOptions(kind string, tagsPair []string, field reflect.StructField) (indexOptions, error) {
+	options := indexOptions{throughput: ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1}}
+
+	for _, tag := range tagsPair {
+		key, value, _ := splitOption(tag)
+
+		switch key {
+		case kind:
+			if !isIndexRoles(value) {
+				options.name = value
+				continue
+			}
+
+			roles, err := parseIndexRoles(kind, value)
+			if err != nil {
+				return indexOptions{}, err
+			}
+
+			options.roles = roles
 		case keyPairs:
-			hashRange := strings.Split(tagKeyValue[1], "=")
-			gsIndex.Hash = hashRange[0]
-			gsIndex.Range = hashRange[1]
+			hashKey, rangeKey, _ := splitKeyPairs(value)
+			options.hash = t.attributeName(hashKey)
+			options.rangeKey = t.attributeName(rangeKey)
+		case projection:
+			indexProjection, err := t.parseProjection(value, field)
+			if err != nil {
+				return indexOptions{}, err
+			}
+
+			options.projection = indexProjection
+		case rcu, wcu:
+			if kind != gsi {
+				continue
+			}
+
+			capacity, err := parseCapacity(key, value, field)
+			if err != nil {
+				return indexOptions{}, err
+			}
+
+			if key == rcu {
+				options.throughput.ReadCapacity = capacity
+			} else {
+				options.throughput.WriteCapacity = capacity
+			}
 		}
 	}
 
-	if len(t.TagsModel.GSI) >= 20 {
-		return errors.New("max global secondary index reached")
+	return options, nil
+}
+
+// setIndexKey define o hash ou o range de um índice declarado por papel.
+// Um índice não pode ter duas chaves com o mesmo papel
+func setIndexKey(kind string, role indexRole, key *string, attribute string) error {
+	if *key != "" && *key != attribute {
+		return fmt.Errorf("%s %s has two %s keys: %s and %s", kind, role.index, role.key, *key, attribute)
 	}
 
-	if gsIndex.IndexName != "" && gsIndex.Hash != "" {
-		t.TagsModel.GSI = append(t.TagsModel.GSI, *gsIndex)
+	*key = attribute
+
+	return nil
+}
+
+func (t *TagMapper) globalIndex(indexName string) *GlobalSecIndex {
+	for i := range t.TagsModel.GSI {
+		if t.TagsModel.GSI[i].IndexName == indexName {
+			return &t.TagsModel.GSI[i]
+		}
 	}
 
 	return nil
 }
 
-// ExtractLSI é um método de extração de definição dos pares de Chave
-// dos índices secundários locais: Hash, Range, IndexName e Projection
-func (t *TagMapper) ExtractLSI(tagsPair []string, field reflect.StructField) error {
-	lsIndex := &LocalSecIndex{
-		ProvisionedThroughput: ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+func (t *TagMapper) localIndex(indexName string) *LocalSecIndex {
+	for i := range t.TagsModel.LSI {
+		if t.TagsModel.LSI[i].IndexName == indexName {
+			return &t.TagsModel.LSI[i]
+		}
 	}
 
-	for _, tag := range tagsPair {
-		tagKeyValue := strings.Split(tag, ":")
+	return nil
+}
+
+// checkIndexes completa os lsi declarados por papel com o hash da tabela
+// e verifica se os índices agrupados têm as chaves necessárias
+func (t *TagMapper) checkIndexes() error {
+	if t.TagsModel == nil {
+		return nil
+	}
 
-		if t.TagsModel.LSI == nil {
-			t.TagsModel.LSI = []LocalSecIndex{}
+	for i := range t.TagsModel.LSI {
+		if t.TagsModel.LSI[i].Hash == "" {
+			t.TagsModel.LSI[i].Hash = t.TagsModel.Hash
 		}
+	}
 
-		switch tagKeyValue[0] {
-		case lsi:
-			lsIndex.IndexName = tagKeyValue[1]
-		case keyPairs:
-			hashRange := strings.Split(tagKeyValue[1], "=")
-			lsIndex.Hash = hashRange[0]
-			lsIndex.Range = hashRange[1]
+	for _, gsIndex := range t.TagsModel.GSI {
+		if gsIndex.Hash == "" {
+			return fmt.Errorf("diinamo tags of %s: gsi %s has no hash key, expected a field with %s=hash", t.structName(), gsIndex.IndexName, gsIndex.IndexName)
+		}
+	}
+
+	for _, lsIndex := range t.TagsModel.LSI {
+		if lsIndex.Range == "" {
+			return fmt.Errorf("diinamo tags of %s: lsi %s has no range key", t.structName(), lsIndex.IndexName)
+		}
+	}
+
+	return nil
+}
+
+// parseProjection traduz o valor da tag projection: keys, all ou
+// include(A,B,C). Os atributos do include podem ser os campos da
+// estrutura ou os nomes dos atributos
+func (t *TagMapper) parseProjection(value string, field reflect.StructField) (Projection, error) {
+	switch {
+	case value == "all":
+		return Projection{Type: ProjectionAll}, nil
+	case value == "keys":
+		return Projection{Type: ProjectionKeysOnly}, nil
+	case !strings.HasPrefix(value, "include(") || !strings.HasSuffix(value, ")"):
+		return Projection{}, fmt.Errorf("invalid projection %q of field %s, expected keys, all or include(A,B)", value, field.Name)
+	}
+
+	attributes := strings.TrimSuffix(strings.TrimPrefix(value, "include("), ")")
+	if strings.TrimSpace(attributes) == "" {
+		return Projection{}, fmt.Errorf("include projection of field %s requires the attributes", field.Name)
+	}
+
+	indexProjection := Projection{Type: ProjectionInclude}
+	seen := map[string]bool{}
+
+	for _, attribute := range strings.Split(attributes, ",") {
+		attribute = t.attributeName(strings.TrimSpace(attribute))
+		if attribute == "" || seen[attribute] {
+			return Projection{}, fmt.Errorf("invalid include projection %q of field %s", value, field.Name)
 		}
+
+		seen[attribute] = true
+		indexProjection.NonKeyAttributes = append(indexProjection.NonKeyAttributes, attribute)
+	}
+
+	return indexProjection, nil
+}
+
+// checkProjectedAttributes verifica o limite de NonKeyAttributes somados
+// entre os GSI e os LSI. Um atributo projetado em dois índices conta duas
+// vezes, como no DynamoDB
+func (t *TagMapper) checkProjectedAttributes() error {
+	total := 0
+
+	for _, gsi := range t.TagsModel.GSI {
+		total += len(gsi.Projection.NonKeyAttributes)
 	}
 
-	if len(t.TagsModel.LSI) >= 5 {
-		return errors.New("max local secondary index reached")
+	for _, lsi := range t.TagsModel.LSI {
+		total += len(lsi.Projection.NonKeyAttributes)
 	}
 
-	if lsIndex.IndexName != "" && lsIndex.Hash != "" {
-		t.TagsModel.LSI = append(t.TagsModel.LSI, *lsIndex)
+	if total > MaxProjectedAttributes {
+		return fmt.Errorf("the secondary indexes project %d non-key attributes, the limit is %d", total, MaxProjectedAttributes)
 	}
 
 	return nil
 }
 
+// ExtractCapacity é um método de extração do modo de cobrança e da
+// capacidade da tabela, declarados no campo hash. Ex:
+//
+// 	diinamo:"type:string;hash;billing:on-demand"
+// 	diinamo:"type:string;hash;rcu:5;wcu:10"
+//
+// As tags rcu e wcu de um campo com gsi são do índice. Veja ExtractGSI
+func (t *TagMapper) ExtractCapacity(tagsPair []string, field reflect.StructField) error {
+	isHash, isGSI := false, false
+	for _, tag := range tagsPair {
+		isHash = isHash || tag == hash
+		isGSI = isGSI || strings.HasPrefix(tag, gsi+":")
+	}
+
+	for _, tag := range tagsPair {
+		key, value, _ := splitOption(tag)
+
+		switch key {
+		case billing:
+			if !isHash {
+				return fmt.Errorf("billing of field %s should be declared with the hash key", field.Name)
+			}
+
+			switch value {
+			case "on-demand":
+				t.TagsModel.Billing = BillingPayPerRequest
+			case "provisioned":
+				t.TagsModel.Billing = BillingProvisioned
+			default:
+				return fmt.Errorf("invalid billing %q of field %s, expected on-demand or provisioned", value, field.Name)
+			}
+		case rcu, wcu:
+			if isGSI {
+				continue
+			}
+
+			if !isHash {
+				return fmt.Errorf("%s of field %s should be declared with the hash key or a gsi", key, field.Name)
+			}
+
+			capacity, err := parseCapacity(key, value, field)
+			if err != nil {
+				return err
+			}
+
+			if key == rcu {
+				t.TagsModel.Throughput.ReadCapacity = capacity
+			} else {
+				t.TagsModel.Throughput.WriteCapacity = capacity
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseCapacity lê o valor das tags rcu e wcu, um inteiro positivo
+func parseCapacity(key, value string, field reflect.StructField) (uint, error) {
+	if value == "" {
+		return 0, fmt.Errorf("%s of field %s requires a number", key, field.Name)
+	}
+
+	capacity, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || capacity == 0 {
+		return 0, fmt.Errorf("%s of field %s requires a positive number, got %q", key, field.Name, value)
+	}
+
+	return uint(capacity), nil
+}
+
 // ExtractTypes é um método para extrair os tipos definidos na tag diinamo
 // pela chave type
 func (t *TagMapper) ExtractTypes(tagsPair []string, field reflect.StructField) error {
@@ -247,16 +635,72 @@ func (t *TagMapper) ExtractTypes(tagsPair []string, field reflect.StructField) e
 			t.TagsModel.Types = map[string]reflect.Kind{}
 		}
 
+		if t.TagsModel.Scalars == nil {
+			t.TagsModel.Scalars = map[string]string{}
+		}
+
 		typeMeta := strings.Split(tag, ":")
 
 		switch typeMeta[0] {
 		case _type:
-			t.TagsModel.Types[field.Name] = field.Type.Kind()
+			t.TagsModel.Types[AttributeName(field)] = field.Type.Kind()
+			t.TagsModel.Scalars[AttributeName(field)] = ScalarType(field)
 		}
 	}
 	return nil
 }
 
+// ExtractRules é um método para extrair as regras de validação definidas
+// na tag diinamo: required, min:N, max:N, enum:A|B e pattern:regex.
+// Os parâmetros são verificados aqui, então uma regra inválida falha no
+// NewTable e não na primeira escrita
+func (t *TagMapper) ExtractRules(tagsPair []string, field reflect.StructField) error {
+	for _, tag := range tagsPair {
+		rule := strings.SplitN(tag, ":", 2)
+
+		switch rule[0] {
+		case Required:
+			t.appendRule(AttributeName(field), Rule{Name: Required})
+		case Min, Max:
+			if len(rule) < 2 {
+				return fmt.Errorf("%s rule of field %s requires a number", rule[0], field.Name)
+			}
+
+			if _, err := strconv.ParseFloat(rule[1], 64); err != nil {
+				return fmt.Errorf("%s rule of field %s requires a number, got %q", rule[0], field.Name, rule[1])
+			}
+
+			t.appendRule(AttributeName(field), Rule{Name: rule[0], Param: rule[1]})
+		case Enum:
+			if len(rule) < 2 || rule[1] == "" {
+				return fmt.Errorf("enum rule of field %s requires the allowed values", field.Name)
+			}
+
+			t.appendRule(AttributeName(field), Rule{Name: Enum, Param: rule[1]})
+		case Pattern:
+			if len(rule) < 2 || rule[1] == "" {
+				return fmt.Errorf("pattern rule of field %s requires a regular expression", field.Name)
+			}
+
+			if _, err := regexp.Compile(rule[1]); err != nil {
+				return fmt.Errorf("pattern rule of field %s: %v", field.Name, err)
+			}
+
+			t.appendRule(AttributeName(field), Rule{Name: Pattern, Param: rule[1]})
+		}
+	}
+
+	return nil
+}
+
+func (t *TagMapper) appendRule(attribute string, rule Rule) {
+	if t.TagsModel.Rules == nil {
+		t.TagsModel.Rules = map[string][]Rule{}
+	}
+
+	t.TagsModel.Rules[attribute] = append(t.TagsModel.Rules[attribute], rule)
+}
+
 // SetPropertyTypes define o valor de PropertyTypes
 func (t *TagMapper) SetPropertyTypes(v reflect.Type) {
 	t.PropertyTypes = v
@@ -277,6 +721,17 @@ func (t *TagMapper) GetType(key string) reflect.Kind {
 	return t.Types[key]
 }
 
+// GetRules recupera as regras de validação de uma key específica
+func (t *TagMapper) GetRules(key string) []Rule {
+	return t.Rules[key]
+}
+
+// GetAttributeName traduz o nome de um campo da estrutura para o nome do
+// atributo no DynamoDB. Veja AttributeName
+func (t *TagMapper) GetAttributeName(key string) string {
+	return t.attributeName(key)
+}
+
 // GetModel recupera o modelo de tags
 func (t *TagMapper) GetModel() *TagsModel {
 	return t.TagsModel
@@ -67,18 +67,12 @@ func TestTagMapper_RunMap(t *testing.T) {
 			Log:           logger.NewLogger(),
 		}
 
-		tm.TagsModel = &tagManager.TagsModel{}
-
-		for i := 0; i < 5; i++ {
-			tm.LSI = append(tm.LSI, tagManager.LocalSecIndex{
-				IndexName: fmt.Sprintf("index-%d", i),
-				Hash:      fmt.Sprintf("hash-%d", i),
-				Range:     fmt.Sprintf("range-%d", i),
-			})
+		tm.TagsModel = &tagManager.TagsModel{
+			GSI: []tagManager.GlobalSecIndex{{IndexName: "CourseOwnerIndex", Hash: "PK"}},
 		}
 
 		err := tm.RunMap()
-		assert.EqualError(t, err, "max local secondary index reached")
+		assert.EqualError(t, err, "diinamo tag of Mocktable.Owner: gsi CourseOwnerIndex is declared twice")
 	})
 }
 
@@ -102,7 +96,7 @@ func TestTagMapper_TagsLoop(t *testing.T) {
 
 		err := tm.TagsLoop(th.Execute)
 
-		assert.EqualError(t, err, "fail tag handler")
+		assert.EqualError(t, err, "diinamo tag of Mocktable.PK: fail tag handler")
 	})
 }
 
@@ -153,10 +147,48 @@ func TestTagMapper_ExtractGSI(t *testing.T) {
 		for _, f := range tm.FieldList {
 			if inlineTags, ok := f.Tag.Lookup("diinamo"); ok {
 				tags := strings.Split(inlineTags, ";")
-				err := tm.ExtractGSI(tags, f)
-				assert.EqualError(t, err, "max global secondary index reached")
+				assert.Nil(t, tm.ExtractGSI(tags, f))
 			}
 		}
+
+		assert.Contains(t, tm.TagsModel.Validate().Error(), "the table has 23 global secondary indexes, the limit is 20")
+	})
+}
+
+func TestTagMapper_ExtractProjection(t *testing.T) {
+	field := reflect.StructField{Name: "Owner"}
+
+	t.Run("should extract the projection of the indexes", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		assert.Nil(t, tm.ExtractGSI([]string{"gsi:OwnerIndex", "keyPairs:Owner=SK", "projection:include(Title, Status)"}, field))
+		assert.Nil(t, tm.ExtractGSI([]string{"gsi:StatusIndex", "keyPairs:Status=SK", "projection:keys"}, field))
+		assert.Nil(t, tm.ExtractLSI([]string{"lsi:TitleIndex", "keyPairs:PK=Title", "projection:all"}, field))
+
+		assert.Equal(t, tagManager.Projection{Type: tagManager.ProjectionInclude, NonKeyAttributes: []string{"Title", "Status"}}, tm.GSI[0].Projection)
+		assert.Equal(t, tagManager.Projection{Type: tagManager.ProjectionKeysOnly}, tm.GSI[1].Projection)
+		assert.Equal(t, tagManager.Projection{Type: tagManager.ProjectionAll}, tm.LSI[0].Projection)
+	})
+	t.Run("should fail with invalid projections", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		assert.EqualError(t, tm.ExtractGSI([]string{"gsi:OwnerIndex", "projection:some"}, field), `invalid projection "some" of field Owner, expected keys, all or include(A,B)`)
+		assert.EqualError(t, tm.ExtractLSI([]string{"lsi:OwnerIndex", "projection:include()"}, field), "include projection of field Owner requires the attributes")
+		assert.EqualError(t, tm.ExtractGSI([]string{"gsi:OwnerIndex", "projection:include(A,,B)"}, field), `invalid include projection "include(A,,B)" of field Owner`)
+		assert.EqualError(t, tm.ExtractGSI([]string{"gsi:OwnerIndex", "projection:include(A,A)"}, field), `invalid include projection "include(A,A)" of field Owner`)
+	})
+	t.Run("should fail when the indexes project too many attributes", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		attributes := make([]string, 51)
+		for i := range attributes {
+			attributes[i] = fmt.Sprintf("Attr%d", i)
+		}
+
+		include := fmt.Sprintf("projection:include(%s)", strings.Join(attributes, ","))
+
+		assert.Nil(t, tm.ExtractGSI([]string{"gsi:FirstIndex", "keyPairs:Owner=SK", include}, field))
+		assert.EqualError(t, tm.ExtractLSI([]string{"lsi:SecondIndex", "keyPairs:PK=Owner", include}, field), "the secondary indexes project 102 non-key attributes, the limit is 100")
 	})
 }
 
@@ -189,10 +221,44 @@ func TestTagMapper_ExtractLSI(t *testing.T) {
 		for _, f := range tm.FieldList {
 			if inlineTags, ok := f.Tag.Lookup("diinamo"); ok {
 				tags := strings.Split(inlineTags, ";")
-				err := tm.ExtractLSI(tags, f)
-				assert.EqualError(t, err, "max local secondary index reached")
+				assert.Nil(t, tm.ExtractLSI(tags, f))
 			}
 		}
+
+		assert.Contains(t, tm.TagsModel.Validate().Error(), "the table has 6 local secondary indexes, the limit is 5")
+	})
+}
+
+func TestTagMapper_ExtractCapacity(t *testing.T) {
+	field := reflect.StructField{Name: "PK"}
+
+	t.Run("should extract the billing and the capacity of the table", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		assert.Nil(t, tm.ExtractCapacity([]string{"type:string", "hash", "billing:provisioned", "rcu:5", "wcu:10"}, field))
+		assert.Equal(t, tagManager.BillingProvisioned, tm.Billing)
+		assert.Equal(t, tagManager.ProvisionedThroughput{ReadCapacity: 5, WriteCapacity: 10}, tm.Throughput)
+
+		assert.Nil(t, tm.ExtractCapacity([]string{"hash", "billing:on-demand"}, field))
+		assert.Equal(t, tagManager.BillingPayPerRequest, tm.Billing)
+	})
+	t.Run("should leave the capacity of a gsi to the index", func(t *testing.T) {
+		tm := prepareTagMapper()
+		tags := []string{"type:string", "gsi:OwnerIndex", "keyPairs:Owner=SK", "rcu:2", "wcu:3"}
+
+		assert.Nil(t, tm.ExtractCapacity(tags, field))
+		assert.Nil(t, tm.ExtractGSI(tags, field))
+		assert.Equal(t, tagManager.ProvisionedThroughput{}, tm.Throughput)
+		assert.Equal(t, tagManager.ProvisionedThroughput{ReadCapacity: 2, WriteCapacity: 3}, tm.GSI[0].ProvisionedThroughput)
+	})
+	t.Run("should fail with invalid capacity tags", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		assert.EqualError(t, tm.ExtractCapacity([]string{"hash", "billing:free"}, field), `invalid billing "free" of field PK, expected on-demand or provisioned`)
+		assert.EqualError(t, tm.ExtractCapacity([]string{"billing:on-demand"}, field), "billing of field PK should be declared with the hash key")
+		assert.EqualError(t, tm.ExtractCapacity([]string{"range", "rcu:1"}, field), "rcu of field PK should be declared with the hash key or a gsi")
+		assert.EqualError(t, tm.ExtractCapacity([]string{"hash", "wcu:0"}, field), `wcu of field PK requires a positive number, got "0"`)
+		assert.EqualError(t, tm.ExtractGSI([]string{"gsi:OwnerIndex", "rcu"}, field), "rcu of field PK requires a number")
 	})
 }
 
@@ -216,6 +282,86 @@ func TestTagMapper_ExtractTypes(t *testing.T) {
 	})
 }
 
+func TestTagMapper_ExtractRules(t *testing.T) {
+	field := reflect.StructField{Name: "Status"}
+
+	t.Run("should extract validation rules", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		err := tm.ExtractRules([]string{"type:string", "required", "min:1", "max:255", "enum:ACTIVE|PAUSED", "pattern:^[A-Z]+$"}, field)
+		assert.Nil(t, err)
+		assert.Equal(t, []tagManager.Rule{
+			{Name: "required"},
+			{Name: "min", Param: "1"},
+			{Name: "max", Param: "255"},
+			{Name: "enum", Param: "ACTIVE|PAUSED"},
+			{Name: "pattern", Param: "^[A-Z]+$"},
+		}, tm.GetRules("Status"))
+	})
+	t.Run("should keep colons in pattern", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		err := tm.ExtractRules([]string{"pattern:^[a-z]+:[0-9]+$"}, field)
+		assert.Nil(t, err)
+		assert.Equal(t, "^[a-z]+:[0-9]+$", tm.GetRules("Status")[0].Param)
+	})
+	t.Run("should fail with invalid rules", func(t *testing.T) {
+		tm := prepareTagMapper()
+
+		assert.EqualError(t, tm.ExtractRules([]string{"min:one"}, field), `min rule of field Status requires a number, got "one"`)
+		assert.EqualError(t, tm.ExtractRules([]string{"max"}, field), "max rule of field Status requires a number")
+		assert.EqualError(t, tm.ExtractRules([]string{"enum:"}, field), "enum rule of field Status requires the allowed values")
+		assert.Contains(t, tm.ExtractRules([]string{"pattern:[a-"}, field).Error(), "pattern rule of field Status")
+		assert.Nil(t, tm.GetRules("Status"))
+	})
+}
+
+func TestAttributeName(t *testing.T) {
+	type entity struct {
+		PK     string `diinamo:"type:string;hash;name:pk"`
+		SK     string `diinamo:"type:string;range;name:sk" dynamodbav:"sort"`
+		Email  string `dynamodbav:"email,omitempty"`
+		Hidden string `dynamodbav:"-"`
+		Title  string
+	}
+
+	entityType := reflect.TypeOf(entity{})
+
+	t.Run("should resolve the attribute name of each field", func(t *testing.T) {
+		names := []string{}
+		for i := 0; i < entityType.NumField(); i++ {
+			names = append(names, tagManager.AttributeName(entityType.Field(i)))
+		}
+
+		assert.Equal(t, []string{"pk", "sk", "email", "Hidden", "Title"}, names)
+		assert.True(t, tagManager.Ignored(entityType.Field(3)))
+	})
+	t.Run("should map keys and indexes with the attribute names", func(t *testing.T) {
+		type renamed struct {
+			PK    string `diinamo:"type:string;hash;name:pk"`
+			SK    string `diinamo:"type:string;range;name:sk"`
+			Owner string `diinamo:"type:string;required;name:owner_id;gsi:OwnerIndex;keyPairs:Owner=SK"`
+		}
+
+		tm := &tagManager.TagMapper{Log: logger.NewLogger()}
+		tm.SetPropertyTypes(reflect.TypeOf(renamed{}))
+
+		assert.Nil(t, tm.RunMap())
+		assert.Equal(t, "pk", tm.GetHash())
+		assert.Equal(t, "sk", tm.GetRange())
+		assert.Equal(t, []tagManager.GlobalSecIndex{{
+			IndexName:             "OwnerIndex",
+			Hash:                  "owner_id",
+			Range:                 "sk",
+			ProvisionedThroughput: tagManager.ProvisionedThroughput{ReadCapacity: 1, WriteCapacity: 1},
+		}}, tm.GSI)
+		assert.Equal(t, reflect.String, tm.GetType("owner_id"))
+		assert.Len(t, tm.GetRules("owner_id"), 1)
+		assert.Equal(t, "owner_id", tm.GetAttributeName("Owner"))
+		assert.Equal(t, "owner_id", tm.GetAttributeName("owner_id"))
+	})
+}
+
 func TestTagMapper_TagGetters(t *testing.T) {
 	t.Run("should return key tags", func(t *testing.T) {
 		tm := prepareTagMapper()
@@ -267,7 +413,7 @@ func ExampleTagMapper_ExtractGSI() {
 
 	fmt.Printf("%+v", tm.TagsModel.GSI)
 	// Output:
-	// [{IndexName:IndexName Hash:PK Range:SK ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}]
+	// [{IndexName:IndexName Hash:PK Range:SK Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}]
 }
 
 func ExampleTagMapper_ExtractLSI() {
@@ -290,7 +436,7 @@ func ExampleTagMapper_ExtractLSI() {
 
 	fmt.Printf("%+v", tm.TagsModel.LSI)
 	// Output:
-	// [{IndexName:IndexName Hash:PK Range:SK ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}]
+	// [{IndexName:IndexName Hash:PK Range:SK Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}]
 }
 
 func ExampleTagMapper_ExtractPK() {
@@ -342,6 +488,27 @@ func ExampleTagMapper_ExtractTypes() {
 	// Output: int
 }
 
+func ExampleTagMapper_ExtractRules() {
+	tm := &tagManager.TagMapper{
+		Log:       logger.NewLogger(),
+		TagsModel: new(tagManager.TagsModel),
+	}
+
+	// Seta o reflect.Type da entidade com as tags diinamo
+	tm.SetPropertyTypes(reflect.TypeOf(tagManager.ExampleEntity{}))
+	// Extrai a FieldList
+	tm.ExtractFieldList()
+
+	// Executa a extração das regras de validação
+	err := tm.ExtractRules([]string{"type:string", "required", "max:120"}, tm.PropertyTypes.Field(3))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	fmt.Printf("%+v", tm.GetRules("Title"))
+	// Output: [{Name:required Param:} {Name:max Param:120}]
+}
+
 func ExampleTagMapper_RunMap() {
 	tm := &tagManager.TagMapper{
 		Log:       logger.NewLogger(),
@@ -359,5 +526,5 @@ func ExampleTagMapper_RunMap() {
 	// GetType retorna um reflect.Kind
 	fmt.Printf("%+v", tm.TagsModel)
 	// Output:
-	// &{Hash:PK Range:SK GSI:[{IndexName:CourseOwnerIndex Hash:PK Range:Owner ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}} {IndexName:CourseTitleIndex Hash:Title Range:SK ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}} {IndexName:CourseLessonsIndex Hash:ParentCourse Range:SK ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}] LSI:[{IndexName:ModuleLessonsIndex Hash:ParentModule Range:SK ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}] Types:map[Owner:string PK:int ParentCourse:string ParentModule:string SK:string Title:string]}
+	// &{Hash:PK Range:SK TTL: Billing: Throughput:{ReadCapacity:0 WriteCapacity:0} GSI:[{IndexName:CourseOwnerIndex Hash:PK Range:Owner Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}} {IndexName:CourseTitleIndex Hash:Title Range:SK Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}} {IndexName:CourseLessonsIndex Hash:ParentCourse Range:SK Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}] LSI:[{IndexName:ModuleLessonsIndex Hash:PK Range:ParentModule Projection:{Type: NonKeyAttributes:[]} ProvisionedThroughput:{ReadCapacity:1 WriteCapacity:1}}] Types:map[Owner:string PK:int ParentCourse:string ParentModule:string SK:string Status:string Title:string] Scalars:map[Owner:S PK:N ParentCourse:S ParentModule:S SK:S Status:S Title:S] Rules:map[Status:[{Name:required Param:} {Name:enum Param:DRAFT|PUBLISHED}]]}
 }
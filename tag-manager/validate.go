@@ -0,0 +1,139 @@
+package tagManager
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limites de índices secundários por tabela do DynamoDB
+const (
+	MaxGlobalSecondaryIndexes = 20
+	MaxLocalSecondaryIndexes  = 5
+)
+
+type (
+	// SchemaError é o erro de Validate com todas as regras do DynamoDB
+	// violadas pelo modelo, na ordem em que foram encontradas
+	SchemaError struct {
+		Violations []string
+	}
+
+	// keyUsage é um atributo de chave e o schema em que ele aparece
+	keyUsage struct {
+		attribute string
+		schema    string
+	}
+)
+
+// Error implementa a interface error
+func (e *SchemaError) Error() string {
+	return "invalid schema: " + strings.Join(e.Violations, "; ")
+}
+
+// Validate verifica o modelo contra as regras do DynamoDB que o
+// CreateTable recusaria com mensagens vagas: a falta de hash key, chaves
+// sem a tag type ou que o codec não grava como S, N ou B, LSI com hash
+// diferente do hash da tabela, nomes de índice repetidos e os limites de
+// GSI e LSI. Retorna um *SchemaError com todas as violações
+func (m *TagsModel) Validate() error {
+	if m == nil {
+		return &SchemaError{Violations: []string{"the table has no hash key, tag a field with hash"}}
+	}
+
+	var violations []string
+
+	if m.Hash == "" {
+		violations = append(violations, "the table has no hash key, tag a field with hash")
+	}
+
+	if len(m.GSI) > MaxGlobalSecondaryIndexes {
+		violations = append(violations, fmt.Sprintf("the table has %d global secondary indexes, the limit is %d", len(m.GSI), MaxGlobalSecondaryIndexes))
+	}
+
+	if len(m.LSI) > MaxLocalSecondaryIndexes {
+		violations = append(violations, fmt.Sprintf("the table has %d local secondary indexes, the limit is %d", len(m.LSI), MaxLocalSecondaryIndexes))
+	}
+
+	indexes := map[string]int{}
+	for _, gsi := range m.GSI {
+		indexes[gsi.IndexName]++
+	}
+
+	for _, lsi := range m.LSI {
+		indexes[lsi.IndexName]++
+
+		if m.Hash != "" && lsi.Hash != m.Hash {
+			violations = append(violations, fmt.Sprintf("lsi %s has the hash key %s, expected the table hash key %s", lsi.IndexName, lsi.Hash, m.Hash))
+		}
+	}
+
+	reported := map[string]bool{}
+	for _, name := range m.indexNames() {
+		if indexes[name] > 1 && !reported[name] {
+			reported[name] = true
+			violations = append(violations, fmt.Sprintf("the index name %s is used by %d indexes", name, indexes[name]))
+		}
+	}
+
+	checked := map[string]bool{}
+	for _, key := range m.keyUsages() {
+		if key.attribute == "" || checked[key.attribute] {
+			continue
+		}
+
+		checked[key.attribute] = true
+
+		if violation := m.checkKeyType(key); violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &SchemaError{Violations: violations}
+	}
+
+	return nil
+}
+
+// checkKeyType verifica se o atributo de chave tem a tag type e é gravado
+// pelo codec como S, N ou B. Veja ScalarType
+func (m *TagsModel) checkKeyType(key keyUsage) string {
+	kind, ok := m.Types[key.attribute]
+	if !ok {
+		return fmt.Sprintf("key %s of %s has no type tag", key.attribute, key.schema)
+	}
+
+	if m.Scalars[key.attribute] == "" {
+		return fmt.Sprintf("key %s of %s is a %s, expected a string, number or binary", key.attribute, key.schema, kind)
+	}
+
+	return ""
+}
+
+// keyUsages lista os atributos de chave da tabela, dos GSI e dos LSI
+func (m *TagsModel) keyUsages() []keyUsage {
+	keys := []keyUsage{{m.Hash, "the table"}, {m.Range, "the table"}}
+
+	for _, gsi := range m.GSI {
+		keys = append(keys, keyUsage{gsi.Hash, "gsi " + gsi.IndexName}, keyUsage{gsi.Range, "gsi " + gsi.IndexName})
+	}
+
+	for _, lsi := range m.LSI {
+		keys = append(keys, keyUsage{lsi.Hash, "lsi " + lsi.IndexName}, keyUsage{lsi.Range, "lsi " + lsi.IndexName})
+	}
+
+	return keys
+}
+
+func (m *TagsModel) indexNames() []string {
+	names := make([]string, 0, len(m.GSI)+len(m.LSI))
+	for _, gsi := range m.GSI {
+		names = append(names, gsi.IndexName)
+	}
+
+	for _, lsi := range m.LSI {
+		names = append(names, lsi.IndexName)
+	}
+
+	return names
+}
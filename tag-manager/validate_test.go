@@ -0,0 +1,170 @@
+package tagManager_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/logger"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func validModel() *tagManager.TagsModel {
+	return &tagManager.TagsModel{
+		Hash:  "PK",
+		Range: "SK",
+		GSI:   []tagManager.GlobalSecIndex{{IndexName: "OwnerIndex", Hash: "Owner", Range: "SK"}},
+		LSI:   []tagManager.LocalSecIndex{{IndexName: "DateIndex", Hash: "PK", Range: "CreatedAt"}},
+		Types: map[string]reflect.Kind{
+			"PK":        reflect.String,
+			"SK":        reflect.String,
+			"Owner":     reflect.String,
+			"CreatedAt": reflect.Int64,
+		},
+		Scalars: map[string]string{"PK": "S", "SK": "S", "Owner": "S", "CreatedAt": "N"},
+	}
+}
+
+func TestTagsModel_Validate(t *testing.T) {
+	t.Run("should accept a valid model", func(t *testing.T) {
+		assert.Nil(t, validModel().Validate())
+	})
+	t.Run("should accept the ExampleEntity", func(t *testing.T) {
+		tm := &tagManager.TagMapper{Log: logger.NewLogger(), TagsModel: new(tagManager.TagsModel)}
+		tm.SetPropertyTypes(reflect.TypeOf(tagManager.ExampleEntity{}))
+
+		assert.Nil(t, tm.RunMap())
+		assert.Nil(t, tm.TagsModel.Validate())
+	})
+	t.Run("should fail without hash key", func(t *testing.T) {
+		model := validModel()
+		model.Hash = ""
+		model.LSI = nil
+
+		assert.EqualError(t, model.Validate(), "invalid schema: the table has no hash key, tag a field with hash")
+		assert.EqualError(t, (*tagManager.TagsModel)(nil).Validate(), "invalid schema: the table has no hash key, tag a field with hash")
+	})
+	t.Run("should fail with a lsi on another hash key", func(t *testing.T) {
+		model := validModel()
+		model.LSI[0].Hash = "Owner"
+
+		assert.EqualError(t, model.Validate(), "invalid schema: lsi DateIndex has the hash key Owner, expected the table hash key PK")
+	})
+	t.Run("should fail with keys without type or with invalid types", func(t *testing.T) {
+		model := validModel()
+		delete(model.Types, "Owner")
+		model.Types["CreatedAt"] = reflect.Bool
+		model.Scalars["CreatedAt"] = ""
+
+		assert.EqualError(t, model.Validate(), "invalid schema: key Owner of gsi OwnerIndex has no type tag; key CreatedAt of lsi DateIndex is a bool, expected a string, number or binary")
+	})
+	t.Run("should fail with duplicated index names", func(t *testing.T) {
+		model := validModel()
+		model.LSI[0].IndexName = "OwnerIndex"
+
+		assert.EqualError(t, model.Validate(), "invalid schema: the index name OwnerIndex is used by 2 indexes")
+	})
+	t.Run("should fail over the index limits", func(t *testing.T) {
+		model := validModel()
+		model.GSI, model.LSI = nil, nil
+
+		for i := 0; i <= tagManager.MaxGlobalSecondaryIndexes; i++ {
+			model.GSI = append(model.GSI, tagManager.GlobalSecIndex{IndexName: fmt.Sprintf("GSI%d", i), Hash: "Owner"})
+		}
+
+		for i := 0; i <= tagManager.MaxLocalSecondaryIndexes; i++ {
+			model.LSI = append(model.LSI, tagManager.LocalSecIndex{IndexName: fmt.Sprintf("LSI%d", i), Hash: "PK", Range: "CreatedAt"})
+		}
+
+		assert.EqualError(t, model.Validate(), "invalid schema: the table has 21 global secondary indexes, the limit is 20; the table has 6 local secondary indexes, the limit is 5")
+	})
+	t.Run("should return every violation", func(t *testing.T) {
+		model := validModel()
+		model.LSI[0].Hash = "Owner"
+		model.LSI[0].IndexName = "OwnerIndex"
+
+		err := model.Validate()
+
+		var schemaErr *tagManager.SchemaError
+		if assert.ErrorAs(t, err, &schemaErr) {
+			assert.Len(t, schemaErr.Violations, 2)
+		}
+	})
+}
+
+type (
+	// status é gravado como N por MarshalDiinamo
+	status string
+
+	scalarEntity struct {
+		PK        string     `diinamo:"type:string;hash"`
+		CreatedAt time.Time  `diinamo:"type:string;range"`
+		ExpiresAt time.Time  `diinamo:"type:number;ttl"`
+		SyncedAt  time.Time  `diinamo:"type:number" dynamodbav:",unixtime"`
+		Version   *int       `diinamo:"type:number"`
+		UpdatedAt *time.Time `diinamo:"type:string"`
+		Checksum  []byte     `diinamo:"type:binary"`
+		Hash      [16]byte   `diinamo:"type:binary"`
+		Status    status     `diinamo:"type:number"`
+		Tags      []string   `diinamo:"type:string"`
+		Owner     struct{}   `diinamo:"type:string"`
+		Meta      map[string]string
+	}
+)
+
+func (s status) MarshalDiinamo() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: "1"}, nil
+}
+
+func TestScalarType(t *testing.T) {
+	entity := reflect.TypeOf(scalarEntity{})
+
+	cases := map[string]string{
+		"PK":        tagManager.ScalarString,
+		"CreatedAt": tagManager.ScalarString,
+		"ExpiresAt": tagManager.ScalarNumber,
+		"SyncedAt":  tagManager.ScalarNumber,
+		"Version":   tagManager.ScalarNumber,
+		"UpdatedAt": tagManager.ScalarString,
+		"Checksum":  tagManager.ScalarBinary,
+		"Hash":      tagManager.ScalarBinary,
+		"Status":    tagManager.ScalarNumber,
+		"Tags":      "",
+		"Owner":     "",
+		"Meta":      "",
+	}
+
+	for name, expected := range cases {
+		field, _ := entity.FieldByName(name)
+		assert.Equal(t, expected, tagManager.ScalarType(field), name)
+	}
+}
+
+func TestTagsModel_ValidateKeyTypes(t *testing.T) {
+	t.Run("should accept time, pointers and marshalers as keys", func(t *testing.T) {
+		type item struct {
+			PK        *int      `diinamo:"type:number;hash"`
+			CreatedAt time.Time `diinamo:"type:string;range"`
+			Status    status    `diinamo:"type:number;gsi:StatusIndex;keyPairs:Status=CreatedAt"`
+		}
+
+		tm := &tagManager.TagMapper{PropertyTypes: reflect.TypeOf(item{}), Log: logger.NewLogger()}
+
+		assert.Nil(t, tm.RunMap())
+		assert.Nil(t, tm.TagsModel.Validate())
+	})
+	t.Run("should reject a list of strings as key", func(t *testing.T) {
+		type item struct {
+			PK   string   `diinamo:"type:string;hash"`
+			Tags []string `diinamo:"type:string;range"`
+		}
+
+		tm := &tagManager.TagMapper{PropertyTypes: reflect.TypeOf(item{}), Log: logger.NewLogger()}
+
+		assert.Nil(t, tm.RunMap())
+		assert.EqualError(t, tm.TagsModel.Validate(), "invalid schema: key Tags of the table is a slice, expected a string, number or binary")
+	})
+}
@@ -0,0 +1,15 @@
+/*
+Package testkit
+
+pacote criado para testes de integração com tabelas isoladas por teste.
+Cada teste recebe uma tabela com nome exclusivo, então pacotes rodando em
+paralelo podem compartilhar o mesmo DynamoDB Local:
+
+	func TestCreateUser(t *testing.T) {
+		client := testkit.New(t, table.MustNewTable("users", User{}), "http://localhost:8000",
+			domain.FixtureFile("testdata/users.json"),
+		)
+		...
+	}
+*/
+package testkit
@@ -0,0 +1,121 @@
+package testkit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/bootstrap"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/domain"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+)
+
+// Environment é o ambiente dos clients criados pelo testkit
+const Environment = domain.Environment("testing")
+
+var (
+	// ActiveTimeout é o tempo máximo de espera pela tabela ficar ACTIVE
+	ActiveTimeout = 2 * time.Minute
+
+	// invalidTableChars são os caracteres não aceitos em nomes de tabela
+	invalidTableChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+)
+
+// New cria uma tabela exclusiva para o teste no DynamoDB do endpoint
+// (ex: DynamoDB Local), aguarda ela ficar ACTIVE, grava as fixtures e
+// retorna um DynamoClient pronto. A tabela é removida em t.Cleanup.
+//
+// O nome da tabela é o TableName de tb com o nome do teste e um sufixo
+// aleatório, então pacotes rodando em paralelo podem compartilhar o mesmo
+// DynamoDB Local. As fixtures aceitam os mesmos valores de
+// DynamoClient.Seed.
+//
+// Quando endpoint é vazio, DYNAMODB_ENDPOINT é usado e, se também não
+// estiver definido, o teste é ignorado com t.Skip
+func New(t testing.TB, tb *table.Table, endpoint string, fixtures ...interface{}) *drivers.DynamoClient {
+	t.Helper()
+
+	if endpoint == "" {
+		endpoint = os.Getenv(bootstrap.EndpointEnv)
+	}
+
+	if endpoint == "" {
+		t.Skipf("%s is not set", bootstrap.EndpointEnv)
+	}
+
+	client := dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("local", "local", ""),
+		EndpointResolver: dynamodb.EndpointResolverFromURL(endpoint),
+	})
+
+	return NewWithClient(t, tb, client, fixtures...)
+}
+
+// NewWithClient é o New para um *dynamodb.Client já configurado
+func NewWithClient(t testing.TB, tb *table.Table, client *dynamodb.Client, fixtures ...interface{}) *drivers.DynamoClient {
+	t.Helper()
+
+	isolated := *tb
+	isolated.TableName = TableName(t, tb.TableName)
+
+	d := drivers.NewDynamoClient(context.Background(), &domain.Config{
+		TableName:   isolated.TableName,
+		Environment: Environment,
+		Client:      client,
+		Table:       &isolated,
+	})
+
+	if err := d.CreateTable(); err != nil {
+		t.Fatalf("testkit: create table %s: %v", isolated.TableName, err)
+	}
+
+	t.Cleanup(func() {
+		if err := d.DropTable(); err != nil {
+			t.Errorf("testkit: drop table %s: %v", isolated.TableName, err)
+		}
+	})
+
+	waiter := dynamodb.NewTableExistsWaiter(client, func(o *dynamodb.TableExistsWaiterOptions) {
+		o.MinDelay = 100 * time.Millisecond
+		o.MaxDelay = 2 * time.Second
+	})
+
+	err := waiter.Wait(context.Background(), &dynamodb.DescribeTableInput{TableName: aws.String(isolated.TableName)}, ActiveTimeout)
+	if err != nil {
+		t.Fatalf("testkit: wait table %s: %v", isolated.TableName, err)
+	}
+
+	if len(fixtures) > 0 {
+		if err = d.Seed(fixtures...); err != nil {
+			t.Fatalf("testkit: seed table %s: %v", isolated.TableName, err)
+		}
+	}
+
+	return d
+}
+
+// TableName gera um nome de tabela exclusivo para o teste. Ex:
+// users_TestCreateUser_should_create_4f9a1c2e
+func TableName(t testing.TB, base string) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatalf("testkit: random suffix: %v", err)
+	}
+
+	testName := invalidTableChars.ReplaceAllString(t.Name(), "_")
+	if len(testName) > 64 {
+		testName = testName[:64]
+	}
+
+	return fmt.Sprintf("%s_%s_%s", base, testName, hex.EncodeToString(suffix))
+}
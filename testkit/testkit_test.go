@@ -0,0 +1,58 @@
+package testkit_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/drivers"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/expressions"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/inmemory"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/testkit"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	PK    string `diinamo:"type:string;hash"`
+	SK    string `diinamo:"type:string;range"`
+	Email string
+}
+
+func listTables(t *testing.T, server *inmemory.Server) []string {
+	out, err := server.NewClient().ListTables(context.Background(), &dynamodb.ListTablesInput{})
+	assert.Nil(t, err)
+
+	return out.TableNames
+}
+
+func TestNew(t *testing.T) {
+	server := inmemory.NewServer()
+	defer server.Close()
+
+	tb := table.MustNewTable("users", user{})
+
+	t.Run("should isolate the data of each test", func(t *testing.T) {
+		first := testkit.New(t, tb, server.URL, user{PK: "ORG#1", SK: "USER#1", Email: "jane@example.com"})
+		second := testkit.New(t, tb, server.URL)
+
+		assert.NotEqual(t, *first.TableName, *second.TableName)
+		assert.True(t, strings.HasPrefix(*first.TableName, "users_TestNew_should_isolate_the_data_of_each_test_"))
+		assert.Equal(t, "users", tb.TableName)
+
+		var found []user
+		sql := first.NewExpressionBuilder().Where(expressions.NewKeyCondition("PK", "ORG#1"))
+
+		assert.Nil(t, first.Perform(drivers.QUERY, sql, &found))
+		assert.Len(t, found, 1)
+
+		var empty []user
+		assert.Nil(t, second.Perform(drivers.QUERY, sql, &empty))
+		assert.Empty(t, empty)
+		assert.Len(t, listTables(t, server), 2)
+	})
+	t.Run("should drop the tables on cleanup", func(t *testing.T) {
+		assert.Empty(t, listTables(t, server))
+	})
+}
@@ -0,0 +1,17 @@
+/*
+Package validation
+
+pacote criado para verificar as regras de validação declaradas na tag
+diinamo antes das escritas:
+
+	type Campaign struct {
+		PK     string `diinamo:"type:string;hash"`
+		Name   string `diinamo:"type:string;required;min:1;max:255"`
+		Status string `diinamo:"type:string;enum:ACTIVE|PAUSED"`
+		Slug   string `diinamo:"type:string;pattern:^[a-z0-9-]+$"`
+	}
+
+Todas as violações são reunidas em um *Error, que pode ser devolvido
+direto como 400 pelo API Gateway com Error.Response
+*/
+package validation
@@ -0,0 +1,244 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	tagManager "github.com/startup-of-zero-reais/dynamo-for-lambda/tag-manager"
+)
+
+type (
+	// Violation é uma regra da tag diinamo não atendida por um campo
+	Violation struct {
+		Field   string `json:"field"`
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+
+	// Error é o erro de validação de um item com todas as violações
+	// encontradas, na ordem dos campos da estrutura
+	Error struct {
+		Violations []Violation `json:"violations"`
+	}
+)
+
+// patterns guarda as expressões de pattern já compiladas
+var patterns sync.Map
+
+// Error implementa a interface error
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, fmt.Sprintf("%s %s", violation.Field, violation.Message))
+	}
+
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// MarshalJSON serializa o erro no corpo devolvido por Response
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message    string      `json:"message"`
+		Violations []Violation `json:"violations"`
+	}{
+		Message:    "validation failed",
+		Violations: e.Violations,
+	})
+}
+
+// Response monta a resposta 400 do API Gateway com as violações. Ex:
+//
+// 	if errors.As(err, &validationErr) {
+// 		return validationErr.Response(), nil
+// 	}
+func (e *Error) Response() events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(e)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: http.StatusBadRequest,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
+
+// Struct verifica as regras de todos os campos de item. Retorna um
+// *Error quando alguma regra não é atendida. As violações usam o nome do
+// atributo no DynamoDB, o mesmo de Fields
+func Struct(metadata tagManager.TagGetters, item interface{}) error {
+	value := reflect.ValueOf(item)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var violations []Violation
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if tagManager.Ignored(field) {
+			continue
+		}
+
+		attribute := tagManager.AttributeName(field)
+		violations = append(violations, check(attribute, metadata.GetRules(attribute), value.Field(i))...)
+	}
+
+	return newError(violations)
+}
+
+// Fields verifica as regras apenas dos campos informados, como os campos
+// do SET de um UPDATE parcial. Um required só falha se o campo estiver
+// presente com valor vazio
+func Fields(metadata tagManager.TagGetters, fields map[string]interface{}) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var violations []Violation
+	for _, name := range names {
+		violations = append(violations, check(name, metadata.GetRules(name), reflect.ValueOf(fields[name]))...)
+	}
+
+	return newError(violations)
+}
+
+func newError(violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &Error{Violations: violations}
+}
+
+// check verifica as regras de um campo. Um ponteiro nil só viola o
+// required, as demais regras precisam de um valor
+func check(field string, rules []tagManager.Rule, value reflect.Value) []Violation {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			value = reflect.Value{}
+			break
+		}
+
+		value = value.Elem()
+	}
+
+	var violations []Violation
+	for _, rule := range rules {
+		if !value.IsValid() {
+			if rule.Name == tagManager.Required {
+				violations = append(violations, Violation{Field: field, Rule: rule.Name, Message: "is required"})
+			}
+
+			continue
+		}
+
+		if message := checkRule(rule, value); message != "" {
+			violations = append(violations, Violation{Field: field, Rule: rule.Name, Message: message})
+		}
+	}
+
+	return violations
+}
+
+// checkRule retorna a mensagem da violação ou vazio quando a regra é
+// atendida
+func checkRule(rule tagManager.Rule, value reflect.Value) string {
+	switch rule.Name {
+	case tagManager.Required:
+		if value.IsZero() {
+			return "is required"
+		}
+	case tagManager.Min, tagManager.Max:
+		return checkLimit(rule, value)
+	case tagManager.Enum:
+		allowed := strings.Split(rule.Param, "|")
+		current := fmt.Sprint(value.Interface())
+
+		for _, option := range allowed {
+			if option == current {
+				return ""
+			}
+		}
+
+		return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))
+	case tagManager.Pattern:
+		if !compile(rule.Param).MatchString(fmt.Sprint(value.Interface())) {
+			return fmt.Sprintf("must match %s", rule.Param)
+		}
+	}
+
+	return ""
+}
+
+// checkLimit verifica min e max: o número de caracteres de uma string, o
+// valor de um número ou o tamanho de uma lista ou mapa
+func checkLimit(rule tagManager.Rule, value reflect.Value) string {
+	limit, _ := strconv.ParseFloat(rule.Param, 64)
+
+	var size float64
+	var unit string
+
+	switch value.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(value.String())), " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size, unit = float64(value.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = value.Float()
+	default:
+		return fmt.Sprintf("%s rule does not support %s", rule.Name, value.Kind())
+	}
+
+	if rule.Name == tagManager.Min && size < limit {
+		if unit != "" {
+			return fmt.Sprintf("must have at least %s%s", rule.Param, unit)
+		}
+
+		return fmt.Sprintf("must be greater than or equal to %s", rule.Param)
+	}
+
+	if rule.Name == tagManager.Max && size > limit {
+		if unit != "" {
+			return fmt.Sprintf("must have at most %s%s", rule.Param, unit)
+		}
+
+		return fmt.Sprintf("must be less than or equal to %s", rule.Param)
+	}
+
+	return ""
+}
+
+// compile compila o pattern uma única vez. O pattern já foi verificado
+// pelo TagMapper
+func compile(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+
+	return re
+}
@@ -0,0 +1,93 @@
+package validation_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/table"
+	"github.com/startup-of-zero-reais/dynamo-for-lambda/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+type campaign struct {
+	PK     string   `diinamo:"type:string;hash"`
+	Name   string   `diinamo:"type:string;required;min:3;max:10"`
+	Status string   `diinamo:"type:string;enum:ACTIVE|PAUSED"`
+	Slug   string   `diinamo:"type:string;pattern:^[a-z0-9-]+$"`
+	Budget int      `diinamo:"type:number;min:1;max:1000"`
+	Tags   []string `diinamo:"max:2"`
+	Owner  *string  `diinamo:"type:string;required"`
+}
+
+func TestStruct(t *testing.T) {
+	metadata := table.MustNewTable("campaigns", campaign{}).GetMetadata()
+	owner := "jane"
+
+	t.Run("should accept a valid item", func(t *testing.T) {
+		item := campaign{PK: "C#1", Name: "Launch", Status: "ACTIVE", Slug: "launch-2022", Budget: 10, Owner: &owner}
+
+		assert.Nil(t, validation.Struct(metadata, item))
+		assert.Nil(t, validation.Struct(metadata, &item))
+	})
+	t.Run("should list every violation in field order", func(t *testing.T) {
+		item := campaign{Name: "Jo", Status: "DONE", Slug: "Not Valid", Budget: 5000, Tags: []string{"a", "b", "c"}}
+
+		err := validation.Struct(metadata, item)
+
+		var validationErr *validation.Error
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Name", Rule: "min", Message: "must have at least 3 characters"},
+			{Field: "Status", Rule: "enum", Message: "must be one of ACTIVE, PAUSED"},
+			{Field: "Slug", Rule: "pattern", Message: "must match ^[a-z0-9-]+$"},
+			{Field: "Budget", Rule: "max", Message: "must be less than or equal to 1000"},
+			{Field: "Tags", Rule: "max", Message: "must have at most 2 items"},
+			{Field: "Owner", Rule: "required", Message: "is required"},
+		}, validationErr.Violations)
+		assert.Contains(t, err.Error(), "validation failed: Name must have at least 3 characters; Status must be one of ACTIVE, PAUSED")
+	})
+	t.Run("should count characters instead of bytes", func(t *testing.T) {
+		item := campaign{Name: "ação", Status: "PAUSED", Slug: "a", Budget: 1, Owner: &owner}
+
+		assert.Nil(t, validation.Struct(metadata, item))
+	})
+}
+
+func TestFields(t *testing.T) {
+	metadata := table.MustNewTable("campaigns", campaign{}).GetMetadata()
+
+	t.Run("should check only the informed fields", func(t *testing.T) {
+		assert.Nil(t, validation.Fields(metadata, map[string]interface{}{"Status": "PAUSED"}))
+	})
+	t.Run("should check decoded numbers and empty values", func(t *testing.T) {
+		err := validation.Fields(metadata, map[string]interface{}{"Name": "", "Budget": float64(0)})
+
+		var validationErr *validation.Error
+		assert.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []validation.Violation{
+			{Field: "Budget", Rule: "min", Message: "must be greater than or equal to 1"},
+			{Field: "Name", Rule: "required", Message: "is required"},
+			{Field: "Name", Rule: "min", Message: "must have at least 3 characters"},
+		}, validationErr.Violations)
+	})
+}
+
+func TestError_Response(t *testing.T) {
+	err := &validation.Error{Violations: []validation.Violation{
+		{Field: "Name", Rule: "required", Message: "is required"},
+	}}
+
+	response := err.Response()
+
+	assert.Equal(t, 400, response.StatusCode)
+	assert.Equal(t, "application/json", response.Headers["Content-Type"])
+
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &body))
+	assert.Equal(t, map[string]interface{}{
+		"message": "validation failed",
+		"violations": []interface{}{
+			map[string]interface{}{"field": "Name", "rule": "required", "message": "is required"},
+		},
+	}, body)
+}